@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultAuditName is the name of audit loggers, if no names are given.
+const defaultAuditName = "audit"
+
+// auditCore routes the entries of the given loggers and their descendants
+// to the audit core, bypassing the level and sampling of the other core.
+type auditCore struct {
+	zapcore.Core
+	audit zapcore.Core
+	names []string
+}
+
+func (c *auditCore) Enabled(level zapcore.Level) bool {
+	return c.Core.Enabled(level) || c.audit.Enabled(level)
+}
+
+func (c *auditCore) With(fields []zapcore.Field) zapcore.Core {
+	return &auditCore{Core: c.Core.With(fields), audit: c.audit.With(fields), names: c.names}
+}
+
+func (c *auditCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if hasLoggerPrefix(ent.LoggerName, c.names) {
+		return c.audit.Check(ent, ce)
+	}
+	return c.Core.Check(ent, ce)
+}
+
+func (c *auditCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if hasLoggerPrefix(ent.LoggerName, c.names) {
+		return c.audit.Write(ent, fields)
+	}
+	return c.Core.Write(ent, fields)
+}
+
+func (c *auditCore) Sync() error {
+	err := c.Core.Sync()
+	if aerr := c.audit.Sync(); err == nil {
+		err = aerr
+	}
+	return err
+}
+
+// syncCore syncs after each entry is written.
+type syncCore struct {
+	zapcore.Core
+}
+
+func (c *syncCore) With(fields []zapcore.Field) zapcore.Core {
+	return &syncCore{Core: c.Core.With(fields)}
+}
+
+func (c *syncCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *syncCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if err := c.Core.Write(ent, fields); err != nil {
+		return err
+	}
+	return c.Core.Sync()
+}
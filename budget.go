@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+const maxBudgetFactor = 1 << 10
+
+// byteBudget limits the bytes of encoded entries per second. Once the budget
+// of a second is spent, entries are dropped until the next second. After
+// a second in which the budget was spent, sampling is tightened such that
+// the budget is spread across the next second, and it's loosened again after
+// a second in which less than half of the budget was spent. Sampling doesn't
+// apply to errors, but the budget does.
+type byteBudget struct {
+	budget int
+	now    func() time.Time
+
+	mu     sync.Mutex
+	end    time.Time // end of the current second
+	bytes  int       // bytes in the current second
+	n      int       // sampled entries in the current second
+	factor int       // log one of factor entries
+}
+
+func newByteBudget(bytesPerSecond int, clock zapcore.Clock) *byteBudget {
+	b := &byteBudget{budget: bytesPerSecond, factor: 1, now: time.Now}
+	if clock != nil {
+		b.now = clock.Now
+	}
+	return b
+}
+
+func (b *byteBudget) allow(lvl zapcore.Level) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if now := b.now(); !now.Before(b.end) {
+		switch {
+		case b.bytes >= b.budget:
+			if b.factor < maxBudgetFactor {
+				b.factor *= 2
+			}
+		case b.bytes < b.budget/2:
+			if b.factor > 1 {
+				b.factor /= 2
+			}
+		}
+		b.end = now.Truncate(time.Second).Add(time.Second)
+		b.bytes, b.n = 0, 0
+	}
+	if b.bytes >= b.budget {
+		return false
+	}
+	if lvl >= zapcore.ErrorLevel {
+		return true
+	}
+	b.n++
+	return (b.n-1)%b.factor == 0
+}
+
+func (b *byteBudget) spend(n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.bytes += n
+}
+
+// budgetCore drops entries which aren't allowed by the budget.
+type budgetCore struct {
+	zapcore.Core
+	budget *byteBudget
+}
+
+func (c *budgetCore) With(fields []zapcore.Field) zapcore.Core {
+	return &budgetCore{Core: c.Core.With(fields), budget: c.budget}
+}
+
+func (c *budgetCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) && c.budget.allow(ent.Level) {
+		return c.Core.Check(ent, ce)
+	}
+	return ce
+}
+
+// Write drops the entry if it isn't allowed by the budget, since the core
+// may be written without being checked by cores which transform entries.
+func (c *budgetCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if !c.budget.allow(ent.Level) {
+		return nil
+	}
+	return c.Core.Write(ent, fields)
+}
+
+// budgetEncoder spends the budget on encoded entries.
+type budgetEncoder struct {
+	zapcore.Encoder
+	budget *byteBudget
+}
+
+func (enc *budgetEncoder) Clone() zapcore.Encoder {
+	return &budgetEncoder{Encoder: enc.Encoder.Clone(), budget: enc.budget}
+}
+
+func (enc *budgetEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	b, err := enc.Encoder.EncodeEntry(ent, fields)
+	if err == nil {
+		enc.budget.spend(b.Len())
+	}
+	return b, err
+}
@@ -0,0 +1,165 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// auditChainStart is the value of the chain field of the first entry
+// of a chain, which explicitly marks where the chain starts.
+const auditChainStart = "start"
+
+// auditChain is the rolling hash of the entries written to a stream.
+type auditChain struct {
+	key string
+
+	mu      sync.Mutex
+	mac     hash.Hash
+	prev    [sha256.Size]byte
+	started bool
+}
+
+func newAuditChain(key string, secret []byte) *auditChain {
+	if len(secret) == 0 {
+		return &auditChain{key: key, mac: sha256.New()}
+	}
+	return &auditChain{key: key, mac: hmac.New(sha256.New, secret)}
+}
+
+// value returns the value of the chain field of the next entry.
+func (ch *auditChain) value() string {
+	if !ch.started {
+		return auditChainStart
+	}
+	return hex.EncodeToString(ch.prev[:])
+}
+
+// next returns the hash of the previous hash and the previous entry.
+func (ch *auditChain) next(line []byte) (sum [sha256.Size]byte) {
+	ch.mac.Reset()
+	ch.mac.Write(ch.prev[:])
+	ch.mac.Write(line)
+	ch.mac.Sum(sum[:0])
+	return sum
+}
+
+// chainCore is like the core returned by zapcore.NewCore, except that it
+// encodes and writes entries one at a time, such that each entry includes
+// the rolling hash of all entries that were written to the stream before it.
+type chainCore struct {
+	zapcore.LevelEnabler
+	enc   zapcore.Encoder
+	out   zapcore.WriteSyncer
+	chain *auditChain
+}
+
+func newChainCore(enc zapcore.Encoder, ws zapcore.WriteSyncer, enab zapcore.LevelEnabler, chain *auditChain) zapcore.Core {
+	return &chainCore{LevelEnabler: enab, enc: enc, out: ws, chain: chain}
+}
+
+func (c *chainCore) With(fields []zapcore.Field) zapcore.Core {
+	enc := c.enc.Clone()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return &chainCore{LevelEnabler: c.LevelEnabler, enc: enc, out: c.out, chain: c.chain}
+}
+
+func (c *chainCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *chainCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ch := c.chain
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	fields = append(fields[:len(fields):len(fields)], zap.String(ch.key, ch.value()))
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+	if _, err := c.out.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	ch.prev = ch.next(buf.Bytes())
+	ch.started = true
+	if ent.Level > zapcore.ErrorLevel {
+		// Sync before a panic or fatal entry exits.
+		return c.out.Sync()
+	}
+	return nil
+}
+
+func (c *chainCore) Sync() error {
+	return c.out.Sync()
+}
+
+// VerifyAuditChain reads lines of entries written with WithAuditChain, the
+// same key, and the same secret, and returns an error if any of them were
+// altered, deleted, or reordered. Each line must be a whole entry, as written
+// by the JSON encoder. It returns the line numbers at which chains start,
+// including the first line. A chain only restarts at an entry which is marked
+// as the start of a chain, such as when a process appends to the file of its
+// predecessor, but its predecessor's entries may have been truncated or its
+// chain may have been spliced from elsewhere, so each restart should be
+// checked by the caller (e.g. against the process's restarts).
+func VerifyAuditChain(r io.Reader, key string, secret []byte) (starts []int, err error) {
+	chain := newAuditChain(key, secret)
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			v, ok := chainValue(line, key)
+			switch {
+			case !ok:
+				return starts, fmt.Errorf("zapr: missing audit chain at line %d", n)
+			case v == auditChainStart:
+				starts = append(starts, n)
+				chain.prev, chain.started = [sha256.Size]byte{}, true
+			case !chain.started || v != chain.value():
+				return starts, fmt.Errorf("zapr: broken audit chain at line %d", n)
+			}
+			chain.prev = chain.next(line)
+		}
+		if err == io.EOF {
+			return starts, nil
+		}
+		if err != nil {
+			return starts, err
+		}
+	}
+}
+
+// chainValue returns the string value of the key in the JSON object.
+func chainValue(line []byte, key string) (string, bool) {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(line, &m); err != nil {
+		return "", false
+	}
+	var v string
+	if err := json.Unmarshal(m[key], &v); err != nil {
+		return "", false
+	}
+	return v, true
+}
@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"bursavich.dev/zapr/output"
+)
+
+func decrypt(args []string) error {
+	fs := newFlagSet("decrypt")
+	keyFile := fs.String("key-file", "", "Path of the hex-encoded AES key.")
+	identityFile := fs.String("identity-file", "", "Path of the hex-encoded X25519 identity.")
+	fs.Parse(args)
+	if (*keyFile == "") == (*identityFile == "") {
+		return errors.New("need one of -key-file or -identity-file")
+	}
+	fn := output.Decrypt
+	name := *keyFile
+	if *identityFile != "" {
+		fn = output.DecryptWithIdentity
+		name = *identityFile
+	}
+	key, err := output.ReadKeyFile(name)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	return forEachInput(fs.Args(), func(r io.Reader) error {
+		return fn(w, r, key)
+	})
+}
+
+func keygen(args []string) error {
+	fs := newFlagSet("keygen")
+	identityFile := fs.String("identity-file", "", "Path of the new hex-encoded X25519 identity.")
+	fs.Parse(args)
+	if *identityFile == "" {
+		return errors.New("missing -identity-file")
+	}
+	identity, recipient, err := output.GenerateIdentity()
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(*identityFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(f, hex.EncodeToString(identity)); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	_, err = fmt.Println(hex.EncodeToString(recipient))
+	return err
+}
@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command zapr provides tools for working with zapr log outputs.
+//
+// Usage:
+//
+//	zapr <command> [flags] [files...]
+//
+// The commands are:
+//
+//	decrypt  decrypt encrypted log files to stdout
+//	keygen   generate an identity file and write its recipient to stdout
+//	unpack   write the entries of zstd container files to stdout
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{
+		name:  "decrypt",
+		usage: "decrypt (-key-file=<file> | -identity-file=<file>) [files...]",
+		run:   decrypt,
+	},
+	{
+		name:  "keygen",
+		usage: "keygen -identity-file=<file>",
+		run:   keygen,
+	},
+	{
+		name:  "unpack",
+		usage: "unpack [-header] [files...]",
+		run:   unpack,
+	},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	for _, cmd := range commands {
+		if cmd.name == os.Args[1] {
+			if err := cmd.run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "zapr %s: %v\n", cmd.name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	usage()
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "\tzapr %s\n", cmd.usage)
+	}
+	os.Exit(2)
+}
+
+// forEachInput calls fn with each named file, or stdin if there are none.
+func forEachInput(names []string, fn func(io.Reader) error) error {
+	if len(names) == 0 {
+		return fn(os.Stdin)
+	}
+	for _, name := range names {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		err = fn(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet("zapr "+name, flag.ExitOnError)
+}
@@ -0,0 +1,51 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+
+	"bursavich.dev/zapr/zaprzstd"
+)
+
+func unpack(args []string) error {
+	fs := newFlagSet("unpack")
+	header := fs.Bool("header", false, "Write the container headers instead of the entries.")
+	fs.Parse(args)
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	return forEachInput(fs.Args(), func(r io.Reader) error {
+		cr, err := zaprzstd.NewContainerReader(r)
+		if err != nil {
+			return err
+		}
+		defer cr.Close()
+		if *header {
+			b, err := json.Marshal(cr.Header())
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(append(b, '\n'))
+			return err
+		}
+		for {
+			b, err := cr.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if _, err := w.Write(b); err != nil {
+				return err
+			}
+		}
+	})
+}
@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"bursavich.dev/zapr/encoding"
+	"go.uber.org/zap/zapcore"
+)
+
+// Config is the struct-based configuration which preceded Options. It's kept
+// so that its users may migrate incrementally: OptionsFromConfig converts it
+// to Options, which may be mixed with other Options. Zero values are ignored,
+// leaving the defaults in place.
+//
+// Deprecated: Use Options.
+type Config struct {
+	Name  string
+	Level int
+
+	TimeKey       string
+	LevelKey      string
+	NameKey       string
+	CallerKey     string
+	FunctionKey   string
+	MessageKey    string
+	ErrorKey      string
+	StacktraceKey string
+	LineEnding    string
+
+	Encoder         encoding.Encoder
+	TimeEncoder     encoding.TimeEncoder
+	LevelEncoder    encoding.LevelEncoder
+	DurationEncoder encoding.DurationEncoder
+	CallerEncoder   encoding.CallerEncoder
+
+	DisableCaller    bool
+	EnableStacktrace bool
+	Development      bool
+
+	Sampler *SamplerConfig
+
+	WriteSyncer zapcore.WriteSyncer
+	Observer    Observer
+}
+
+// OptionsFromConfig returns the Options described by the Config.
+// A nil Config describes no Options.
+//
+// Deprecated: Use Options.
+func OptionsFromConfig(cfg *Config) []Option {
+	if cfg == nil {
+		return nil
+	}
+	var opts []Option
+	add := func(ok bool, o Option) {
+		if ok {
+			opts = append(opts, o)
+		}
+	}
+	add(cfg.Name != "", WithName(cfg.Name))
+	add(cfg.Level != 0, WithLevel(cfg.Level))
+	add(cfg.TimeKey != "", WithTimeKey(cfg.TimeKey))
+	add(cfg.LevelKey != "", WithLevelKey(cfg.LevelKey))
+	add(cfg.NameKey != "", WithNameKey(cfg.NameKey))
+	add(cfg.CallerKey != "", WithCallerKey(cfg.CallerKey))
+	add(cfg.FunctionKey != "", WithFunctionKey(cfg.FunctionKey))
+	add(cfg.MessageKey != "", WithMessageKey(cfg.MessageKey))
+	add(cfg.ErrorKey != "", WithErrorKey(cfg.ErrorKey))
+	add(cfg.StacktraceKey != "", WithStacktraceKey(cfg.StacktraceKey))
+	add(cfg.LineEnding != "", WithLineEnding(cfg.LineEnding))
+	add(cfg.Encoder != nil, WithEncoder(cfg.Encoder))
+	add(cfg.TimeEncoder != nil, WithTimeEncoder(cfg.TimeEncoder))
+	add(cfg.LevelEncoder != nil, WithLevelEncoder(cfg.LevelEncoder))
+	add(cfg.DurationEncoder != nil, WithDurationEncoder(cfg.DurationEncoder))
+	add(cfg.CallerEncoder != nil, WithCallerEncoder(cfg.CallerEncoder))
+	add(cfg.DisableCaller, WithCallerEnabled(false))
+	add(cfg.EnableStacktrace, WithStacktraceEnabled(true))
+	add(cfg.Development, WithDevelopmentOptions(true))
+	if s := cfg.Sampler; s != nil {
+		opts = append(opts, WithSampler(s.Tick, s.First, s.Thereafter))
+	}
+	add(cfg.WriteSyncer != nil, WithWriteSyncer(cfg.WriteSyncer))
+	add(cfg.Observer != nil, WithObserver(cfg.Observer))
+	return opts
+}
@@ -0,0 +1,475 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"bursavich.dev/zapr/encoding"
+	"bursavich.dev/zapr/output"
+	"github.com/go-logr/logr"
+	"go.uber.org/zap/zapcore"
+)
+
+// FileConfig is a serializable configuration, such as is read from a file
+// by ReadConfigFile. It may be converted to Options with OptionsFromFileConfig,
+// which allows it to be used along with other Options. Zero values are
+// ignored, leaving the defaults in place.
+//
+// Encoders are identified by their registered names (e.g. "json") and the
+// output is identified by its URL (e.g. "stderr"). See RegisterFlags for the
+// names of the equivalent flags.
+type FileConfig struct {
+	Name   string `json:"name,omitempty"`
+	Level  int    `json:"level,omitempty"`
+	Output string `json:"output,omitempty"`
+
+	// AuditOutput is the URL of the output to which the entries of
+	// the AuditNames loggers are written, as described by WithAuditOutput.
+	AuditOutput string   `json:"auditOutput,omitempty"`
+	AuditNames  []string `json:"auditNames,omitempty"`
+
+	// Preset is the name of a preset registered by RegisterPreset,
+	// whose Options are overridden by the rest of the FileConfig.
+	Preset string `json:"preset,omitempty"`
+
+	// VModule is a list of rules parsed by ParseVModule.
+	VModule string `json:"vmodule,omitempty"`
+
+	// NameLevels maps logger names to levels, which apply to the named
+	// loggers and their descendants, as described by WithLevelFunc.
+	NameLevels map[string]int `json:"nameLevels,omitempty"`
+
+	TimeKey         string `json:"timeKey,omitempty"`
+	LevelKey        string `json:"levelKey,omitempty"`
+	NameKey         string `json:"nameKey,omitempty"`
+	CallerKey       string `json:"callerKey,omitempty"`
+	FunctionKey     string `json:"functionKey,omitempty"`
+	MessageKey      string `json:"messageKey,omitempty"`
+	ErrorKey        string `json:"errorKey,omitempty"`
+	ErrorKindKey    string `json:"errorKindKey,omitempty"`
+	ErrorCausesKey  string `json:"errorCausesKey,omitempty"`
+	ErrorVerboseKey string `json:"errorVerboseKey,omitempty"`
+	StacktraceKey   string `json:"stacktraceKey,omitempty"`
+	GoroutineKey    string `json:"goroutineKey,omitempty"`
+	SourceKey       string `json:"sourceKey,omitempty"`
+	SequenceKey     string `json:"sequenceKey,omitempty"`
+	OriginKey       string `json:"originKey,omitempty"`
+	LineEnding      string `json:"lineEnding,omitempty"`
+
+	KeyRenames map[string]string `json:"keyRenames,omitempty"`
+	RedactKeys []string          `json:"redactKeys,omitempty"`
+
+	Encoder         string `json:"encoder,omitempty"`
+	TimeEncoder     string `json:"timeEncoder,omitempty"`
+	LevelEncoder    string `json:"levelEncoder,omitempty"`
+	DurationEncoder string `json:"durationEncoder,omitempty"`
+	CallerEncoder   string `json:"callerEncoder,omitempty"`
+	ConsoleWidth    int    `json:"consoleWidth,omitempty"`
+
+	// DuplicateKeys is the name of a DuplicateKeys policy
+	// (e.g. "allow", "last", or "suffix").
+	DuplicateKeys string `json:"duplicateKeys,omitempty"`
+
+	DisableCaller    bool `json:"disableCaller,omitempty"`
+	EnableStacktrace bool `json:"enableStacktrace,omitempty"`
+	Development      bool `json:"development,omitempty"`
+	SortFields       bool `json:"sortFields,omitempty"`
+	TraceEvents      bool `json:"traceEvents,omitempty"`
+	StrictFields     bool `json:"strictFields,omitempty"`
+	ZapFieldsAllowed bool `json:"zapFieldsAllowed,omitempty"`
+	ReopenOnSignal   bool `json:"reopenOnSignal,omitempty"`
+	SplitOutput      bool `json:"splitOutput,omitempty"`
+	ErrorStacktrace  bool `json:"errorStacktrace,omitempty"`
+
+	StacktraceLevel   string   `json:"stacktraceLevel,omitempty"`
+	StacktraceOmitted []string `json:"stacktraceOmitted,omitempty"`
+	StacktraceDepth   int      `json:"stacktraceDepth,omitempty"`
+
+	// ErrorLevel is the zap level of entries logged by Error and ErrorLevels
+	// maps logger names to overriding levels, as described by WithErrorLevels.
+	ErrorLevel  string            `json:"errorLevel,omitempty"`
+	ErrorLevels map[string]string `json:"errorLevels,omitempty"`
+
+	// ErrorReportingService and ErrorReportingVersion identify the service of
+	// Google Cloud Error Reporting events, as described by WithGoogleErrorReporting.
+	ErrorReportingService string `json:"errorReportingService,omitempty"`
+	ErrorReportingVersion string `json:"errorReportingVersion,omitempty"`
+
+	ByteBudget  int  `json:"byteBudget,omitempty"`
+	ValuesLimit int  `json:"valuesLimit,omitempty"`
+	EvictValues bool `json:"evictValues,omitempty"`
+
+	// ValuesNamespace is the key under which values are nested,
+	// as described by WithValuesNamespace.
+	ValuesNamespace string `json:"valuesNamespace,omitempty"`
+
+	// ReflectDepth and ReflectSize limit the values encoded by reflection,
+	// as described by WithReflectLimits.
+	ReflectDepth int `json:"reflectDepth,omitempty"`
+	ReflectSize  int `json:"reflectSize,omitempty"`
+
+	// MaxValueLength limits the length of string and byte values,
+	// as described by WithMaxValueLength.
+	MaxValueLength int `json:"maxValueLength,omitempty"`
+
+	BufferSize    int           `json:"bufferSize,omitempty"`
+	FlushInterval time.Duration `json:"flushInterval,omitempty"`
+
+	Sampler *SamplerConfig `json:"sampler,omitempty"`
+
+	// WriteSyncer overrides Output, if it's set.
+	WriteSyncer zapcore.WriteSyncer `json:"-"`
+	Observer    Observer            `json:"-"`
+}
+
+// SamplerConfig is the sampler configuration.
+// See WithSampler for details.
+type SamplerConfig struct {
+	Tick       time.Duration `json:"tick"`
+	First      int           `json:"first"`
+	Thereafter int           `json:"thereafter"`
+}
+
+// OptionsFromFileConfig returns the Options described by the FileConfig.
+// It returns an error if any encoder or preset names are not registered
+// or the output can't be opened. A nil FileConfig describes no Options.
+func OptionsFromFileConfig(cfg *FileConfig) ([]Option, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	var opts []Option
+	add := func(ok bool, o Option) {
+		if ok {
+			opts = append(opts, o)
+		}
+	}
+	if cfg.Preset != "" {
+		if _, ok := lookupPreset(cfg.Preset); !ok {
+			return nil, fmt.Errorf("zapr: unknown preset: %q", cfg.Preset)
+		}
+		opts = append(opts, WithPreset(cfg.Preset))
+	}
+	add(cfg.Name != "", WithName(cfg.Name))
+	add(cfg.Level != 0, WithLevel(cfg.Level))
+	add(cfg.TimeKey != "", WithTimeKey(cfg.TimeKey))
+	add(cfg.LevelKey != "", WithLevelKey(cfg.LevelKey))
+	add(cfg.NameKey != "", WithNameKey(cfg.NameKey))
+	add(cfg.CallerKey != "", WithCallerKey(cfg.CallerKey))
+	add(cfg.FunctionKey != "", WithFunctionKey(cfg.FunctionKey))
+	add(cfg.MessageKey != "", WithMessageKey(cfg.MessageKey))
+	add(cfg.ErrorKey != "", WithErrorKey(cfg.ErrorKey))
+	add(cfg.ErrorKindKey != "", WithErrorKindKey(cfg.ErrorKindKey))
+	add(cfg.ErrorCausesKey != "", WithErrorCausesKey(cfg.ErrorCausesKey))
+	add(cfg.ErrorVerboseKey != "", WithErrorVerboseKey(cfg.ErrorVerboseKey))
+	add(cfg.StacktraceKey != "", WithStacktraceKey(cfg.StacktraceKey))
+	add(cfg.GoroutineKey != "", WithGoroutineIDKey(cfg.GoroutineKey))
+	add(cfg.SourceKey != "", WithCallerSourceKey(cfg.SourceKey))
+	add(cfg.SequenceKey != "", WithSequenceKey(cfg.SequenceKey))
+	add(cfg.OriginKey != "", WithOriginKey(cfg.OriginKey))
+	add(cfg.LineEnding != "", WithLineEnding(cfg.LineEnding))
+	add(cfg.ConsoleWidth != 0, WithConsoleWidth(cfg.ConsoleWidth))
+	add(len(cfg.KeyRenames) > 0, WithKeyRenames(cfg.KeyRenames))
+	add(len(cfg.RedactKeys) > 0, WithRedactKeys(cfg.RedactKeys...))
+	add(cfg.DisableCaller, WithCallerEnabled(false))
+	add(cfg.EnableStacktrace, WithStacktraceEnabled(true))
+	add(cfg.ErrorStacktrace, WithErrorStacktrace(true))
+	add(cfg.Development, WithDevelopmentOptions(true))
+	add(cfg.SortFields, WithSortedFields(true))
+	add(cfg.TraceEvents, WithTraceEvents(true))
+	add(cfg.StrictFields, WithStrictFields(true))
+	add(cfg.ZapFieldsAllowed, WithZapFieldsAllowed(true))
+	add(cfg.ReopenOnSignal, WithReopenSignal(true))
+	add(cfg.SplitOutput, WithSplitOutput(true))
+	add(cfg.Observer != nil, WithObserver(cfg.Observer))
+	add(len(cfg.NameLevels) > 0, WithLevelFunc(copyLevels(cfg.NameLevels).level))
+	add(len(cfg.StacktraceOmitted) > 0, WithStacktraceOmitted(cfg.StacktraceOmitted...))
+	add(cfg.StacktraceDepth > 0, WithStacktraceDepth(cfg.StacktraceDepth))
+	add(cfg.ErrorReportingService != "", WithGoogleErrorReporting(cfg.ErrorReportingService, cfg.ErrorReportingVersion))
+	add(cfg.ByteBudget > 0, WithByteBudget(cfg.ByteBudget))
+	add(cfg.ValuesLimit > 0, WithValuesLimit(cfg.ValuesLimit, cfg.EvictValues))
+	add(cfg.ValuesNamespace != "", WithValuesNamespace(cfg.ValuesNamespace))
+	add(cfg.ReflectDepth > 0 || cfg.ReflectSize > 0, WithReflectLimits(cfg.ReflectDepth, cfg.ReflectSize))
+	add(cfg.MaxValueLength > 0, WithMaxValueLength(cfg.MaxValueLength))
+	add(cfg.BufferSize > 0, WithBufferedOutput(cfg.BufferSize, cfg.FlushInterval))
+	if cfg.StacktraceLevel != "" {
+		lvl, err := zapcore.ParseLevel(cfg.StacktraceLevel)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithStacktraceLevel(lvl))
+	}
+	if cfg.DuplicateKeys != "" {
+		var policy DuplicateKeys
+		if err := policy.Set(cfg.DuplicateKeys); err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithDuplicateKeys(policy))
+	}
+	if cfg.ErrorLevel != "" {
+		lvl, err := zapcore.ParseLevel(cfg.ErrorLevel)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithErrorLevel(lvl))
+	}
+	if len(cfg.ErrorLevels) > 0 {
+		levels := make(map[string]zapcore.Level, len(cfg.ErrorLevels))
+		for name, s := range cfg.ErrorLevels {
+			lvl, err := zapcore.ParseLevel(s)
+			if err != nil {
+				return nil, err
+			}
+			levels[name] = lvl
+		}
+		opts = append(opts, WithErrorLevels(levels))
+	}
+	if cfg.VModule != "" {
+		rules, err := ParseVModule(cfg.VModule)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithVModule(rules...))
+	}
+	if s := cfg.Sampler; s != nil {
+		opts = append(opts, WithSampler(s.Tick, s.First, s.Thereafter))
+	}
+
+	if cfg.Encoder != "" {
+		var e encoding.Encoder
+		if err := encoding.EncoderFlag(&e).Set(cfg.Encoder); err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithEncoder(e))
+	}
+	if cfg.TimeEncoder != "" {
+		var e encoding.TimeEncoder
+		if err := encoding.TimeEncoderFlag(&e).Set(cfg.TimeEncoder); err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithTimeEncoder(e))
+	}
+	if cfg.LevelEncoder != "" {
+		var e encoding.LevelEncoder
+		if err := encoding.LevelEncoderFlag(&e).Set(cfg.LevelEncoder); err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithLevelEncoder(e))
+	}
+	if cfg.DurationEncoder != "" {
+		var e encoding.DurationEncoder
+		if err := encoding.DurationEncoderFlag(&e).Set(cfg.DurationEncoder); err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithDurationEncoder(e))
+	}
+	if cfg.CallerEncoder != "" {
+		var e encoding.CallerEncoder
+		if err := encoding.CallerEncoderFlag(&e).Set(cfg.CallerEncoder); err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithCallerEncoder(e))
+	}
+
+	// Open the outputs last, so that they're not leaked by other errors.
+	var audit output.Output
+	if cfg.AuditOutput != "" {
+		var err error
+		if audit, err = output.Open(cfg.AuditOutput); err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithAuditOutput(audit, cfg.AuditNames...))
+	}
+	switch {
+	case cfg.WriteSyncer != nil:
+		opts = append(opts, WithWriteSyncer(cfg.WriteSyncer))
+	case cfg.Output != "":
+		out, err := output.Open(cfg.Output)
+		if err != nil {
+			if audit != nil {
+				audit.Close()
+			}
+			return nil, err
+		}
+		opts = append(opts, WithWriteSyncer(out))
+	}
+	return opts, nil
+}
+
+// OptionsFromEnv returns the Options described by environment variables, whose
+// names are derived from the names of the equivalent flags by replacing the
+// "log" prefix with the given prefix (default "ZAPR"), converting to upper case,
+// and replacing dashes with underscores. For example, the "log-level" and
+// "log-time-format" flags are set by ZAPR_LEVEL and ZAPR_TIME_FORMAT. Only the
+// Options for which a variable is set are returned. It returns an error if any
+// variable has an invalid value.
+func OptionsFromEnv(prefix string) ([]Option, error) {
+	if prefix == "" {
+		prefix = "ZAPR"
+	}
+	var opts []Option
+	for _, o := range AllOptions() {
+		fs := flag.NewFlagSet("", flag.ContinueOnError)
+		o.register(fs)
+		set := false
+		var err error
+		fs.VisitAll(func(f *flag.Flag) {
+			key := envKey(prefix, f.Name)
+			v, ok := os.LookupEnv(key)
+			if !ok || err != nil {
+				return
+			}
+			if err = fs.Set(f.Name, v); err != nil {
+				err = fmt.Errorf("zapr: invalid environment variable: %s: %w", key, err)
+			}
+			set = true
+		})
+		if err != nil {
+			return nil, err
+		}
+		if set {
+			opts = append(opts, o)
+		}
+	}
+	return opts, nil
+}
+
+// envKey returns the name of the environment variable for the flag.
+func envKey(prefix, name string) string {
+	name = strings.TrimPrefix(name, "log-")
+	return strings.ToUpper(prefix + "_" + strings.ReplaceAll(name, "-", "_"))
+}
+
+// EffectiveConfig returns the FileConfig resolved from the given Options,
+// including defaults, such that it may be serialized to describe exactly how
+// logging is configured. Options which can't be described by a FileConfig,
+// such as tees and taps, are omitted. Empty keys are omitted when it's
+// serialized.
+func EffectiveConfig(options ...Option) *FileConfig {
+	return effectiveConfig(configWithOptions(options))
+}
+
+func effectiveConfig(c *config) *FileConfig {
+	cfg := &FileConfig{
+		Name:              c.name,
+		Preset:            c.preset,
+		Level:             int(c.levelVar.Load()),
+		TimeKey:           c.timeKey,
+		LevelKey:          c.levelKey,
+		NameKey:           c.nameKey,
+		CallerKey:         c.callerKey,
+		FunctionKey:       c.functionKey,
+		MessageKey:        c.messageKey,
+		ErrorKey:          c.errorKey,
+		ErrorKindKey:      c.errorKindKey,
+		ErrorCausesKey:    c.causesKey,
+		ErrorVerboseKey:   c.verboseKey,
+		StacktraceKey:     c.stacktraceKey,
+		GoroutineKey:      c.goroutineKey,
+		SourceKey:         c.sourceKey,
+		SequenceKey:       c.sequenceKey,
+		OriginKey:         c.originKey,
+		LineEnding:        c.lineEnding,
+		KeyRenames:        copyMap(c.keyRenames),
+		RedactKeys:        append([]string(nil), c.redactKeys...),
+		ConsoleWidth:      c.consoleWidth,
+		DisableCaller:     !c.enableCaller,
+		EnableStacktrace:  c.enableStacktrace,
+		ErrorStacktrace:   c.errorStacktrace,
+		Development:       c.development,
+		SortFields:        c.sortFields,
+		TraceEvents:       c.traceEvents,
+		StrictFields:      c.strictFields,
+		ZapFieldsAllowed:  c.zapFields,
+		ReopenOnSignal:    c.reopenOnSignal,
+		SplitOutput:       c.splitOutput,
+		StacktraceLevel:   c.stacktraceLevel.String(),
+		StacktraceOmitted: append([]string(nil), c.stacktraceOmit...),
+		StacktraceDepth:   c.stacktraceDepth,
+		ErrorLevel:        c.errorLevel.String(),
+		ByteBudget:        c.byteBudget,
+		ValuesLimit:       c.maxValues,
+		EvictValues:       c.evictValues,
+		ValuesNamespace:   c.valuesNamespace,
+		ReflectDepth:      c.reflectDepth,
+		ReflectSize:       c.reflectSize,
+		MaxValueLength:    c.maxValueLength,
+		BufferSize:        c.bufferSize,
+		FlushInterval:     c.flushInterval,
+	}
+	if c.duplicateKeys != AllowDuplicateKeys {
+		cfg.DuplicateKeys = c.duplicateKeys.String()
+	}
+	if c.reportService != "" {
+		cfg.ErrorReportingService = c.reportService
+		cfg.ErrorReportingVersion = c.reportVersion
+	}
+	if s, ok := c.ws.(fmt.Stringer); ok {
+		cfg.Output = s.String()
+	}
+	if s, ok := c.auditWS.(fmt.Stringer); ok {
+		cfg.AuditOutput = s.String()
+		cfg.AuditNames = append([]string(nil), c.auditNames...)
+	}
+	if len(c.errorLevels) > 0 {
+		cfg.ErrorLevels = make(map[string]string, len(c.errorLevels))
+		for name, lvl := range c.errorLevels {
+			cfg.ErrorLevels[name] = lvl.String()
+		}
+	}
+	if len(c.vmodule) > 0 {
+		parts := make([]string, len(c.vmodule))
+		for i, r := range c.vmodule {
+			parts[i] = r.String()
+		}
+		cfg.VModule = strings.Join(parts, ",")
+	}
+	for _, e := range []struct {
+		dst *string
+		e   interface{ Name() string }
+	}{
+		{&cfg.Encoder, c.encoder},
+		{&cfg.TimeEncoder, c.timeEncoder},
+		{&cfg.LevelEncoder, c.levelEncoder},
+		{&cfg.DurationEncoder, c.durationEncoder},
+		{&cfg.CallerEncoder, c.callerEncoder},
+	} {
+		if e.e != nil {
+			*e.dst = e.e.Name()
+		}
+	}
+	if c.sampleFirst != 0 || c.sampleThereafter != 0 {
+		cfg.Sampler = &SamplerConfig{
+			Tick:       c.sampleTick,
+			First:      c.sampleFirst,
+			Thereafter: c.sampleThereafter,
+		}
+	}
+	return cfg
+}
+
+// LogEffectiveConfig logs the effective configuration of the Logger at V(0),
+// if its LogSink was created by NewLogSink or NewLogSinkFromZap. The secrets
+// of output URLs are redacted, as described by output.Redact.
+func LogEffectiveConfig(log logr.Logger) {
+	s, ok := log.GetSink().(*sink)
+	if !ok || s.cfg == nil {
+		return
+	}
+	cfg := effectiveConfig(s.cfg)
+	if cfg.Output != "" {
+		cfg.Output = output.Redact(cfg.Output)
+	}
+	if cfg.AuditOutput != "" {
+		cfg.AuditOutput = output.Redact(cfg.AuditOutput)
+	}
+	log.WithCallDepth(1).Info("Logging configuration", "config", cfg)
+}
@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"errors"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// An extraField computes a field for an entry when it's written.
+// It returns false if the entry should not have the field.
+type extraField func(ent zapcore.Entry) (zapcore.Field, bool)
+
+// extraCore adds extra fields to each entry when it's written.
+type extraCore struct {
+	zapcore.Core
+	extra []extraField
+}
+
+func (c *extraCore) With(fields []zapcore.Field) zapcore.Core {
+	return &extraCore{Core: c.Core.With(fields), extra: c.extra}
+}
+
+func (c *extraCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *extraCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	fs := make([]zapcore.Field, 0, len(fields)+len(c.extra))
+	fs = append(fs, fields...)
+	for _, fn := range c.extra {
+		if f, ok := fn(ent); ok {
+			fs = append(fs, f)
+		}
+	}
+	return c.Core.Write(ent, fs)
+}
+
+// checkedTee is like the core returned by zapcore.NewTee, except that it only
+// writes entries to the cores which are enabled at their levels. Cores which
+// transform entries check themselves in place of the cores they wrap, so a tee
+// of cores with different levels must check them again when it's written.
+type checkedTee []zapcore.Core
+
+func newCheckedTee(cores ...zapcore.Core) zapcore.Core {
+	if len(cores) == 1 {
+		return cores[0]
+	}
+	return checkedTee(cores)
+}
+
+func (t checkedTee) Enabled(level zapcore.Level) bool {
+	for _, c := range t {
+		if c.Enabled(level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t checkedTee) With(fields []zapcore.Field) zapcore.Core {
+	cores := make(checkedTee, len(t))
+	for i, c := range t {
+		cores[i] = c.With(fields)
+	}
+	return cores
+}
+
+func (t checkedTee) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	for _, c := range t {
+		ce = c.Check(ent, ce)
+	}
+	return ce
+}
+
+func (t checkedTee) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	var errs []error
+	for _, c := range t {
+		if !c.Enabled(ent.Level) {
+			continue
+		}
+		if err := c.Write(ent, fields); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t checkedTee) Sync() error {
+	var errs []error
+	for _, c := range t {
+		if err := c.Sync(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
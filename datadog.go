@@ -0,0 +1,24 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import "strconv"
+
+// DatadogTraceContext returns the key-value pairs of the "dd.trace_id" and
+// "dd.span_id" attributes used by Datadog to correlate entries with traces.
+// If traceID is zero, it returns nothing.
+//
+//	log = log.WithValues(zapr.DatadogTraceContext(span.TraceID(), span.SpanID())...)
+func DatadogTraceContext(traceID, spanID uint64) []interface{} {
+	if traceID == 0 {
+		return nil
+	}
+	return []interface{}{
+		"dd.trace_id", strconv.FormatUint(traceID, 10),
+		"dd.span_id", strconv.FormatUint(spanID, 10),
+	}
+}
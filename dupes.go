@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"bursavich.dev/zapr/internal/fields"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// A DuplicateKeys policy determines how fields with repeated keys are encoded,
+// including those added by WithValues.
+type DuplicateKeys int
+
+const (
+	// AllowDuplicateKeys encodes every field, even if its key is repeated.
+	AllowDuplicateKeys DuplicateKeys = iota
+	// KeepLastDuplicateKey encodes only the last field with each key.
+	KeepLastDuplicateKey
+	// SuffixDuplicateKeys appends "_2", "_3", etc. to repeated keys, skipping
+	// suffixed keys which are already present.
+	SuffixDuplicateKeys
+)
+
+var duplicateKeysNames = []string{"allow", "last", "suffix"}
+
+// String returns the name of the policy.
+func (d DuplicateKeys) String() string {
+	if 0 <= d && int(d) < len(duplicateKeysNames) {
+		return duplicateKeysNames[d]
+	}
+	return "DuplicateKeys(" + strconv.Itoa(int(d)) + ")"
+}
+
+// Set sets the policy by its name. It implements flag.Value.
+func (d *DuplicateKeys) Set(s string) error {
+	for i, name := range duplicateKeysNames {
+		if strings.EqualFold(s, name) {
+			*d = DuplicateKeys(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("zapr: unknown duplicate keys policy: %q", s)
+}
+
+// dedupeEncoder encodes fields without repeated keys, after the entry's
+// header. Context fields are recorded rather than encoded so that they may
+// be compared with each entry's fields.
+type dedupeEncoder struct {
+	fields.Recorder
+	enc    zapcore.Encoder // without context
+	policy DuplicateKeys
+	header map[string]bool // keys of the entry's header
+}
+
+func newDedupeEncoder(enc zapcore.Encoder, policy DuplicateKeys, cfg zapcore.EncoderConfig) *dedupeEncoder {
+	header := make(map[string]bool)
+	for _, key := range []string{
+		cfg.TimeKey,
+		cfg.LevelKey,
+		cfg.NameKey,
+		cfg.CallerKey,
+		cfg.FunctionKey,
+		cfg.MessageKey,
+		cfg.StacktraceKey,
+	} {
+		if key != "" {
+			header[key] = true
+		}
+	}
+	return &dedupeEncoder{enc: enc, policy: policy, header: header}
+}
+
+func (enc *dedupeEncoder) Clone() zapcore.Encoder {
+	return &dedupeEncoder{
+		Recorder: enc.Recorder.Clone(),
+		enc:      enc.enc,
+		policy:   enc.policy,
+		header:   enc.header,
+	}
+}
+
+func (enc *dedupeEncoder) EncodeEntry(ent zapcore.Entry, fs []zapcore.Field) (*buffer.Buffer, error) {
+	return enc.enc.EncodeEntry(ent, dedupeFields(enc.With(fs), enc.policy, enc.header))
+}
+
+// dedupeFields returns the fields without repeated keys, according to the
+// policy. Fields following a namespace belong to it, so each run of fields
+// between namespaces is processed separately. Before any namespace, fields
+// whose keys are in the header are repeats of it, but since the header can't
+// be removed, they're suffixed with "_2" by KeepLastDuplicateKey. The given
+// slice isn't modified.
+func dedupeFields(fs []zapcore.Field, policy DuplicateKeys, header map[string]bool) []zapcore.Field {
+	var out []zapcore.Field
+	seen := make(map[string]int)
+	for k := range header {
+		seen[k] = 1
+	}
+	nested := false
+	for i, f := range fs {
+		if f.Type == zapcore.NamespaceType {
+			for k := range seen {
+				delete(seen, k)
+			}
+			nested = true
+			if out != nil {
+				out = append(out, f)
+			}
+			continue
+		}
+		if f.Key == "" || f.Type == zapcore.SkipType {
+			if out != nil {
+				out = append(out, f)
+			}
+			continue
+		}
+		if policy == KeepLastDuplicateKey && !nested && header[f.Key] {
+			if out == nil {
+				out = append(make([]zapcore.Field, 0, len(fs)), fs[:i]...)
+			}
+			f.Key += "_2"
+		}
+		n, dup := seen[f.Key]
+		seen[f.Key] = n + 1
+		if !dup {
+			if out != nil {
+				out = append(out, f)
+			}
+			continue
+		}
+		if out == nil {
+			out = append(make([]zapcore.Field, 0, len(fs)), fs[:i]...)
+		}
+		switch policy {
+		case KeepLastDuplicateKey:
+			out = removeLastKey(out, f.Key)
+			out = append(out, f)
+		case SuffixDuplicateKeys:
+			// Skip suffixes of keys which are already present.
+			key := f.Key
+			for {
+				n++
+				f.Key = key + "_" + strconv.Itoa(n)
+				if _, ok := seen[f.Key]; !ok {
+					break
+				}
+			}
+			seen[key] = n
+			seen[f.Key] = 1
+			out = append(out, f)
+		}
+	}
+	if out == nil {
+		return fs
+	}
+	return out
+}
+
+// removeLastKey removes the last field with the key,
+// which must be in the current namespace.
+func removeLastKey(fs []zapcore.Field, key string) []zapcore.Field {
+	for i := len(fs) - 1; i >= 0; i-- {
+		if fs[i].Key == key {
+			return append(fs[:i], fs[i+1:]...)
+		}
+	}
+	return fs
+}
@@ -9,7 +9,11 @@ package encoding
 import (
 	"flag"
 	"fmt"
+	"path/filepath"
+	"runtime/debug"
 	"sort"
+	"strconv"
+	"strings"
 
 	"go.uber.org/zap/zapcore"
 )
@@ -42,6 +46,22 @@ func CallerEncoders() []CallerEncoder {
 	return s
 }
 
+// CallerEncoderNames returns the sorted names of the registered CallerEncoders.
+func CallerEncoderNames() []string {
+	names := make([]string, 0, len(callerEncoders))
+	for name := range callerEncoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupCallerEncoder returns the registered CallerEncoder with the name, if any.
+func LookupCallerEncoder(name string) (CallerEncoder, bool) {
+	e, ok := callerEncoders[name]
+	return e, ok
+}
+
 type callerEncoder struct {
 	e    zapcore.CallerEncoder
 	name string
@@ -51,38 +71,170 @@ func (e *callerEncoder) CallerEncoder() zapcore.CallerEncoder { return e.e }
 func (e *callerEncoder) Name() string                         { return e.name }
 
 var (
-	shortCallerEncoder = CallerEncoder(&callerEncoder{name: "short", e: zapcore.ShortCallerEncoder})
-	fullCallerEncoder  = CallerEncoder(&callerEncoder{name: "full", e: zapcore.FullCallerEncoder})
+	shortCallerEncoder  = CallerEncoder(&callerEncoder{name: "short", e: zapcore.ShortCallerEncoder})
+	fullCallerEncoder   = CallerEncoder(&callerEncoder{name: "full", e: zapcore.FullCallerEncoder})
+	googleCallerEncoder = CallerEncoder(&callerEncoder{name: "google", e: encodeGoogleCaller})
+	moduleCallerEncoder = CallerEncoder(&callerEncoder{name: "module", e: moduleCaller(mainModule())})
+	funcCallerEncoder   = CallerEncoder(&callerEncoder{name: "func", e: encodeFuncCaller})
 )
 
 func init() {
 	must(RegisterCallerEncoder(shortCallerEncoder))
 	must(RegisterCallerEncoder(fullCallerEncoder))
+	must(RegisterCallerEncoder(googleCallerEncoder))
+	must(RegisterCallerEncoder(moduleCallerEncoder))
+	must(RegisterCallerEncoder(funcCallerEncoder))
 }
 
 // ShortCallerEncoder serializes a caller in package/file:line format, trimming
 // all but the final directory from the full path.
 func ShortCallerEncoder() CallerEncoder { return shortCallerEncoder }
 
+// TrimmedCallerEncoder serializes a caller in path/to/package/file:line format,
+// keeping the given number of the path's final directories, such that it's
+// equivalent to ShortCallerEncoder if dirs is 1. Its name is "short:" followed
+// by the number, which is accepted by CallerEncoderFlag.
+func TrimmedCallerEncoder(dirs int) CallerEncoder {
+	if dirs < 0 {
+		dirs = 0
+	}
+	return &callerEncoder{
+		name: "short:" + strconv.Itoa(dirs),
+		e: func(caller zapcore.EntryCaller, enc zapcore.PrimitiveArrayEncoder) {
+			if !caller.Defined {
+				enc.AppendString("undefined")
+				return
+			}
+			enc.AppendString(trimPath(caller.File, dirs+1) + ":" + strconv.Itoa(caller.Line))
+		},
+	}
+}
+
+// trimPath returns the final n elements of the slash-separated path.
+func trimPath(path string, n int) string {
+	i := len(path)
+	for ; n > 0; n-- {
+		if i = strings.LastIndexByte(path[:i], '/'); i < 0 {
+			return path
+		}
+	}
+	return path[i+1:]
+}
+
 // FullCallerEncoder serializes a caller in /full/path/to/package/file:line
 // format.
 func FullCallerEncoder() CallerEncoder { return fullCallerEncoder }
 
+// GoogleCallerEncoder serializes a caller as a Google Cloud Logging source
+// location object with file, line, and function fields. If the encoder
+// doesn't support objects, it falls back to the short format.
+func GoogleCallerEncoder() CallerEncoder { return googleCallerEncoder }
+
+// ModuleCallerEncoder serializes a caller in path/to/package/file:line format,
+// where the path is the package's import path without the main module's path
+// prefix (e.g. "internal/server/handler.go:42"). If the package isn't in the
+// main module, its full import path is kept. If the package is unknown or is
+// a main package, it falls back to the short format.
+func ModuleCallerEncoder() CallerEncoder { return moduleCallerEncoder }
+
+// FuncCallerEncoder serializes a caller in package.Func (file:line) format
+// (e.g. "server.(*Handler).ServeHTTP (handler.go:42)"), which includes the
+// function without a separate function key. If the function is unknown,
+// it falls back to the short format.
+func FuncCallerEncoder() CallerEncoder { return funcCallerEncoder }
+
+func encodeFuncCaller(caller zapcore.EntryCaller, enc zapcore.PrimitiveArrayEncoder) {
+	if !caller.Defined || caller.Function == "" {
+		zapcore.ShortCallerEncoder(caller, enc)
+		return
+	}
+	fn := caller.Function
+	if i := strings.LastIndexByte(fn, '/'); i >= 0 {
+		fn = fn[i+1:]
+	}
+	enc.AppendString(fn + " (" + filepath.Base(caller.File) + ":" + strconv.Itoa(caller.Line) + ")")
+}
+
+// mainModule returns the path of the main module, if it's known.
+func mainModule() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		return info.Main.Path
+	}
+	return ""
+}
+
+func moduleCaller(module string) zapcore.CallerEncoder {
+	return func(caller zapcore.EntryCaller, enc zapcore.PrimitiveArrayEncoder) {
+		pkg := funcPackage(caller.Function)
+		if !caller.Defined || pkg == "" || pkg == "main" {
+			zapcore.ShortCallerEncoder(caller, enc)
+			return
+		}
+		if pkg == module {
+			pkg = ""
+		} else if rel, ok := strings.CutPrefix(pkg, module+"/"); ok && module != "" {
+			pkg = rel + "/"
+		} else {
+			pkg += "/"
+		}
+		enc.AppendString(pkg + filepath.Base(caller.File) + ":" + strconv.Itoa(caller.Line))
+	}
+}
+
+// funcPackage returns the import path of the package of the fully-qualified
+// function name (e.g. "example.com/pkg" for "example.com/pkg.(*T).Method").
+func funcPackage(fn string) string {
+	i := strings.LastIndexByte(fn, '/')
+	if k := strings.IndexByte(fn[i+1:], '.'); k >= 0 {
+		return fn[:i+1+k]
+	}
+	return ""
+}
+
+func encodeGoogleCaller(caller zapcore.EntryCaller, enc zapcore.PrimitiveArrayEncoder) {
+	if enc, ok := enc.(zapcore.ArrayEncoder); ok {
+		enc.AppendObject(googleSourceLocation(caller))
+		return
+	}
+	zapcore.ShortCallerEncoder(caller, enc)
+}
+
+type googleSourceLocation zapcore.EntryCaller
+
+func (loc googleSourceLocation) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("file", loc.File)
+	enc.AddString("line", strconv.Itoa(loc.Line)) // int64 encoded as a string
+	if loc.Function != "" {
+		enc.AddString("function", loc.Function)
+	}
+	return nil
+}
+
 type callerEncoderFlag struct {
 	e *CallerEncoder
 }
 
-// CallerEncoderFlag returns a flag value for the encoder.
+// CallerEncoderFlag returns a flag value for the encoder. In addition to the
+// names of registered CallerEncoders, it accepts "short:" followed by a number
+// of directories (e.g. "short:3"), as described by TrimmedCallerEncoder.
 func CallerEncoderFlag(encoder *CallerEncoder) flag.Value {
 	return &callerEncoderFlag{encoder}
 }
 
 func (f *callerEncoderFlag) Get() interface{} { return *f.e }
 func (f *callerEncoderFlag) Set(s string) error {
-	if e, ok := callerEncoders[s]; ok {
+	if e, ok := LookupCallerEncoder(s); ok {
 		*f.e = e
 		return nil
 	}
+	if dirs, ok := strings.CutPrefix(s, "short:"); ok {
+		n, err := strconv.Atoi(dirs)
+		if err != nil || n < 0 {
+			return fmt.Errorf("zapr: invalid CallerEncoder directories: %q", s)
+		}
+		*f.e = TrimmedCallerEncoder(n)
+		return nil
+	}
 	return fmt.Errorf("zapr: unknown CallerEncoder: %q", s)
 }
 func (f *callerEncoderFlag) String() string {
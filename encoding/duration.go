@@ -42,6 +42,27 @@ func DurationEncoders() []DurationEncoder {
 	return s
 }
 
+// DurationEncoderNames returns the sorted names of the registered DurationEncoders.
+func DurationEncoderNames() []string {
+	names := make([]string, 0, len(durationEncoders))
+	for name := range durationEncoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupDurationEncoder returns the registered DurationEncoder with the name,
+// if any. The "ns", "ms", and "s" units are aliases of "nanos", "millis", and
+// "secs".
+func LookupDurationEncoder(name string) (DurationEncoder, bool) {
+	if alias, ok := durationEncoderAliases[name]; ok {
+		name = alias
+	}
+	e, ok := durationEncoders[name]
+	return e, ok
+}
+
 type durationEncoder struct {
 	e    zapcore.DurationEncoder
 	name string
@@ -76,18 +97,28 @@ func MillisecondsDurationEncoder() DurationEncoder { return millisDurationEncode
 // SecondsDurationEncoder serializes a time.Duration to a floating-point number of seconds.
 func SecondsDurationEncoder() DurationEncoder { return secsDurationEncoder }
 
+// durationEncoderAliases maps the abbreviated names of units to the names
+// of their DurationEncoders.
+var durationEncoderAliases = map[string]string{
+	"ns": "nanos",
+	"ms": "millis",
+	"s":  "secs",
+}
+
 type durationEncoderFlag struct {
 	e *DurationEncoder
 }
 
 // DurationEncoderFlag returns a flag value for the encoder.
+// The "ns", "ms", and "s" units are accepted as aliases of
+// "nanos", "millis", and "secs".
 func DurationEncoderFlag(encoder *DurationEncoder) flag.Value {
 	return &durationEncoderFlag{encoder}
 }
 
 func (f *durationEncoderFlag) Get() interface{} { return *f.e }
 func (f *durationEncoderFlag) Set(s string) error {
-	if e, ok := durationEncoders[s]; ok {
+	if e, ok := LookupDurationEncoder(s); ok {
 		*f.e = e
 		return nil
 	}
@@ -43,6 +43,22 @@ func Encoders() []Encoder {
 	return s
 }
 
+// EncoderNames returns the sorted names of the registered Encoders.
+func EncoderNames() []string {
+	names := make([]string, 0, len(encoders))
+	for name := range encoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupEncoder returns the registered Encoder with the name, if any.
+func LookupEncoder(name string) (Encoder, bool) {
+	e, ok := encoders[name]
+	return e, ok
+}
+
 type encoder struct {
 	ctor func(zapcore.EncoderConfig) zapcore.Encoder
 	name string
@@ -77,7 +93,7 @@ func EncoderFlag(encoder *Encoder) flag.Value {
 
 func (f *encoderFlag) Get() interface{} { return *f.e }
 func (f *encoderFlag) Set(s string) error {
-	if e, ok := encoders[s]; ok {
+	if e, ok := LookupEncoder(s); ok {
 		*f.e = e
 		return nil
 	}
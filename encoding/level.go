@@ -7,7 +7,9 @@ package encoding
 import (
 	"flag"
 	"fmt"
+	"os"
 	"sort"
+	"strings"
 
 	"go.uber.org/zap/zapcore"
 )
@@ -40,6 +42,22 @@ func LevelEncoders() []LevelEncoder {
 	return s
 }
 
+// LevelEncoderNames returns the sorted names of the registered LevelEncoders.
+func LevelEncoderNames() []string {
+	names := make([]string, 0, len(levelEncoders))
+	for name := range levelEncoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupLevelEncoder returns the registered LevelEncoder with the name, if any.
+func LookupLevelEncoder(name string) (LevelEncoder, bool) {
+	e, ok := levelEncoders[name]
+	return e, ok
+}
+
 type levelEncoder struct {
 	e    zapcore.LevelEncoder
 	name string
@@ -52,12 +70,16 @@ var (
 	colorLevelEncoder     = LevelEncoder(&levelEncoder{name: "color", e: zapcore.CapitalColorLevelEncoder})
 	lowercaseLevelEncoder = LevelEncoder(&levelEncoder{name: "lower", e: zapcore.LowercaseLevelEncoder})
 	uppercaseLevelEncoder = LevelEncoder(&levelEncoder{name: "upper", e: zapcore.CapitalLevelEncoder})
+	googleLevelEncoder    = LevelEncoder(&levelEncoder{name: "google", e: encodeGoogleLevel})
+	charLevelEncoder      = LevelEncoder(&levelEncoder{name: "char", e: encodeCharLevel})
 )
 
 func init() {
 	must(RegisterLevelEncoder(colorLevelEncoder))
 	must(RegisterLevelEncoder(lowercaseLevelEncoder))
 	must(RegisterLevelEncoder(uppercaseLevelEncoder))
+	must(RegisterLevelEncoder(googleLevelEncoder))
+	must(RegisterLevelEncoder(charLevelEncoder))
 }
 
 // ColorLevelEncoder serializes a Level to an all-caps string and adds color.
@@ -72,23 +94,150 @@ func LowercaseLevelEncoder() LevelEncoder { return lowercaseLevelEncoder }
 // InfoLevel is serialized to "INFO".
 func UppercaseLevelEncoder() LevelEncoder { return uppercaseLevelEncoder }
 
+// CharLevelEncoder serializes a Level to a single character, as in klog.
+// Info and all verbosity levels are serialized to "I", WarnLevel to "W",
+// ErrorLevel and DPanicLevel to "E", and PanicLevel and FatalLevel to "F".
+func CharLevelEncoder() LevelEncoder { return charLevelEncoder }
+
+func encodeCharLevel(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	switch {
+	case l <= zapcore.InfoLevel:
+		enc.AppendString("I")
+	case l == zapcore.WarnLevel:
+		enc.AppendString("W")
+	case l <= zapcore.DPanicLevel:
+		enc.AppendString("E")
+	default:
+		enc.AppendString("F")
+	}
+}
+
+// GoogleLevelEncoder serializes a Level to a Google Cloud Logging severity.
+// For example, WarnLevel is serialized to "WARNING" and DPanicLevel is
+// serialized to "CRITICAL".
+func GoogleLevelEncoder() LevelEncoder { return googleLevelEncoder }
+
+func encodeGoogleLevel(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	switch l {
+	case zapcore.DebugLevel:
+		enc.AppendString("DEBUG")
+	case zapcore.InfoLevel:
+		enc.AppendString("INFO")
+	case zapcore.WarnLevel:
+		enc.AppendString("WARNING")
+	case zapcore.ErrorLevel:
+		enc.AppendString("ERROR")
+	case zapcore.DPanicLevel:
+		enc.AppendString("CRITICAL")
+	case zapcore.PanicLevel:
+		enc.AppendString("ALERT")
+	case zapcore.FatalLevel:
+		enc.AppendString("EMERGENCY")
+	default:
+		enc.AppendString("DEFAULT")
+	}
+}
+
+// WordsLevelEncoder serializes a Level to the given word, or to an all-caps
+// string if it has none. For example, with the words {WarnLevel: "WARNING"},
+// WarnLevel is serialized to "WARNING" and InfoLevel is serialized to "INFO".
+func WordsLevelEncoder(words map[zapcore.Level]string) LevelEncoder {
+	pairs := make([]string, 0, len(words))
+	for l, w := range words {
+		pairs = append(pairs, l.String()+"="+w)
+	}
+	sort.Strings(pairs)
+	return newWordsLevelEncoder("words:"+strings.Join(pairs, ","), words)
+}
+
+func newWordsLevelEncoder(name string, words map[zapcore.Level]string) LevelEncoder {
+	m := make(map[zapcore.Level]string, len(words))
+	for l, w := range words {
+		m[l] = w
+	}
+	return &levelEncoder{
+		name: name,
+		e: func(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+			if w, ok := m[l]; ok {
+				enc.AppendString(w)
+				return
+			}
+			enc.AppendString(l.CapitalString())
+		},
+	}
+}
+
+// ParseLevelWords parses level words from "level=word" pairs separated by
+// commas or newlines (e.g. "warn=WARNING,error=ERR"). Blank lines and lines
+// beginning with "#" are ignored.
+func ParseLevelWords(s string) (map[zapcore.Level]string, error) {
+	words := make(map[zapcore.Level]string)
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, pair := range strings.Split(line, ",") {
+			name, word, ok := strings.Cut(pair, "=")
+			word = strings.TrimSpace(word)
+			if !ok || word == "" {
+				return nil, fmt.Errorf("zapr: invalid level word: %q", pair)
+			}
+			var l zapcore.Level
+			if err := l.UnmarshalText([]byte(strings.TrimSpace(name))); err != nil {
+				return nil, fmt.Errorf("zapr: invalid level word: %q: %w", pair, err)
+			}
+			words[l] = word
+		}
+	}
+	return words, nil
+}
+
 type levelEncoderFlag struct {
 	e *LevelEncoder
 }
 
 // LevelEncoderFlag returns a flag value for the encoder.
+//
+// In addition to the names of registered LevelEncoders, it accepts custom
+// words as "words:" followed by words parsed by ParseLevelWords
+// (e.g. "words:warn=WARNING,error=ERR"), or as "words-file:" followed by
+// the path of a file containing them.
 func LevelEncoderFlag(encoder *LevelEncoder) flag.Value {
 	return &levelEncoderFlag{encoder}
 }
 
 func (f *levelEncoderFlag) Get() interface{} { return *f.e }
 func (f *levelEncoderFlag) Set(s string) error {
-	if e, ok := levelEncoders[s]; ok {
+	if e, ok := LookupLevelEncoder(s); ok {
 		*f.e = e
 		return nil
 	}
+	if spec, ok := strings.CutPrefix(s, "words:"); ok {
+		words, err := ParseLevelWords(spec)
+		if err != nil {
+			return err
+		}
+		*f.e = newWordsLevelEncoder(s, words)
+		return nil
+	}
+	if name, ok := strings.CutPrefix(s, "words-file:"); ok {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return fmt.Errorf("zapr: failed to read level words file: %w", err)
+		}
+		words, err := ParseLevelWords(string(b))
+		if err != nil {
+			return err
+		}
+		*f.e = newWordsLevelEncoder(s, words)
+		return nil
+	}
 	return fmt.Errorf("zapr: unknown LevelEncoder: %q", s)
 }
+
+// AcceptsCommas reports that the value may contain commas.
+func (f *levelEncoderFlag) AcceptsCommas() bool { return true }
+
 func (f *levelEncoderFlag) String() string {
 	if f.e == nil {
 		return ""
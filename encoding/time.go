@@ -10,6 +10,7 @@ import (
 	"flag"
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
 	"go.uber.org/zap/zapcore"
@@ -43,6 +44,22 @@ func TimeEncoders() []TimeEncoder {
 	return s
 }
 
+// TimeEncoderNames returns the sorted names of the registered TimeEncoders.
+func TimeEncoderNames() []string {
+	names := make([]string, 0, len(timeEncoders))
+	for name := range timeEncoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupTimeEncoder returns the registered TimeEncoder with the name, if any.
+func LookupTimeEncoder(name string) (TimeEncoder, bool) {
+	e, ok := timeEncoders[name]
+	return e, ok
+}
+
 type timeEncoder struct {
 	e    func(time.Time, zapcore.PrimitiveArrayEncoder)
 	name string
@@ -51,6 +68,9 @@ type timeEncoder struct {
 func (e *timeEncoder) TimeEncoder() zapcore.TimeEncoder { return e.e }
 func (e *timeEncoder) Name() string                     { return e.name }
 
+// processStart approximates the start of the process for relative times.
+var processStart = time.Now()
+
 var (
 	iso8601TimeEncoder = TimeEncoder(&timeEncoder{name: "iso8601", e: zapcore.ISO8601TimeEncoder})
 	millisTimeEncoder  = TimeEncoder(&timeEncoder{name: "millis", e: zapcore.EpochMillisTimeEncoder})
@@ -62,6 +82,22 @@ var (
 			encodeTimeLayout(t, "2006-01-02T15:04:05.000Z07:00", e)
 		},
 	})
+	iso8601MicroTimeEncoder = layoutTimeEncoder("iso8601-micro", "2006-01-02T15:04:05.000000Z0700")
+	iso8601NanoTimeEncoder  = layoutTimeEncoder("iso8601-nano", "2006-01-02T15:04:05.000000000Z0700")
+	rfc3339MicroTimeEncoder = layoutTimeEncoder("rfc3339-micro", "2006-01-02T15:04:05.000000Z07:00")
+	rfc3339NanoTimeEncoder  = layoutTimeEncoder("rfc3339-nano", "2006-01-02T15:04:05.000000000Z07:00")
+	relativeTimeEncoder     = TimeEncoder(&timeEncoder{
+		name: "relative",
+		e: func(t time.Time, e zapcore.PrimitiveArrayEncoder) {
+			e.AppendFloat64(t.Sub(processStart).Seconds())
+		},
+	})
+	relativeStringTimeEncoder = TimeEncoder(&timeEncoder{
+		name: "relative-string",
+		e: func(t time.Time, e zapcore.PrimitiveArrayEncoder) {
+			e.AppendString(t.Sub(processStart).String())
+		},
+	})
 )
 
 func init() {
@@ -70,6 +106,12 @@ func init() {
 	must(RegisterTimeEncoder(nanosTimeEncoder))
 	must(RegisterTimeEncoder(secsTimeEncoder))
 	must(RegisterTimeEncoder(rfc3339TimeEncoder))
+	must(RegisterTimeEncoder(iso8601MicroTimeEncoder))
+	must(RegisterTimeEncoder(iso8601NanoTimeEncoder))
+	must(RegisterTimeEncoder(rfc3339MicroTimeEncoder))
+	must(RegisterTimeEncoder(rfc3339NanoTimeEncoder))
+	must(RegisterTimeEncoder(relativeTimeEncoder))
+	must(RegisterTimeEncoder(relativeStringTimeEncoder))
 }
 
 func encodeTimeLayout(t time.Time, layout string, e zapcore.PrimitiveArrayEncoder) {
@@ -89,6 +131,22 @@ func ISO8601TimeEncoder() TimeEncoder { return iso8601TimeEncoder }
 // millisecond precision.
 func RFC3339TimeEncoder() TimeEncoder { return rfc3339TimeEncoder }
 
+// ISO8601MicroTimeEncoder serializes a time.Time to an ISO8601-formatted string
+// with microsecond precision.
+func ISO8601MicroTimeEncoder() TimeEncoder { return iso8601MicroTimeEncoder }
+
+// ISO8601NanoTimeEncoder serializes a time.Time to an ISO8601-formatted string
+// with nanosecond precision.
+func ISO8601NanoTimeEncoder() TimeEncoder { return iso8601NanoTimeEncoder }
+
+// RFC3339MicroTimeEncoder serializes a time.Time to an RFC3339-formatted string
+// with microsecond precision.
+func RFC3339MicroTimeEncoder() TimeEncoder { return rfc3339MicroTimeEncoder }
+
+// RFC3339NanoTimeEncoder serializes a time.Time to an RFC3339-formatted string
+// with nanosecond precision.
+func RFC3339NanoTimeEncoder() TimeEncoder { return rfc3339NanoTimeEncoder }
+
 // NanosecondsTimeEncoder serializes a time.Time to an integer number of nanoseconds
 // since the Unix epoch.
 func NanosecondsTimeEncoder() TimeEncoder { return nanosTimeEncoder }
@@ -101,23 +159,59 @@ func MillisecondsTimeEncoder() TimeEncoder { return millisTimeEncoder }
 // since the Unix epoch.
 func SecondsTimeEncoder() TimeEncoder { return secsTimeEncoder }
 
+// RelativeTimeEncoder serializes a time.Time to a floating-point number of
+// seconds since the process started, which is easier to read than absolute
+// times during local development and benchmarking.
+func RelativeTimeEncoder() TimeEncoder { return relativeTimeEncoder }
+
+// RelativeStringTimeEncoder serializes a time.Time to the string of the
+// time.Duration since the process started (e.g. "1.5s").
+func RelativeStringTimeEncoder() TimeEncoder { return relativeStringTimeEncoder }
+
+// LayoutTimeEncoder serializes a time.Time to a string formatted with the given
+// Go time layout (e.g. "2006-01-02 15:04:05.000"). Its name is the layout with
+// a "layout:" prefix, which is accepted by TimeEncoderFlag.
+func LayoutTimeEncoder(layout string) TimeEncoder {
+	return layoutTimeEncoder("layout:"+layout, layout)
+}
+
+func layoutTimeEncoder(name, layout string) TimeEncoder {
+	return &timeEncoder{
+		name: name,
+		e: func(t time.Time, e zapcore.PrimitiveArrayEncoder) {
+			encodeTimeLayout(t, layout, e)
+		},
+	}
+}
+
 type timeEncoderFlag struct {
 	e *TimeEncoder
 }
 
-// TimeEncoderFlag returns a flag value for the encoder.
+// TimeEncoderFlag returns a flag value for the encoder. In addition to the
+// names of registered TimeEncoders, it accepts a Go time layout with a "layout:"
+// prefix (e.g. "layout:2006-01-02 15:04:05.000"), as described by
+// LayoutTimeEncoder.
 func TimeEncoderFlag(encoder *TimeEncoder) flag.Value {
 	return &timeEncoderFlag{encoder}
 }
 
 func (f *timeEncoderFlag) Get() interface{} { return *f.e }
 func (f *timeEncoderFlag) Set(s string) error {
-	if e, ok := timeEncoders[s]; ok {
+	if e, ok := LookupTimeEncoder(s); ok {
 		*f.e = e
 		return nil
 	}
+	if layout, ok := strings.CutPrefix(s, "layout:"); ok && layout != "" {
+		*f.e = LayoutTimeEncoder(layout)
+		return nil
+	}
 	return fmt.Errorf("zapr: unknown TimeEncoder: %q", s)
 }
+
+// AcceptsCommas reports that the value may contain commas.
+func (f *timeEncoderFlag) AcceptsCommas() bool { return true }
+
 func (f *timeEncoderFlag) String() string {
 	if f.e == nil {
 		return ""
@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// errorLevels maps logger names to the zap levels of their Error entries.
+// A name's level is that of its longest dot-separated prefix in the map
+// (e.g. "app.db" applies to "app.db.sql").
+type errorLevels map[string]zapcore.Level
+
+func (m errorLevels) level(name string, def zapcore.Level) zapcore.Level {
+	for {
+		if level, ok := m[name]; ok {
+			return level
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			return def
+		}
+		name = name[:i]
+	}
+}
+
+func copyErrorLevels(m map[string]zapcore.Level) errorLevels {
+	if len(m) == 0 {
+		return nil
+	}
+	c := make(errorLevels, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// errorLevelsFlag is a flag value for error levels formatted as "name1=level1,name2=level2".
+type errorLevelsFlag struct {
+	m *map[string]zapcore.Level
+}
+
+func (f *errorLevelsFlag) Get() interface{} { return *f.m }
+
+func (f *errorLevelsFlag) Set(s string) error {
+	m := make(map[string]zapcore.Level)
+	for _, kv := range strings.Split(s, ",") {
+		if kv = strings.TrimSpace(kv); kv == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			return fmt.Errorf("zapr: invalid name=level pair: %q", kv)
+		}
+		lvl, err := zapcore.ParseLevel(v)
+		if err != nil {
+			return fmt.Errorf("zapr: invalid error level: %q: %w", kv, err)
+		}
+		m[k] = lvl
+	}
+	*f.m = m
+	return nil
+}
+
+// AcceptsCommas reports that the value may contain commas.
+func (f *errorLevelsFlag) AcceptsCommas() bool { return true }
+
+func (f *errorLevelsFlag) String() string {
+	if f.m == nil || len(*f.m) == 0 {
+		return ""
+	}
+	kvs := make([]string, 0, len(*f.m))
+	for k, v := range *f.m {
+		kvs = append(kvs, k+"="+v.String())
+	}
+	sort.Strings(kvs)
+	return strings.Join(kvs, ",")
+}
@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// reportedErrorEventType is the type of entries which are aggregated by
+// Google Cloud Error Reporting.
+const reportedErrorEventType = "type.googleapis.com/google.devtools.clouderrorreporting.v1beta1.ReportedErrorEvent"
+
+// errorReportingCore shapes error entries as Google Cloud Error Reporting
+// events.
+type errorReportingCore struct {
+	zapcore.Core
+	service serviceContext
+}
+
+func (c *errorReportingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &errorReportingCore{Core: c.Core.With(fields), service: c.service}
+}
+
+func (c *errorReportingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *errorReportingCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if ent.Level < zapcore.ErrorLevel {
+		return c.Core.Write(ent, fields)
+	}
+	fields = append(fields[:len(fields):len(fields)],
+		zap.String("@type", reportedErrorEventType),
+		zap.Object("serviceContext", c.service),
+	)
+	if ent.Stack != "" {
+		fields = append(fields, zap.String("stack_trace", goStack(ent.Message, ent.Stack)))
+		ent.Stack = ""
+	}
+	return c.Core.Write(ent, fields)
+}
+
+type serviceContext struct {
+	service string
+	version string
+}
+
+func (s serviceContext) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("service", s.service)
+	if s.version != "" {
+		enc.AddString("version", s.version)
+	}
+	return nil
+}
+
+// goStack returns the message and stacktrace in the format of runtime.Stack,
+// which is parsed by Error Reporting. Stacktraces formatted like zap's are
+// given a goroutine header and their functions are given argument lists.
+func goStack(msg, stack string) string {
+	var b strings.Builder
+	b.WriteString(msg)
+	b.WriteString("\n\n")
+	if strings.HasPrefix(stack, "goroutine ") {
+		b.WriteString(stack)
+		return b.String()
+	}
+	id := goroutineID()
+	if id < 0 {
+		id = 1
+	}
+	b.WriteString("goroutine ")
+	b.WriteString(strconv.FormatInt(id, 10))
+	b.WriteString(" [running]:\n")
+	for i, line := range strings.Split(stack, "\n") {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(line)
+		if !strings.HasPrefix(line, "\t") {
+			b.WriteString("(...)")
+		}
+	}
+	return b.String()
+}
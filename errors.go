@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// errorCauses returns the messages of the errors wrapped by err, in order
+// from the outermost to the root cause. The errors joined by a multi-error,
+// such as one returned by errors.Join, are each included, followed by their
+// own causes, so that they're individually searchable.
+func errorCauses(err error) []string {
+	return appendCauses(nil, err)
+}
+
+func appendCauses(causes []string, err error) []string {
+	switch x := err.(type) {
+	case interface{ Unwrap() error }:
+		if err := x.Unwrap(); err != nil {
+			causes = append(causes, err.Error())
+			causes = appendCauses(causes, err)
+		}
+	case interface{ Unwrap() []error }:
+		for _, err := range x.Unwrap() {
+			if err != nil {
+				causes = append(causes, err.Error())
+				causes = appendCauses(causes, err)
+			}
+		}
+	}
+	return causes
+}
+
+// errorVerbose returns the "%+v" rendering of err, if it implements
+// fmt.Formatter and the rendering differs from its message.
+func errorVerbose(err error) (string, bool) {
+	if _, ok := err.(fmt.Formatter); !ok {
+		return "", false
+	}
+	verbose := fmt.Sprintf("%+v", err)
+	return verbose, verbose != err.Error()
+}
+
+// errorStacktrace returns the innermost stacktrace carried by err or the
+// errors it wraps, if any, as returned by a StackTrace or Stack method.
+func errorStacktrace(err error) string {
+	var stack string
+	for ; err != nil; err = errors.Unwrap(err) {
+		for _, name := range []string{"StackTrace", "Stack"} {
+			if s := callStack(err, name); s != "" {
+				stack = s
+				break
+			}
+		}
+	}
+	return stack
+}
+
+// callStack calls the named method of err, if it has no arguments and one
+// result, and formats the result as a stacktrace. Slices of program counters,
+// such as github.com/pkg/errors.StackTrace, are formatted like zap's
+// stacktraces. Strings, byte slices, and other fmt.Formatters are formatted
+// as-is.
+func callStack(err error, name string) string {
+	m := reflect.ValueOf(err).MethodByName(name)
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+		return ""
+	}
+	v := m.Call(nil)[0]
+	switch {
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uintptr:
+		pcs := make([]uintptr, v.Len())
+		for i := range pcs {
+			pcs[i] = uintptr(v.Index(i).Uint())
+		}
+		return formatFrames(pcs)
+	case v.Kind() == reflect.String:
+		return strings.TrimSpace(v.String())
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		return strings.TrimSpace(string(v.Bytes()))
+	}
+	if f, ok := v.Interface().(fmt.Formatter); ok {
+		return strings.TrimSpace(fmt.Sprintf("%+v", f))
+	}
+	return ""
+}
+
+// formatFrames formats the program counters like zap's stacktraces.
+func formatFrames(pcs []uintptr) string {
+	var b strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		f, more := frames.Next()
+		if f.Function != "" || f.File != "" {
+			if b.Len() > 0 {
+				b.WriteByte('\n')
+			}
+			b.WriteString(f.Function)
+			b.WriteString("\n\t")
+			b.WriteString(f.File)
+			b.WriteByte(':')
+			b.WriteString(strconv.Itoa(f.Line))
+		}
+		if !more {
+			return b.String()
+		}
+	}
+}
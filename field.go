@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// A Value is a strongly-typed value. It may be passed to a Logger as the
+// value of a key-value pair, in which case this package's LogSinks add it
+// to the entry without reflection. Other LogSinks see it as a logr.Marshaler
+// of the underlying value.
+//
+//	log.Info("Handled request", "path", zapr.String(path), "status", zapr.Int(status))
+type Value interface {
+	logr.Marshaler
+
+	// field returns the Field of the key and value.
+	field(key string) zapcore.Field
+}
+
+// String returns a Value of the string.
+func String(v string) Value { return stringValue(v) }
+
+// Bool returns a Value of the bool.
+func Bool(v bool) Value { return boolValue(v) }
+
+// Int returns a Value of the int.
+func Int(v int) Value { return int64Value(v) }
+
+// Int64 returns a Value of the int64.
+func Int64(v int64) Value { return int64Value(v) }
+
+// Float64 returns a Value of the float64.
+func Float64(v float64) Value { return float64Value(v) }
+
+// Dur returns a Value of the time.Duration.
+func Dur(v time.Duration) Value { return durationValue(v) }
+
+// Time returns a Value of the time.Time.
+func Time(v time.Time) Value { return timeValue(v) }
+
+// Err returns a Value of the error's message. If the error is nil, the value
+// is null, which zap only encodes by reflection.
+func Err(err error) Value {
+	if err == nil {
+		return nilValue{}
+	}
+	return stringValue(err.Error())
+}
+
+type stringValue string
+
+func (v stringValue) MarshalLog() interface{}        { return string(v) }
+func (v stringValue) field(key string) zapcore.Field { return zap.String(key, string(v)) }
+
+type boolValue bool
+
+func (v boolValue) MarshalLog() interface{}        { return bool(v) }
+func (v boolValue) field(key string) zapcore.Field { return zap.Bool(key, bool(v)) }
+
+type int64Value int64
+
+func (v int64Value) MarshalLog() interface{}        { return int64(v) }
+func (v int64Value) field(key string) zapcore.Field { return zap.Int64(key, int64(v)) }
+
+type float64Value float64
+
+func (v float64Value) MarshalLog() interface{}        { return float64(v) }
+func (v float64Value) field(key string) zapcore.Field { return zap.Float64(key, float64(v)) }
+
+type durationValue time.Duration
+
+func (v durationValue) MarshalLog() interface{}        { return time.Duration(v) }
+func (v durationValue) field(key string) zapcore.Field { return zap.Duration(key, time.Duration(v)) }
+
+type timeValue time.Time
+
+func (v timeValue) MarshalLog() interface{}        { return time.Time(v) }
+func (v timeValue) field(key string) zapcore.Field { return zap.Time(key, time.Time(v)) }
+
+type nilValue struct{}
+
+func (nilValue) MarshalLog() interface{}        { return nil }
+func (nilValue) field(key string) zapcore.Field { return zap.Reflect(key, nil) }
+
+// objectValue is a Value of an object, which other LogSinks see as a map.
+type objectValue struct{ m zapcore.ObjectMarshaler }
+
+func (v objectValue) MarshalLog() interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+	_ = v.m.MarshalLogObject(enc)
+	return enc.Fields
+}
+
+func (v objectValue) field(key string) zapcore.Field { return zap.Object(key, v.m) }
+
+// Lazy returns a value which is computed by the function only when its entry
+// is written, such that expensive values of entries which are disabled or
+// dropped by the sampler aren't computed. It may be passed to a Logger as the
+// value of a key-value pair. Values added by WithValues may be computed when
+// they're added. Other LogSinks see it as a logr.Marshaler.
+//
+//	log.V(2).Info("Received request", "body", zapr.Lazy(func() interface{} { return dump(req) }))
+func Lazy(fn func() interface{}) logr.Marshaler { return lazyValue(fn) }
+
+type lazyValue func() interface{}
+
+func (fn lazyValue) MarshalLog() interface{} { return fn() }
+
+// lazyField is inlined so that its value is computed when it's encoded.
+// The value is computed once, even if it's encoded by several outputs.
+type lazyField struct {
+	key string
+	fn  lazyValue
+	lim *reflectLimits
+
+	once sync.Once
+	val  interface{}
+}
+
+func (f *lazyField) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	f.once.Do(func() { f.val = marshalLog(f.fn) })
+	valueField(f.key, f.val, f.lim).AddTo(enc)
+	return nil
+}
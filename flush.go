@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import "sync"
+
+// A FlushObserver is an Observer which also observes flushes.
+type FlushObserver interface {
+	Observer
+
+	// ObserveFlush observes a call to Flush. It's coalesced if it shared
+	// the underlying sync with concurrent calls instead of causing its own.
+	ObserveFlush(coalesced bool)
+}
+
+// flusher coalesces concurrent flushes. Since a sync that's already running
+// may not include entries written by a new caller, the caller waits for it
+// to finish and then starts the next sync, which is shared with all callers
+// that arrive before it starts.
+type flusher struct {
+	sync     func() error
+	observer FlushObserver
+
+	mu      sync.Mutex
+	cond    sync.Cond
+	running bool
+	pending *flushCall
+}
+
+type flushCall struct {
+	done chan struct{}
+	err  error
+}
+
+func newFlusher(sync func() error, observer Observer) *flusher {
+	f := &flusher{sync: sync}
+	f.cond.L = &f.mu
+	f.observer, _ = observer.(FlushObserver)
+	return f
+}
+
+func (f *flusher) flush() error {
+	f.mu.Lock()
+	if c := f.pending; c != nil {
+		f.mu.Unlock()
+		f.observe(true)
+		<-c.done
+		return c.err
+	}
+	c := &flushCall{done: make(chan struct{})}
+	f.pending = c
+	for f.running {
+		f.cond.Wait()
+	}
+	f.pending = nil
+	f.running = true
+	f.mu.Unlock()
+
+	f.observe(false)
+	c.err = f.sync()
+	close(c.done)
+
+	f.mu.Lock()
+	f.running = false
+	f.cond.Signal()
+	f.mu.Unlock()
+	return c.err
+}
+
+func (f *flusher) observe(coalesced bool) {
+	if f.observer != nil {
+		f.observer.ObserveFlush(coalesced)
+	}
+}
@@ -4,8 +4,11 @@ go 1.20
 
 require (
 	github.com/go-logr/logr v1.2.3
+	github.com/klauspost/compress v1.16.7
 	github.com/prometheus/client_golang v1.14.0
+	github.com/spf13/pflag v1.0.5
 	go.uber.org/zap v1.24.0
+	golang.org/x/sys v0.5.0
 )
 
 require (
@@ -18,6 +21,5 @@ require (
 	github.com/prometheus/procfs v0.9.0 // indirect
 	go.uber.org/atomic v1.10.0 // indirect
 	go.uber.org/multierr v1.9.0 // indirect
-	golang.org/x/sys v0.5.0 // indirect
 	google.golang.org/protobuf v1.28.1 // indirect
 )
@@ -0,0 +1,133 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"sync"
+
+	"bursavich.dev/zapr/output"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// hashedPrefix prefixes the values of hashed keys.
+const hashedPrefix = "hmac:"
+
+// keyHasher replaces the values of matching keys with their HMACs.
+type keyHasher struct {
+	keys *keyMatcher
+	pool sync.Pool // of hash.Hash
+}
+
+func newKeyHasher(secret []byte, patterns []string) *keyHasher {
+	keys := newKeyMatcher(patterns)
+	if keys == nil || len(secret) == 0 {
+		return nil
+	}
+	secret = append([]byte(nil), secret...)
+	return &keyHasher{
+		keys: keys,
+		pool: sync.Pool{New: func() interface{} { return hmac.New(sha256.New, secret) }},
+	}
+}
+
+// hash returns the truncated HMAC of the value.
+func (h *keyHasher) hash(v string) string {
+	mac := h.pool.Get().(hash.Hash)
+	defer h.pool.Put(mac)
+	mac.Reset()
+	mac.Write([]byte(v))
+	var sum [sha256.Size]byte
+	return hashedPrefix + hex.EncodeToString(mac.Sum(sum[:0])[:16])
+}
+
+// hashFields returns the fields with the values of matching keys hashed,
+// including the fields of nested and inlined objects when they're encoded.
+// The given slice isn't modified.
+func (h *keyHasher) hashFields(fs []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, f := range fs {
+		switch {
+		case f.Type == zapcore.NamespaceType || f.Type == zapcore.SkipType:
+			// Not a value.
+		case f.Key != "" && h.keys.match(f.Key):
+			f = zap.String(f.Key, h.hash(fieldString(f)))
+		case f.Type == zapcore.ObjectMarshalerType || f.Type == zapcore.InlineMarshalerType:
+			f.Interface = mappedObject{m: f.Interface.(zapcore.ObjectMarshaler), fn: h.hashFields}
+		default:
+			if out != nil {
+				out = append(out, f)
+			}
+			continue
+		}
+		if out == nil {
+			out = append(make([]zapcore.Field, 0, len(fs)), fs[:i]...)
+		}
+		out = append(out, f)
+	}
+	if out == nil {
+		return fs
+	}
+	return out
+}
+
+// fieldString returns the value of the field as a string, such that equal
+// values of the same type have equal strings.
+func fieldString(f zapcore.Field) string {
+	if f.Type == zapcore.StringType {
+		return f.String
+	}
+	m := zapcore.NewMapObjectEncoder()
+	f.AddTo(m)
+	return fmt.Sprint(m.Fields[f.Key])
+}
+
+// hashCore hashes the values of matching keys before they're encoded
+// by any output.
+type hashCore struct {
+	zapcore.Core
+	h *keyHasher
+}
+
+func (c *hashCore) With(fields []zapcore.Field) zapcore.Core {
+	return &hashCore{Core: c.Core.With(c.h.hashFields(fields)), h: c.h}
+}
+
+func (c *hashCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *hashCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, c.h.hashFields(fields))
+}
+
+// secretFileFlag is a flag value which reads a hex-encoded secret from a file.
+type secretFileFlag struct {
+	secret *[]byte
+	name   string
+}
+
+func (f *secretFileFlag) Get() interface{} { return *f.secret }
+func (f *secretFileFlag) String() string   { return f.name }
+
+func (f *secretFileFlag) Set(name string) error {
+	secret, err := output.ReadKeyFile(name)
+	if err != nil {
+		return err
+	}
+	*f.secret = secret
+	f.name = name
+	return nil
+}
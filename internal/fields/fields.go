@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package fields provides helpers for encoders that process fields as a whole.
+package fields
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Recorder is a zapcore.ObjectEncoder that records fields, such that
+// an encoder's context can be processed together with each entry's fields.
+type Recorder struct {
+	Fields []zapcore.Field
+}
+
+// Clone returns a copy of the Recorder.
+func (r *Recorder) Clone() Recorder {
+	return Recorder{Fields: append([]zapcore.Field(nil), r.Fields...)}
+}
+
+// With returns the recorded fields followed by the given fields.
+func (r *Recorder) With(fields []zapcore.Field) []zapcore.Field {
+	if len(r.Fields) == 0 {
+		return fields
+	}
+	all := make([]zapcore.Field, 0, len(r.Fields)+len(fields))
+	all = append(all, r.Fields...)
+	return append(all, fields...)
+}
+
+func (r *Recorder) add(f zapcore.Field) { r.Fields = append(r.Fields, f) }
+
+func (r *Recorder) AddArray(k string, v zapcore.ArrayMarshaler) error {
+	r.add(zap.Array(k, v))
+	return nil
+}
+
+func (r *Recorder) AddObject(k string, v zapcore.ObjectMarshaler) error {
+	r.add(zap.Object(k, v))
+	return nil
+}
+
+func (r *Recorder) AddReflected(k string, v interface{}) error {
+	r.add(zap.Reflect(k, v))
+	return nil
+}
+
+func (r *Recorder) AddBinary(k string, v []byte)          { r.add(zap.Binary(k, v)) }
+func (r *Recorder) AddByteString(k string, v []byte)      { r.add(zap.ByteString(k, v)) }
+func (r *Recorder) AddBool(k string, v bool)              { r.add(zap.Bool(k, v)) }
+func (r *Recorder) AddComplex128(k string, v complex128)  { r.add(zap.Complex128(k, v)) }
+func (r *Recorder) AddComplex64(k string, v complex64)    { r.add(zap.Complex64(k, v)) }
+func (r *Recorder) AddDuration(k string, v time.Duration) { r.add(zap.Duration(k, v)) }
+func (r *Recorder) AddFloat64(k string, v float64)        { r.add(zap.Float64(k, v)) }
+func (r *Recorder) AddFloat32(k string, v float32)        { r.add(zap.Float32(k, v)) }
+func (r *Recorder) AddInt(k string, v int)                { r.add(zap.Int(k, v)) }
+func (r *Recorder) AddInt64(k string, v int64)            { r.add(zap.Int64(k, v)) }
+func (r *Recorder) AddInt32(k string, v int32)            { r.add(zap.Int32(k, v)) }
+func (r *Recorder) AddInt16(k string, v int16)            { r.add(zap.Int16(k, v)) }
+func (r *Recorder) AddInt8(k string, v int8)              { r.add(zap.Int8(k, v)) }
+func (r *Recorder) AddString(k, v string)                 { r.add(zap.String(k, v)) }
+func (r *Recorder) AddTime(k string, v time.Time)         { r.add(zap.Time(k, v)) }
+func (r *Recorder) AddUint(k string, v uint)              { r.add(zap.Uint(k, v)) }
+func (r *Recorder) AddUint64(k string, v uint64)          { r.add(zap.Uint64(k, v)) }
+func (r *Recorder) AddUint32(k string, v uint32)          { r.add(zap.Uint32(k, v)) }
+func (r *Recorder) AddUint16(k string, v uint16)          { r.add(zap.Uint16(k, v)) }
+func (r *Recorder) AddUint8(k string, v uint8)            { r.add(zap.Uint8(k, v)) }
+func (r *Recorder) AddUintptr(k string, v uintptr)        { r.add(zap.Uintptr(k, v)) }
+func (r *Recorder) OpenNamespace(k string)                { r.add(zap.Namespace(k)) }
+
+// verbosityMarker identifies the field returned by Verbosity.
+var verbosityMarker = new(struct{})
+
+// Verbosity returns a field that's ignored by encoders, which conveys the
+// verbosity level of an Info entry to cores and encoders.
+func Verbosity(level int) zapcore.Field {
+	return zapcore.Field{Type: zapcore.SkipType, Integer: int64(level), Interface: verbosityMarker}
+}
+
+// VerbosityOf returns the verbosity level conveyed by the last field
+// returned by Verbosity, if any.
+func VerbosityOf(fields []zapcore.Field) (int, bool) {
+	for i := len(fields) - 1; i >= 0; i-- {
+		if f := fields[i]; f.Type == zapcore.SkipType && f.Interface == verbosityMarker {
+			return int(f.Integer), true
+		}
+	}
+	return 0, false
+}
+
+// Map returns the fields as a map, in which nested objects and namespaces
+// are maps and arrays are slices.
+func Map(fields []zapcore.Field) map[string]interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return enc.Fields
+}
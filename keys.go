@@ -0,0 +1,186 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// renameKey returns the new name of the key, if it's renamed,
+// or else its normalized name, if there's a normalizer.
+func (s *sink) renameKey(key string) string {
+	if k, ok := s.renames[key]; ok {
+		return k
+	}
+	if s.normKey != nil {
+		return s.normKey.normalize(key)
+	}
+	return key
+}
+
+// SnakeCaseKey returns the key in snake_case (e.g. "httpStatus" and
+// "HTTPStatus" become "http_status"). Dot-separated segments are
+// normalized separately. It may be given to WithKeyNormalizer.
+func SnakeCaseKey(key string) string {
+	return normalizeSegments(key, func(b *strings.Builder, i int, word string) {
+		if i > 0 {
+			b.WriteByte('_')
+		}
+		b.WriteString(strings.ToLower(word))
+	})
+}
+
+// CamelCaseKey returns the key in camelCase (e.g. "http_status" and
+// "HTTPStatus" become "httpStatus"). Dot-separated segments are
+// normalized separately. It may be given to WithKeyNormalizer.
+func CamelCaseKey(key string) string {
+	return normalizeSegments(key, func(b *strings.Builder, i int, word string) {
+		if i == 0 {
+			b.WriteString(strings.ToLower(word))
+			return
+		}
+		r, n := utf8.DecodeRuneInString(word)
+		b.WriteRune(unicode.ToUpper(r))
+		b.WriteString(strings.ToLower(word[n:]))
+	})
+}
+
+func normalizeSegments(key string, write func(b *strings.Builder, i int, word string)) string {
+	var b strings.Builder
+	for i, seg := range strings.Split(key, ".") {
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		for k, word := range splitWords(seg) {
+			write(&b, k, word)
+		}
+	}
+	return b.String()
+}
+
+// splitWords splits the string into words at separators ('_', '-', and
+// spaces) and changes of case (e.g. "HTTPStatusCode" is split into "HTTP",
+// "Status", and "Code"). Digits belong to the preceding word.
+func splitWords(s string) []string {
+	var words []string
+	start := -1
+	var prev rune
+	for i, r := range s {
+		if r == '_' || r == '-' || unicode.IsSpace(r) {
+			if start >= 0 {
+				words = append(words, s[start:i])
+				start = -1
+			}
+			prev = r
+			continue
+		}
+		if start >= 0 && unicode.IsUpper(r) {
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) {
+				words = append(words, s[start:i])
+				start = i
+			} else if next, _ := utf8.DecodeRuneInString(s[i+utf8.RuneLen(r):]); unicode.IsUpper(prev) && unicode.IsLower(next) {
+				words = append(words, s[start:i])
+				start = i
+			}
+		}
+		if start < 0 {
+			start = i
+		}
+		prev = r
+	}
+	if start >= 0 {
+		words = append(words, s[start:])
+	}
+	return words
+}
+
+// keyNormalizer caches the results of a normalizer, since keys repeat.
+// The cache is cleared when it's full.
+type keyNormalizer struct {
+	fn func(string) string
+
+	mu    sync.RWMutex
+	cache map[string]string
+}
+
+func newKeyNormalizer(fn func(string) string) *keyNormalizer {
+	if fn == nil {
+		return nil
+	}
+	return &keyNormalizer{fn: fn, cache: make(map[string]string)}
+}
+
+func (n *keyNormalizer) normalize(key string) string {
+	n.mu.RLock()
+	k, ok := n.cache[key]
+	n.mu.RUnlock()
+	if ok {
+		return k
+	}
+	k = n.fn(key)
+	n.mu.Lock()
+	if len(n.cache) >= maxInterned {
+		n.cache = make(map[string]string)
+	}
+	n.cache[key] = k
+	n.mu.Unlock()
+	return k
+}
+
+// mapFlag is a flag value for a map formatted as "k1=v1,k2=v2".
+type mapFlag struct {
+	m *map[string]string
+}
+
+func (f *mapFlag) Get() interface{} { return *f.m }
+
+func (f *mapFlag) Set(s string) error {
+	m := make(map[string]string)
+	for _, kv := range strings.Split(s, ",") {
+		if kv = strings.TrimSpace(kv); kv == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			return fmt.Errorf("zapr: invalid key=value pair: %q", kv)
+		}
+		m[k] = v
+	}
+	*f.m = m
+	return nil
+}
+
+// AcceptsCommas reports that the value may contain commas.
+func (f *mapFlag) AcceptsCommas() bool { return true }
+
+func (f *mapFlag) String() string {
+	if f.m == nil || len(*f.m) == 0 {
+		return ""
+	}
+	kvs := make([]string, 0, len(*f.m))
+	for k, v := range *f.m {
+		kvs = append(kvs, k+"="+v)
+	}
+	sort.Strings(kvs)
+	return strings.Join(kvs, ",")
+}
+
+func copyMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
@@ -123,6 +123,14 @@ func (s *lazySink) Flush() error {
 	return (*s.sink.Load()).Flush()
 }
 
+// Reopen reopens the output of the underlying LogSink, if it implements Reopener.
+func (s *lazySink) Reopen() error {
+	if r, ok := (*s.sink.Load()).(Reopener); ok {
+		return r.Reopen()
+	}
+	return nil
+}
+
 func (s *lazySink) SetSink(sink LogSink) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
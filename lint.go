@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"fmt"
+	"sort"
+)
+
+// A Warning describes a suspicious configuration.
+type Warning struct {
+	// Check is the name of the check which failed (e.g. "color-json").
+	Check string
+
+	// Message describes the problem.
+	Message string
+}
+
+func (w Warning) String() string { return w.Check + ": " + w.Message }
+
+// LintOptions returns warnings about suspicious configurations of the Options,
+// such that applications may surface them at startup. A configuration with
+// warnings remains valid.
+func LintOptions(options ...Option) []Warning {
+	c := configWithOptions(options)
+	var warnings []Warning
+	warn := func(check, format string, args ...interface{}) {
+		warnings = append(warnings, Warning{Check: check, Message: fmt.Sprintf(format, args...)})
+	}
+	if c.encoder.Name() == "json" && c.levelEncoder.Name() == "color" {
+		warn("color-json", "color level encoder writes terminal escape codes into JSON")
+	}
+	if c.level >= 5 && (c.sampleFirst != 0 || c.sampleThereafter != 0) {
+		warn("sampled-debug", "sampling is enabled with debug level %d, so debug entries may be dropped", c.level)
+	}
+	if c.development && !c.enableCaller {
+		warn("development-caller", "caller is disabled in development")
+	}
+	if !c.enableCaller && c.functionKey != "" {
+		warn("function-caller", "function key %q is set, but caller is disabled", c.functionKey)
+	}
+	if !c.enableStacktrace && len(c.stacktraceOmit) > 0 {
+		warn("stacktrace-omitted", "stacktraces are omitted from loggers, but they're disabled")
+	}
+	if c.maxValues > 0 && !c.evictValues && c.development {
+		warn("values-limit-development", "values exceeding the limit panic in development")
+	}
+	if invalid := invalidKeyPatterns(c.redactKeys); len(invalid) > 0 {
+		warn("invalid-redact-keys", "redact key patterns %q are invalid, so they only match equal keys", invalid)
+	}
+	if invalid := invalidKeyPatterns(c.hashKeys); len(invalid) > 0 {
+		warn("invalid-hashed-keys", "hashed key patterns %q are invalid, so they only match equal keys", invalid)
+	}
+	if len(c.hashKeys) > 0 && len(c.hashSecret) == 0 {
+		warn("hashed-keys-secret", "hashed keys are set, but the secret is empty, so they're logged in cleartext")
+	}
+	if c.chainKey != "" && len(c.chainSecret) == 0 {
+		warn("audit-chain-secret", "audit chain key is set, but the secret is empty, so the chain may be recomputed")
+	}
+	if c.chainKey != "" && c.encoder.Name() != "json" {
+		warn("audit-chain-encoder", "audit chain key is set, but entries can only be verified with the json encoder")
+	}
+	keys := map[string][]string{}
+	for name, key := range map[string]string{
+		"time":          c.timeKey,
+		"level":         c.levelKey,
+		"name":          c.nameKey,
+		"caller":        c.callerKey,
+		"function":      c.functionKey,
+		"message":       c.messageKey,
+		"error":         c.errorKey,
+		"error kind":    c.errorKindKey,
+		"error causes":  c.causesKey,
+		"error verbose": c.verboseKey,
+		"stacktrace":    c.stacktraceKey,
+		"goroutine":     c.goroutineKey,
+		"source":        c.sourceKey,
+		"sequence":      c.sequenceKey,
+		"origin":        c.originKey,
+		"audit chain":   c.chainKey,
+	} {
+		if key != "" {
+			keys[key] = append(keys[key], name)
+		}
+	}
+	var dups []string
+	for key, names := range keys {
+		if len(names) > 1 {
+			dups = append(dups, key)
+		}
+	}
+	sort.Strings(dups)
+	for _, key := range dups {
+		names := keys[key]
+		sort.Strings(names)
+		warn("duplicate-key", "key %q is used for %s", key, listNames(names))
+	}
+	return warnings
+}
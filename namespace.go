@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"bursavich.dev/zapr/internal/fields"
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// Group returns a Value which nests the key-value pairs, such that related
+// values are grouped in an object. Keys which aren't strings are ignored,
+// as is a key without a value.
+//
+//	log.Info("Handled request", "http", zapr.Group("method", r.Method, "status", status))
+func Group(keysAndValues ...interface{}) Value {
+	var fs []zapcore.Field
+	for i := 0; i < len(keysAndValues); {
+		switch k := keysAndValues[i].(type) {
+		case string:
+			if i+1 < len(keysAndValues) {
+				fs = append(fs, valueField(k, keysAndValues[i+1], nil))
+			}
+			i += 2
+		case zapcore.Field:
+			fs = append(fs, k)
+			i++
+		default:
+			i += 2
+		}
+	}
+	return objectValue{fieldsObject(fs)}
+}
+
+// fieldsObject encodes fields as an object.
+type fieldsObject []zapcore.Field
+
+func (fs fieldsObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, f := range fs {
+		f.AddTo(enc)
+	}
+	return nil
+}
+
+// mappedObject encodes an object whose fields are mapped by a function,
+// such that a core's processing of fields applies to nested objects.
+type mappedObject struct {
+	m  zapcore.ObjectMarshaler
+	fn func([]zapcore.Field) []zapcore.Field
+}
+
+func (o mappedObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	var r fields.Recorder
+	if err := o.m.MarshalLogObject(&r); err != nil {
+		return err
+	}
+	for _, f := range o.fn(r.Fields) {
+		f.AddTo(enc)
+	}
+	return nil
+}
+
+// namespaceEncoder nests context fields under a key, before the entry's
+// fields. Context fields are recorded rather than encoded so that they may
+// be separated from each entry's fields.
+type namespaceEncoder struct {
+	fields.Recorder
+	enc zapcore.Encoder // without context
+	key string
+}
+
+func (enc *namespaceEncoder) Clone() zapcore.Encoder {
+	return &namespaceEncoder{
+		Recorder: enc.Recorder.Clone(),
+		enc:      enc.enc,
+		key:      enc.key,
+	}
+}
+
+func (enc *namespaceEncoder) EncodeEntry(ent zapcore.Entry, fs []zapcore.Field) (*buffer.Buffer, error) {
+	if len(enc.Fields) == 0 {
+		return enc.enc.EncodeEntry(ent, fs)
+	}
+	all := make([]zapcore.Field, 0, len(fs)+1)
+	all = append(all, zap.Object(enc.key, fieldsObject(enc.Fields)))
+	return enc.enc.EncodeEntry(ent, append(all, fs...))
+}
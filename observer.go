@@ -13,6 +13,9 @@ import (
 )
 
 // Observer represent the ability to observe log metrics.
+//
+// If it also implements output.Observer, it observes the events
+// of the writer, if it implements output.Observable.
 type Observer interface {
 	// Init initializes metrics for the named logger when it's created.
 	// Logger names are not required to be unique and it may be called
@@ -52,3 +55,31 @@ func (enc *observerEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Fi
 func loggerName(log *zap.Logger) string {
 	return log.Check(zapcore.FatalLevel, "").LoggerName
 }
+
+// observerCore observes entries written to a core whose encoder is unknown,
+// such as that of a zap.Logger built elsewhere. Encoded sizes aren't known,
+// so entries are observed with zero bytes.
+type observerCore struct {
+	zapcore.Core
+	observer Observer
+}
+
+func (c *observerCore) With(fields []zapcore.Field) zapcore.Core {
+	return &observerCore{Core: c.Core.With(fields), observer: c.observer}
+}
+
+func (c *observerCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *observerCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if err := c.Core.Write(ent, fields); err != nil {
+		c.observer.ObserveEncoderError(ent.LoggerName)
+		return err
+	}
+	c.observer.ObserveEntryLogged(ent.LoggerName, ent.Level.String(), 0)
+	return nil
+}
@@ -13,10 +13,12 @@ import (
 	"sort"
 	"strconv"
 	"strings"
-	"sync"
+	"sync/atomic"
 	"time"
 
 	"bursavich.dev/zapr/encoding"
+	"bursavich.dev/zapr/output"
+	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
@@ -25,6 +27,9 @@ type config struct {
 	name  string
 	level int
 
+	// levelVar holds the level at runtime, shared by the sink and its core.
+	levelVar *atomic.Int64
+
 	timeKey       string
 	levelKey      string
 	nameKey       string
@@ -32,7 +37,15 @@ type config struct {
 	functionKey   string
 	messageKey    string
 	errorKey      string
+	errorKindKey  string
+	causesKey     string
+	verboseKey    string
 	stacktraceKey string
+	goroutineKey  string
+	sourceKey     string
+	sequenceKey   string
+	originKey     string
+	keyRenames    map[string]string
 	lineEnding    string
 
 	encoder         encoding.Encoder
@@ -42,8 +55,51 @@ type config struct {
 	callerEncoder   encoding.CallerEncoder
 
 	enableStacktrace bool
+	stacktraceLevel  zapcore.Level
+	stacktraceOmit   []string
+	stacktraceDepth  int
+	errorStacktrace  bool
+	errorLevel       zapcore.Level
+	errorLevels      map[string]zapcore.Level
+	reportService    string
+	reportVersion    string
 	enableCaller     bool
 	development      bool
+	sortFields       bool
+	duplicateKeys    DuplicateKeys
+	valuesNamespace  string
+	redactKeys       []string
+	scrubber         *Scrubber
+	hashSecret       []byte
+	hashKeys         []string
+	chainKey         string
+	chainSecret      []byte
+	auditWS          zapcore.WriteSyncer
+	auditNames       []string
+	clock            zapcore.Clock
+	zapOptions       []zap.Option
+	vmodule          []VModuleRule
+	levelFunc        func(name string) int
+	keyNormalizer    func(key string) string
+	watcher          *ConfigWatcher
+	preset           string
+	maxValues        int
+	evictValues      bool
+	traceEvents      bool
+	byteBudget       int
+	strictFields     bool
+	zapFields        bool
+	reflectDepth     int
+	reflectSize      int
+	maxValueLength   int
+	tees             []zapcore.Core
+	outputs          []*outputConfig
+	taps             []Tap
+	reopenOnSignal   bool
+	bufferSize       int
+	splitOutput      bool
+	consoleWidth     int
+	flushInterval    time.Duration
 
 	sampleTick       time.Duration
 	sampleFirst      int
@@ -55,7 +111,7 @@ type config struct {
 
 func configWithOptions(options []Option) *config {
 	c := &config{
-		ws:               stderr(),
+		ws:               output.Stderr(),
 		name:             "",
 		level:            0,
 		timeKey:          "time",
@@ -65,7 +121,12 @@ func configWithOptions(options []Option) *config {
 		functionKey:      "",
 		messageKey:       "message",
 		errorKey:         "error",
+		errorKindKey:     "",
 		stacktraceKey:    "stacktrace",
+		goroutineKey:     "",
+		sourceKey:        "",
+		sequenceKey:      "",
+		originKey:        "",
 		lineEnding:       zapcore.DefaultLineEnding,
 		encoder:          encoding.JSONEncoder(),
 		timeEncoder:      encoding.ISO8601TimeEncoder(),
@@ -73,6 +134,9 @@ func configWithOptions(options []Option) *config {
 		durationEncoder:  encoding.SecondsDurationEncoder(),
 		callerEncoder:    encoding.ShortCallerEncoder(),
 		enableStacktrace: false,
+		stacktraceLevel:  zapcore.ErrorLevel,
+		stacktraceOmit:   nil,
+		errorLevel:       zapcore.ErrorLevel,
 		enableCaller:     true,
 		development:      false,
 		sampleTick:       time.Second,
@@ -83,29 +147,11 @@ func configWithOptions(options []Option) *config {
 	for _, o := range sortedOptions(options) {
 		o.apply(c)
 	}
+	c.levelVar = new(atomic.Int64)
+	c.levelVar.Store(int64(c.level))
 	return c
 }
 
-func stderr() zapcore.WriteSyncer {
-	if err := os.Stderr.Sync(); err != nil {
-		// TODO: errors.Is(syscall.EINVAL)
-		return &stderrNoopSyncer{}
-	}
-	return zapcore.Lock(os.Stderr)
-}
-
-type stderrNoopSyncer struct {
-	mu sync.Mutex
-}
-
-func (s *stderrNoopSyncer) Write(b []byte) (int, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	return os.Stderr.Write(b)
-}
-
-func (*stderrNoopSyncer) Sync() error { return nil }
-
 // An Option applies optional configuration.
 type Option interface {
 	apply(*config)
@@ -148,10 +194,39 @@ func sortedOptions(options []Option) []Option {
 
 // WithWriteSyncer returns an Option that sets the underlying writer.
 // The default value is stderr.
+//
+// Its flag accepts an output URL (e.g. "stdout" or "file:///var/log/app.log?compress=gzip").
+// See output.Open for details.
 func WithWriteSyncer(ws zapcore.WriteSyncer) Option {
 	return opt{
-		applyFn:    func(c *config) { c.ws = ws },
-		registerFn: func(fs *flag.FlagSet) {},
+		applyFn: func(c *config) { c.ws = ws },
+		registerFn: func(fs *flag.FlagSet) {
+			fs.Var(output.Flag(&ws), "log-output", "Log output URL.")
+		},
+	}
+}
+
+// WithAuditOutput returns an Option that writes the entries of the named
+// loggers and their descendants to the given output, in place of the other
+// outputs, such that audit events are never dropped. Their entries bypass
+// the verbosity level and the sampler, and each of them is synced after it's
+// written. For example, "audit" matches loggers named "audit" and "audit.db".
+// If there are no names, "audit" is used. There's no audit output by default.
+func WithAuditOutput(ws zapcore.WriteSyncer, names ...string) Option {
+	if len(names) == 0 {
+		names = []string{defaultAuditName}
+	} else {
+		names = append([]string(nil), names...)
+	}
+	return opt{
+		applyFn: func(c *config) {
+			c.auditWS = ws
+			c.auditNames = names
+		},
+		registerFn: func(fs *flag.FlagSet) {
+			fs.Var(output.Flag(&ws), "log-audit-output", "Log audit entries to this output URL.")
+			fs.Var(&listFlag{&names}, "log-audit-names", "Log the entries of these comma-separated logger names to the audit output.")
+		},
 	}
 }
 
@@ -186,6 +261,16 @@ func WithLevel(level int) Option {
 	}
 }
 
+// WithLevelFunc returns an Option that sets a function which is consulted by
+// Enabled checks with the name of the logger, such that an application may
+// raise the verbosity of named loggers with its own dynamic policy (e.g. from
+// feature flags). An entry is enabled if its level is at most the logger's
+// level or the level returned by the function. It must be safe for concurrent
+// use and should be fast. There's no function by default.
+func WithLevelFunc(fn func(name string) int) Option {
+	return optionFunc(func(c *config) { c.levelFunc = fn })
+}
+
 // WithTimeKey returns an Option that sets the time key.
 // The default value is "time".
 func WithTimeKey(key string) Option {
@@ -263,6 +348,47 @@ func WithErrorKey(key string) Option {
 	}
 }
 
+// WithErrorKindKey returns an Option that sets the error kind key.
+// If it's not empty, the type of the error is added to error entries.
+// The default value is empty.
+func WithErrorKindKey(key string) Option {
+	return opt{
+		applyFn: func(c *config) { c.errorKindKey = key },
+		registerFn: func(fs *flag.FlagSet) {
+			fs.StringVar(&key, "log-error-kind-key", key, "Log error kind key.")
+		},
+	}
+}
+
+// WithErrorCausesKey returns an Option that sets the error causes key (e.g.
+// "errorCauses"). If it's not empty, the messages of the errors wrapped by the
+// error, as returned by errors.Unwrap, are added to error entries as an array
+// so that root causes may be queried. Each error joined by a multi-error, such
+// as one returned by errors.Join, is an element of the array so that it's
+// individually searchable. The default value is empty.
+func WithErrorCausesKey(key string) Option {
+	return opt{
+		applyFn: func(c *config) { c.causesKey = key },
+		registerFn: func(fs *flag.FlagSet) {
+			fs.StringVar(&key, "log-error-causes-key", key, "Log error causes key.")
+		},
+	}
+}
+
+// WithErrorVerboseKey returns an Option that sets the verbose error key (e.g.
+// "errorVerbose"). If it's not empty and the error implements fmt.Formatter,
+// such as those of github.com/pkg/errors, its "%+v" rendering is added to
+// error entries if it differs from its message, as by zap.Error.
+// The default value is empty.
+func WithErrorVerboseKey(key string) Option {
+	return opt{
+		applyFn: func(c *config) { c.verboseKey = key },
+		registerFn: func(fs *flag.FlagSet) {
+			fs.StringVar(&key, "log-error-verbose-key", key, "Log verbose error key.")
+		},
+	}
+}
+
 // WithStacktraceKey returns an Option that sets the stacktrace key.
 // The default value is "stacktrace".
 func WithStacktraceKey(key string) Option {
@@ -274,6 +400,157 @@ func WithStacktraceKey(key string) Option {
 	}
 }
 
+// WithGoroutineIDKey returns an Option that sets the goroutine ID key.
+// If it's not empty, the ID of the logging goroutine is added to each entry.
+// It's intended for debugging concurrency only, because getting the ID is
+// slow and IDs are reused. The default value is empty.
+func WithGoroutineIDKey(key string) Option {
+	return opt{
+		applyFn: func(c *config) { c.goroutineKey = key },
+		registerFn: func(fs *flag.FlagSet) {
+			fs.StringVar(&key, "log-goroutine-id-key", key, "Log goroutine ID key. For debugging only.")
+		},
+	}
+}
+
+// WithCallerSourceKey returns an Option that sets the caller source key.
+// If it's not empty, the source code line of the caller is read from the local
+// file system and added to Error entries. It's intended for local development.
+// The default value is empty.
+func WithCallerSourceKey(key string) Option {
+	return opt{
+		applyFn: func(c *config) { c.sourceKey = key },
+		registerFn: func(fs *flag.FlagSet) {
+			fs.StringVar(&key, "log-caller-source-key", key, "Log caller source code key.")
+		},
+	}
+}
+
+// WithSequenceKey returns an Option that sets the sequence number key.
+// If it's not empty, entries are numbered in the order they're written,
+// starting at one, across the LogSink and all of its descendants.
+// The default value is empty.
+func WithSequenceKey(key string) Option {
+	return opt{
+		applyFn: func(c *config) { c.sequenceKey = key },
+		registerFn: func(fs *flag.FlagSet) {
+			fs.StringVar(&key, "log-sequence-key", key, "Log sequence number key.")
+		},
+	}
+}
+
+// WithOriginKey returns an Option that sets the key for the file and line
+// where a logger was derived by WithValues or WithName, such that inherited
+// values may be traced to their source. It's intended for debugging.
+// The origin isn't logged if the key is empty, which is the default.
+func WithOriginKey(key string) Option {
+	return opt{
+		applyFn: func(c *config) { c.originKey = key },
+		registerFn: func(fs *flag.FlagSet) {
+			fs.StringVar(&key, "log-origin-key", key, "Log origin key.")
+		},
+	}
+}
+
+// WithRedactKeys returns an Option that replaces the values of fields whose
+// keys match any of the patterns with "[REDACTED]" before they're encoded by
+// any output, including fields added by WithValues and those of nested
+// objects, maps, and structs. Reflected values are encoded like encoding/json
+// so that their keys may be redacted, except json.Marshalers and
+// encoding.TextMarshalers, which are encoded as a whole. A pattern enclosed
+// in slashes is a regexp (e.g. "/^x-.*-key$/") and any other pattern is a
+// glob as described by path.Match, which is matched regardless of case (e.g.
+// "authorization" or "*_token"). Invalid patterns match keys which equal
+// them and are reported by LintOptions. There are no patterns by default.
+func WithRedactKeys(patterns ...string) Option {
+	patterns = append([]string(nil), patterns...)
+	return opt{
+		applyFn: func(c *config) { c.redactKeys = patterns },
+		registerFn: func(fs *flag.FlagSet) {
+			fs.Var(&listFlag{&patterns}, "log-redact-keys", "Log \"[REDACTED]\" in place of the values of keys matching these comma-separated patterns (e.g. \"authorization,*_token\").")
+		},
+	}
+}
+
+// WithHashedKeys returns an Option that replaces the values of fields whose
+// keys match any of the patterns with their HMAC-SHA256 keyed by the secret,
+// truncated to 128 bits and prefixed by "hmac:", such that identifiers remain
+// correlatable across entries without being logged in cleartext. Patterns are
+// matched like those of WithRedactKeys. Values which aren't strings are hashed
+// as formatted by fmt. It's disabled by default, or if the secret is empty.
+//
+// The secret may be set by its flag from a file containing the hex-encoded
+// secret.
+func WithHashedKeys(secret []byte, patterns ...string) Option {
+	secret = append([]byte(nil), secret...)
+	patterns = append([]string(nil), patterns...)
+	return opt{
+		applyFn: func(c *config) {
+			c.hashSecret = secret
+			c.hashKeys = patterns
+		},
+		registerFn: func(fs *flag.FlagSet) {
+			fs.Var(&listFlag{&patterns}, "log-hashed-keys", "Log HMACs in place of the values of keys matching these comma-separated patterns (e.g. \"user_id,*_email\").")
+			fs.Var(&secretFileFlag{secret: &secret}, "log-hashed-keys-secret-file", "Log HMACs of hashed keys with the hex-encoded secret in this file.")
+		},
+	}
+}
+
+// WithScrubber returns an Option that sets a Scrubber, which masks sensitive
+// text (e.g. email addresses or bearer tokens) in the string values of fields
+// before they're encoded by any output. There's no Scrubber by default.
+//
+//	scrubber := zapr.NewScrubber(true, zapr.EmailScrubRule, zapr.BearerTokenScrubRule)
+//	log, sink := zapr.NewLogger(zapr.WithScrubber(scrubber))
+func WithScrubber(s *Scrubber) Option {
+	return optionFunc(func(c *config) { c.scrubber = s })
+}
+
+// WithKeyNormalizer returns an Option that sets a function which normalizes
+// the keys of fields passed to the Logger, such that a naming convention is
+// enforced (e.g. SnakeCaseKey or CamelCaseKey). Keys which are renamed by
+// WithKeyRenames aren't normalized. Results are cached, so it should be
+// deterministic. There's no normalizer by default.
+func WithKeyNormalizer(fn func(key string) string) Option {
+	return optionFunc(func(c *config) { c.keyNormalizer = fn })
+}
+
+// WithKeyRenames returns an Option that renames the keys of fields passed to
+// the LogSink, which may be used to migrate keys to a new schema without
+// changing every call site. Reserved keys (e.g. the message key) are set by
+// their own Options. There are no renames by default.
+func WithKeyRenames(renames map[string]string) Option {
+	renames = copyMap(renames)
+	return opt{
+		applyFn: func(c *config) { c.keyRenames = renames },
+		registerFn: func(fs *flag.FlagSet) {
+			fs.Var(&mapFlag{&renames}, "log-key-renames", "Log field key renames (e.g. \"old1=new1,old2=new2\").")
+		},
+	}
+}
+
+// WithAuditChain returns an Option that adds a field with the given key to
+// each entry, whose value is the hex-encoded SHA-256 hash of the previous
+// entry's hash and its encoded line, such that deleted or altered entries
+// of audit logs may be detected by VerifyAuditChain. If the secret isn't
+// empty, HMAC-SHA256 is used, such that the chain can't be recomputed without
+// it. Each output is chained separately and the value of its first entry is
+// "start". Entries of a chained output are encoded and written one at a time.
+// It's disabled by default, or if the key is empty.
+func WithAuditChain(key string, secret []byte) Option {
+	secret = append([]byte(nil), secret...)
+	return opt{
+		applyFn: func(c *config) {
+			c.chainKey = key
+			c.chainSecret = secret
+		},
+		registerFn: func(fs *flag.FlagSet) {
+			fs.StringVar(&key, "log-audit-chain-key", key, "Log the rolling hash of entries with this key (e.g. \"chain\").")
+			fs.Var(&secretFileFlag{secret: &secret}, "log-audit-chain-secret-file", "Log the rolling HMAC of entries with the hex-encoded secret in this file.")
+		},
+	}
+}
+
 // WithLineEnding returns an Option that sets the line-ending.
 // The default value is "\n".
 func WithLineEnding(ending string) Option {
@@ -285,19 +562,26 @@ func WithLineEnding(ending string) Option {
 	}
 }
 
+// WithConsoleWidth returns an Option that wraps lines of the console encoder
+// which are wider than width and indents their continuations. If width is
+// negative, the width of the terminal is used, if it's known. Lines aren't
+// wrapped by default.
+func WithConsoleWidth(width int) Option {
+	return opt{
+		applyFn: func(c *config) { c.consoleWidth = width },
+		registerFn: func(fs *flag.FlagSet) {
+			fs.IntVar(&width, "log-console-width", width, "Wrap console log lines wider than this (0 for no wrapping, -1 for terminal width).")
+		},
+	}
+}
+
 // WithEncoder returns an Option that sets the encoder.
 // The default value is a JSONEncoder.
 func WithEncoder(encoder encoding.Encoder) Option {
-	var names []string
-	for _, e := range encoding.Encoders() {
-		names = append(names, e.Name())
-	}
-	sort.Strings(names)
-	usage := fmt.Sprintf("Log format (e.g. %s).", listNames(names))
-
 	return opt{
 		applyFn: func(c *config) { c.encoder = encoder },
 		registerFn: func(fs *flag.FlagSet) {
+			usage := fmt.Sprintf("Log format (e.g. %s).", listNames(encoding.EncoderNames()))
 			fs.Var(encoding.EncoderFlag(&encoder), "log-format", usage)
 		},
 	}
@@ -306,34 +590,29 @@ func WithEncoder(encoder encoding.Encoder) Option {
 // WithTimeEncoder returns an Option that sets the encoder.
 // The default encoding is ISO 8601.
 func WithTimeEncoder(encoder encoding.TimeEncoder) Option {
-	var names []string
-	for _, e := range encoding.TimeEncoders() {
-		names = append(names, e.Name())
-	}
-	sort.Strings(names)
-	usage := fmt.Sprintf("Log time format (e.g. %s).", listNames(names))
-
 	return opt{
 		applyFn: func(c *config) { c.timeEncoder = encoder },
 		registerFn: func(fs *flag.FlagSet) {
+			usage := fmt.Sprintf("Log time format (e.g. %s, or layout:2006-01-02T15:04:05.000Z07:00).", listNames(encoding.TimeEncoderNames()))
 			fs.Var(encoding.TimeEncoderFlag(&encoder), "log-time-format", usage)
 		},
 	}
 }
 
+// WithTimeLayout returns an Option that sets the time encoder to format times
+// with the given Go time layout (e.g. "2006-01-02 15:04:05.000"), as described
+// by encoding.LayoutTimeEncoder. Its flag is that of WithTimeEncoder.
+func WithTimeLayout(layout string) Option {
+	return WithTimeEncoder(encoding.LayoutTimeEncoder(layout))
+}
+
 // WithLevelEncoder returns an Option that sets the level encoder.
 // The default encoding is uppercase.
 func WithLevelEncoder(encoder encoding.LevelEncoder) Option {
-	var names []string
-	for _, e := range encoding.LevelEncoders() {
-		names = append(names, e.Name())
-	}
-	sort.Strings(names)
-	usage := fmt.Sprintf("Log level format (e.g. %s).", listNames(names))
-
 	return opt{
 		applyFn: func(c *config) { c.levelEncoder = encoder },
 		registerFn: func(fs *flag.FlagSet) {
+			usage := fmt.Sprintf("Log level format (e.g. %s, or words:warn=WARNING,error=ERR).", listNames(encoding.LevelEncoderNames()))
 			fs.Var(encoding.LevelEncoderFlag(&encoder), "log-level-format", usage)
 		},
 	}
@@ -342,16 +621,10 @@ func WithLevelEncoder(encoder encoding.LevelEncoder) Option {
 // WithDurationEncoder returns an Option that sets the duration encoder.
 // The default encoding is seconds.
 func WithDurationEncoder(encoder encoding.DurationEncoder) Option {
-	var names []string
-	for _, e := range encoding.DurationEncoders() {
-		names = append(names, e.Name())
-	}
-	sort.Strings(names)
-	usage := fmt.Sprintf("Log duration format (e.g. %s).", listNames(names))
-
 	return opt{
 		applyFn: func(c *config) { c.durationEncoder = encoder },
 		registerFn: func(fs *flag.FlagSet) {
+			usage := fmt.Sprintf("Log duration format (e.g. %s).", listNames(encoding.DurationEncoderNames()))
 			fs.Var(encoding.DurationEncoderFlag(&encoder), "log-duration-format", usage)
 		},
 	}
@@ -360,28 +633,73 @@ func WithDurationEncoder(encoder encoding.DurationEncoder) Option {
 // WithCallerEncoder returns an Option that sets the caller encoder.
 // The default encoding is short.
 func WithCallerEncoder(encoder encoding.CallerEncoder) Option {
-	var names []string
-	for _, e := range encoding.CallerEncoders() {
-		names = append(names, e.Name())
-	}
-	sort.Strings(names)
-	usage := fmt.Sprintf("Log caller format (e.g. %s).", listNames(names))
-
 	return opt{
 		applyFn: func(c *config) { c.callerEncoder = encoder },
 		registerFn: func(fs *flag.FlagSet) {
+			usage := fmt.Sprintf("Log caller format (e.g. %s, or short:3).", listNames(encoding.CallerEncoderNames()))
 			fs.Var(encoding.CallerEncoderFlag(&encoder), "log-caller-format", usage)
 		},
 	}
 }
 
 // WithCallerEnabled returns an Option that sets whether the caller field
-// is enabled. It's enabled by default.
+// is enabled. It's enabled by default. Its flag also accepts the name of a
+// caller format (e.g. "-log-opts=caller=full"), which enables the caller field
+// and sets the format of the caller format flag, if it's registered.
 func WithCallerEnabled(enabled bool) Option {
 	return opt{
 		applyFn: func(c *config) { c.enableCaller = enabled },
 		registerFn: func(fs *flag.FlagSet) {
-			fs.BoolVar(&enabled, "log-caller", enabled, "Log caller file and line.")
+			usage := "Log caller file and line, or the format in which to log it (e.g. full)."
+			fs.Var(&callerFlag{fs: fs, enabled: &enabled}, "log-caller", usage)
+		},
+	}
+}
+
+type callerFlag struct {
+	fs      *flag.FlagSet
+	enabled *bool
+}
+
+func (f *callerFlag) IsBoolFlag() bool { return true }
+func (f *callerFlag) Get() interface{} { return *f.enabled }
+func (f *callerFlag) String() string {
+	if f.enabled == nil {
+		return ""
+	}
+	return strconv.FormatBool(*f.enabled)
+}
+
+func (f *callerFlag) Set(s string) error {
+	enabled, err := strconv.ParseBool(s)
+	if err != nil {
+		// The caller format flag is looked up by its original name,
+		// since its value is shared if the flags are renamed.
+		format := f.fs.Lookup("log-caller-format")
+		if format == nil {
+			return err
+		}
+		if err := format.Value.Set(s); err != nil {
+			return err
+		}
+		enabled = true
+	}
+	*f.enabled = enabled
+	return nil
+}
+
+// WithErrorStacktrace returns an Option that sets whether the stacktraces
+// carried by errors are logged. If it's enabled and the error passed to Error,
+// or any error it wraps, has a StackTrace or Stack method, such as those of
+// github.com/pkg/errors, the innermost stack is logged with the stacktrace key
+// in place of the call site's stacktrace, which usually identifies the logging
+// call rather than the failure. It's logged whether or not stacktraces are
+// otherwise enabled. It's disabled by default.
+func WithErrorStacktrace(enabled bool) Option {
+	return opt{
+		applyFn: func(c *config) { c.errorStacktrace = enabled },
+		registerFn: func(fs *flag.FlagSet) {
+			fs.BoolVar(&enabled, "log-error-stacktrace", enabled, "Log stacktraces carried by errors.")
 		},
 	}
 }
@@ -415,6 +733,404 @@ func WithSampler(tick time.Duration, first, thereafter int, opts ...zapcore.Samp
 	}
 }
 
+// WithStacktraceLevel returns an Option that sets the minimum level of
+// entries with stacktraces, if they're enabled. The default is error.
+func WithStacktraceLevel(level zapcore.Level) Option {
+	return opt{
+		applyFn: func(c *config) { c.stacktraceLevel = level },
+		registerFn: func(fs *flag.FlagSet) {
+			fs.Var(&level, "log-stacktrace-level", "Log stacktrace on entries at or above this zap level (e.g. \"error\" or \"dpanic\").")
+		},
+	}
+}
+
+// WithErrorLevel returns an Option that sets the zap level of entries logged
+// by Error (e.g. warn). The default is error.
+func WithErrorLevel(level zapcore.Level) Option {
+	return opt{
+		applyFn: func(c *config) { c.errorLevel = level },
+		registerFn: func(fs *flag.FlagSet) {
+			fs.Var(&level, "log-error-level", "Log errors at this zap level (e.g. \"warn\" or \"error\").")
+		},
+	}
+}
+
+// WithErrorLevels returns an Option that sets the zap levels of entries logged
+// by Error for the named loggers and their descendants, overriding the level
+// set by WithErrorLevel (e.g. so that the retryable errors of a controller are
+// logged as warnings). For example, "foo" matches loggers named "foo" and
+// "foo.bar". There are no names by default.
+func WithErrorLevels(levels map[string]zapcore.Level) Option {
+	levels = copyErrorLevels(levels)
+	return opt{
+		applyFn: func(c *config) { c.errorLevels = levels },
+		registerFn: func(fs *flag.FlagSet) {
+			fs.Var(&errorLevelsFlag{&levels}, "log-error-levels", "Log errors of named loggers at these zap levels (e.g. \"app.db=warn,app.http=info\").")
+		},
+	}
+}
+
+// WithStacktraceDepth returns an Option that limits the number of frames
+// captured in stacktraces, which bounds both the cost of capturing them and
+// the size of entries. Frames of zap, logr, and the sink are excluded.
+// There's no limit by default.
+func WithStacktraceDepth(depth int) Option {
+	return opt{
+		applyFn: func(c *config) { c.stacktraceDepth = depth },
+		registerFn: func(fs *flag.FlagSet) {
+			fs.IntVar(&depth, "log-stacktrace-depth", depth, "Log stacktraces with at most this many frames (0 for no limit).")
+		},
+	}
+}
+
+// WithStacktraceOmitted returns an Option that omits stacktraces from the
+// entries of the named loggers and their descendants, even if stacktraces are
+// enabled. For example, "foo" matches loggers named "foo" and "foo.bar".
+// Their stacktraces aren't captured, but those carried by errors are still
+// logged if WithErrorStacktrace is enabled. There are no names by default.
+func WithStacktraceOmitted(names ...string) Option {
+	names = append([]string(nil), names...)
+	return opt{
+		applyFn: func(c *config) { c.stacktraceOmit = names },
+		registerFn: func(fs *flag.FlagSet) {
+			fs.Var(&listFlag{&names}, "log-stacktrace-omit", "Log no stacktraces for these comma-separated logger names.")
+		},
+	}
+}
+
+// WithSortedFields returns an Option that sets whether fields are sorted by
+// key, after the entry's header, including those added by WithValues and
+// those of nested objects. It's disabled by default.
+func WithSortedFields(enabled bool) Option {
+	return opt{
+		applyFn: func(c *config) { c.sortFields = enabled },
+		registerFn: func(fs *flag.FlagSet) {
+			fs.BoolVar(&enabled, "log-sorted-fields", enabled, "Log fields sorted by key.")
+		},
+	}
+}
+
+// WithDuplicateKeys returns an Option that sets the policy for fields with
+// repeated keys, including those added by WithValues and those of the entry's
+// header (e.g. "message"), since some parsers reject entries with duplicate
+// keys. Fields repeating a header key are suffixed, since the header is always
+// encoded. The default policy is AllowDuplicateKeys.
+func WithDuplicateKeys(policy DuplicateKeys) Option {
+	return opt{
+		applyFn: func(c *config) { c.duplicateKeys = policy },
+		registerFn: func(fs *flag.FlagSet) {
+			fs.Var(&policy, "log-duplicate-keys", "Log fields with repeated keys by this policy (e.g. \"allow\", \"last\", or \"suffix\").")
+		},
+	}
+}
+
+// WithValuesNamespace returns an Option that nests the values added by
+// WithValues under the key, such that they don't collide with the keys of
+// entries or their fields. It's disabled by default, or if the key is empty.
+func WithValuesNamespace(key string) Option {
+	return opt{
+		applyFn: func(c *config) { c.valuesNamespace = key },
+		registerFn: func(fs *flag.FlagSet) {
+			fs.StringVar(&key, "log-values-namespace", key, "Log values added to loggers nested under this key.")
+		},
+	}
+}
+
+// WithValuesLimit returns an Option that limits the number of values
+// inherited by each logger from calls to WithValues. Values that would exceed
+// the limit either evict the oldest values or, if evict is false, are refused
+// with a DPanic. Loggers with identical chains of simple values share memory.
+// There's no limit by default.
+func WithValuesLimit(max int, evict bool) Option {
+	return opt{
+		applyFn: func(c *config) {
+			c.maxValues = max
+			c.evictValues = evict
+		},
+		registerFn: func(fs *flag.FlagSet) {
+			fs.IntVar(&max, "log-values-limit", max, "Limit the number of values inherited by each logger (0 for no limit).")
+			fs.BoolVar(&evict, "log-values-evict", evict, "Evict the oldest values when exceeding the values limit, instead of refusing new values.")
+		},
+	}
+}
+
+// WithSplitOutput returns an Option that sets whether entries below the
+// error level are written to stdout and the rest are written to stderr,
+// instead of the output, as expected by many container platforms.
+// It's disabled by default.
+func WithSplitOutput(enabled bool) Option {
+	return opt{
+		applyFn: func(c *config) { c.splitOutput = enabled },
+		registerFn: func(fs *flag.FlagSet) {
+			fs.BoolVar(&enabled, "log-split-output", enabled, "Log errors to stderr and everything else to stdout, instead of the log output.")
+		},
+	}
+}
+
+// WithBufferedOutput returns an Option that buffers up to size bytes of
+// output, which are written when the buffer is full, at least once per flush
+// interval, and when the logger is flushed. If the interval is zero, it's 30s.
+// Output isn't buffered by default.
+func WithBufferedOutput(size int, flushInterval time.Duration) Option {
+	return opt{
+		applyFn: func(c *config) {
+			c.bufferSize = size
+			c.flushInterval = flushInterval
+		},
+		registerFn: func(fs *flag.FlagSet) {
+			fs.IntVar(&size, "log-buffer-size", size, "Buffer up to this many bytes of output (0 for no buffer).")
+			fs.DurationVar(&flushInterval, "log-flush-interval", flushInterval, "Maximum duration buffered output is held (0 for 30s).")
+		},
+	}
+}
+
+// WithTraceEvents returns an Option that sets whether a runtime/trace log
+// event is emitted with the level, logger name, and message of each entry
+// while execution tracing is enabled, such that traces and logs may be
+// correlated. It's disabled by default.
+func WithTraceEvents(enabled bool) Option {
+	return opt{
+		applyFn: func(c *config) { c.traceEvents = enabled },
+		registerFn: func(fs *flag.FlagSet) {
+			fs.BoolVar(&enabled, "log-trace-events", enabled, "Log entries as events in execution traces.")
+		},
+	}
+}
+
+// WithByteBudget returns an Option that limits the bytes of encoded entries
+// per second. Once the budget is spent, entries are dropped for the rest of
+// the second, and sampling is tightened automatically until the output is
+// well under budget again. Errors aren't sampled, but they're subject to the
+// budget. There's no limit by default.
+func WithByteBudget(bytesPerSecond int) Option {
+	return opt{
+		applyFn: func(c *config) { c.byteBudget = bytesPerSecond },
+		registerFn: func(fs *flag.FlagSet) {
+			fs.IntVar(&bytesPerSecond, "log-byte-budget", bytesPerSecond, "Limit encoded log output to this many bytes per second (0 for no limit).")
+		},
+	}
+}
+
+// WithStrictFields returns an Option that sets whether keys which aren't
+// declared with DeclareField are flagged with a DPanic, which panics in
+// development. It's intended for tests. It's disabled by default.
+func WithStrictFields(enabled bool) Option {
+	return opt{
+		applyFn: func(c *config) { c.strictFields = enabled },
+		registerFn: func(fs *flag.FlagSet) {
+			fs.BoolVar(&enabled, "log-strict-fields", enabled, "Log a DPanic for undeclared field keys.")
+		},
+	}
+}
+
+// WithZapFieldsAllowed returns an Option that sets whether zap Fields may be
+// passed in place of key-value pairs, as a fast path for strongly-typed values,
+// without a DPanic. It's disabled by default, since other LogSinks see them as
+// non-string keys; the Field type of this package is always allowed.
+func WithZapFieldsAllowed(enabled bool) Option {
+	return opt{
+		applyFn: func(c *config) { c.zapFields = enabled },
+		registerFn: func(fs *flag.FlagSet) {
+			fs.BoolVar(&enabled, "log-zap-fields-allowed", enabled, "Log zap Fields passed in place of key-value pairs without a DPanic.")
+		},
+	}
+}
+
+// WithReflectLimits returns an Option that limits the nesting depth and the
+// approximate encoded size in bytes of each value which would otherwise be
+// encoded by reflection, such as a struct, map, or slice, to protect against
+// logging huge or cyclic object graphs. Values are encoded like encoding/json,
+// except that values beyond the depth are replaced by "<truncated>", strings
+// exceeding the size are cut short with "…", and the remaining elements of
+// objects and arrays are replaced by a "_truncated" key or "<truncated>"
+// element, respectively. A depth of 1 encodes only the top-level fields.
+// There are no limits by default, or if they're zero.
+func WithReflectLimits(depth, size int) Option {
+	return opt{
+		applyFn: func(c *config) {
+			c.reflectDepth = depth
+			c.reflectSize = size
+		},
+		registerFn: func(fs *flag.FlagSet) {
+			fs.IntVar(&depth, "log-reflect-depth", depth, "Limit the nesting depth of values encoded by reflection (0 for no limit).")
+			fs.IntVar(&size, "log-reflect-size", size, "Limit the approximate encoded size in bytes of each value encoded by reflection (0 for no limit).")
+		},
+	}
+}
+
+// WithMaxValueLength returns an Option that limits the length in bytes of
+// string and byte values, such that a huge payload can't overwhelm the outputs.
+// Longer values are cut short and followed by a "…(+N bytes)" marker of the
+// number of bytes removed, which is also observed if the Observer is
+// a TruncationObserver. Binary values are truncated before they're encoded
+// as base64. There's no limit by default, or if it's zero.
+func WithMaxValueLength(n int) Option {
+	return opt{
+		applyFn: func(c *config) { c.maxValueLength = n },
+		registerFn: func(fs *flag.FlagSet) {
+			fs.IntVar(&n, "log-max-value-length", n, "Limit the length in bytes of string and byte values (0 for no limit).")
+		},
+	}
+}
+
+// WithReopenSignal returns an Option that sets whether the output is reopened
+// when the process receives SIGHUP, such that files moved by logrotate are
+// replaced without truncation. It's only supported on unix platforms and
+// it's disabled by default.
+func WithReopenSignal(enabled bool) Option {
+	return opt{
+		applyFn: func(c *config) { c.reopenOnSignal = enabled },
+		registerFn: func(fs *flag.FlagSet) {
+			fs.BoolVar(&enabled, "log-reopen-on-sighup", enabled, "Reopen the log output on SIGHUP.")
+		},
+	}
+}
+
+// WithTee returns an Option that tees entries to the given cores in addition
+// to the writer, such as cores that report errors to external services.
+// There are none by default.
+func WithTee(cores ...zapcore.Core) Option {
+	cores = append([]zapcore.Core(nil), cores...)
+	return optionFunc(func(c *config) { c.tees = cores })
+}
+
+// WithTap returns an Option that sends snapshots of logged entries to the
+// given taps, such as processors that detect anomalies. There are none by
+// default.
+func WithTap(taps ...Tap) Option {
+	taps = append([]Tap(nil), taps...)
+	return optionFunc(func(c *config) { c.taps = taps })
+}
+
+// WithClock returns an Option that sets the clock used to timestamp entries.
+// The default clock is the system clock.
+func WithClock(clock zapcore.Clock) Option {
+	return optionFunc(func(c *config) { c.clock = clock })
+}
+
+// WithZapOptions returns an Option that applies the given zap options, such
+// as hooks, fields, or wrapped cores, to the logger after zapr's own options.
+// There are none by default.
+func WithZapOptions(opts ...zap.Option) Option {
+	opts = append([]zap.Option(nil), opts...)
+	return optionFunc(func(c *config) { c.zapOptions = opts })
+}
+
+// WithDeterministicOutput returns an Option that enables a set of options
+// which produce identical output for identical inputs, given a deterministic
+// clock (e.g. one which always returns the same time). Fields are sorted by
+// key, entries are numbered by the "seq" key, and sampling is disabled.
+// It's intended for snapshot testing.
+func WithDeterministicOutput(clock zapcore.Clock) Option {
+	return opt{
+		applyFn: func(c *config) {
+			c.clock = clock
+			c.sortFields = true
+			c.sequenceKey = "seq"
+			c.goroutineKey = ""
+			c.sampleFirst = 0
+			c.sampleThereafter = 0
+		},
+		registerFn: func(fs *flag.FlagSet) {},
+		wgt:        1,
+	}
+}
+
+// WithGooglePreset returns an Option that enables a set of options for
+// Google Cloud Logging, which natively parses JSON entries written to
+// stdout or stderr by services such as GKE and Cloud Run. The keys and
+// encoders are set for severity, timestamp, message, and source location.
+func WithGooglePreset() Option {
+	return opt{
+		applyFn: func(c *config) {
+			c.timeKey = "timestamp"
+			c.levelKey = "severity"
+			c.messageKey = "message"
+			c.callerKey = "logging.googleapis.com/sourceLocation"
+			c.functionKey = "" // included in the source location
+			c.encoder = encoding.JSONEncoder()
+			c.timeEncoder = encoding.RFC3339TimeEncoder()
+			c.levelEncoder = encoding.GoogleLevelEncoder()
+			c.callerEncoder = encoding.GoogleCallerEncoder()
+		},
+		registerFn: func(fs *flag.FlagSet) {},
+		wgt:        1,
+	}
+}
+
+// WithGoogleErrorReporting returns an Option that shapes entries at or above
+// the error level as Google Cloud Error Reporting events, such that they're
+// aggregated into error groups. Entries are given the "@type" and
+// "serviceContext" keys, and their stacktraces are moved to the "stack_trace"
+// key in the format of runtime.Stack. Stacktraces are optional, but enabling
+// them improves the grouping. It's disabled by default, or if the service is
+// empty.
+func WithGoogleErrorReporting(service, version string) Option {
+	return opt{
+		applyFn: func(c *config) {
+			c.reportService = service
+			c.reportVersion = version
+		},
+		registerFn: func(fs *flag.FlagSet) {
+			fs.StringVar(&service, "log-error-reporting-service", service, "Log errors as Google Cloud Error Reporting events of this service.")
+			fs.StringVar(&version, "log-error-reporting-version", version, "Log errors as Google Cloud Error Reporting events of this service version.")
+		},
+	}
+}
+
+// WithDatadogPreset returns an Option that enables a set of options for
+// Datadog, which natively parses JSON entries with its standard attributes.
+// The keys and encoders are set for status, timestamp, message, logger name,
+// and error kind, message, and stack. Use DatadogTraceContext to correlate
+// entries with traces.
+func WithDatadogPreset() Option {
+	return opt{
+		applyFn: func(c *config) {
+			c.timeKey = "timestamp"
+			c.levelKey = "status"
+			c.nameKey = "logger.name"
+			c.messageKey = "message"
+			c.errorKey = "error.message"
+			c.errorKindKey = "error.kind"
+			c.stacktraceKey = "error.stack"
+			c.encoder = encoding.JSONEncoder()
+			c.timeEncoder = encoding.RFC3339TimeEncoder()
+			c.levelEncoder = encoding.LowercaseLevelEncoder()
+		},
+		registerFn: func(fs *flag.FlagSet) {},
+		wgt:        1,
+	}
+}
+
+// WithLowOverheadProfile returns an Option that enables a set of options for
+// environments where the overhead of logging must be minimal, such as CLIs,
+// init containers, and embedded targets. Caller and stacktrace capture,
+// sampling, and observation are disabled, additional keys are cleared, and
+// entries are encoded as JSON with epoch timestamps. Options given explicitly
+// take precedence.
+func WithLowOverheadProfile() Option {
+	return opt{
+		applyFn: func(c *config) {
+			c.enableCaller = false
+			c.enableStacktrace = false
+			c.sampleFirst = 0
+			c.sampleThereafter = 0
+			c.observer = nil
+			c.functionKey = ""
+			c.goroutineKey = ""
+			c.sourceKey = ""
+			c.sequenceKey = ""
+			c.originKey = ""
+			c.sortFields = false
+			c.traceEvents = false
+			c.encoder = encoding.JSONEncoder()
+			c.timeEncoder = encoding.SecondsTimeEncoder()
+		},
+		registerFn: func(fs *flag.FlagSet) {},
+		wgt:        1,
+	}
+}
+
 // WithDevelopmentOptions returns an Option that enables a set of
 // development-friendly options.
 func WithDevelopmentOptions(enabled bool) Option {
@@ -425,6 +1141,7 @@ func WithDevelopmentOptions(enabled bool) Option {
 			}
 			c.level = 3
 			c.functionKey = "func"
+			c.sourceKey = "source"
 			c.encoder = encoding.ConsoleEncoder()
 			c.levelEncoder = encoding.ColorLevelEncoder()
 			c.durationEncoder = encoding.StringDurationEncoder()
@@ -439,6 +1156,11 @@ func WithDevelopmentOptions(enabled bool) Option {
 }
 
 // RegisterFlags registers the given Options with the FlagSet.
+//
+// The "log-opts" flag is also registered, which sets the other flags from
+// comma-separated key=value pairs of their names without the "log-" prefix
+// (e.g. "-log-opts=level=3,format=console"), for environments where passing
+// many flags is awkward. Unknown keys are errors.
 func RegisterFlags(fs *flag.FlagSet, options ...Option) []Option {
 	if fs == nil {
 		fs = flag.CommandLine
@@ -446,17 +1168,78 @@ func RegisterFlags(fs *flag.FlagSet, options ...Option) []Option {
 	for _, o := range options {
 		o.register(fs)
 	}
+	registerOptsFlag(fs, "log-")
 	return options
 }
 
+// RegisterFlagsWithPrefix registers the given Options with the FlagSet, as
+// described by RegisterFlags, replacing the "log-" prefix of the flag names
+// with the given prefix (e.g. with the "acme-log-" prefix, the "log-level" flag
+// is named "acme-log-level"), such that several components of a binary may
+// register their own flags without colliding.
+func RegisterFlagsWithPrefix(fs *flag.FlagSet, prefix string, options ...Option) []Option {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+	tmp := flag.NewFlagSet("", flag.ContinueOnError)
+	for _, o := range options {
+		o.register(tmp)
+	}
+	tmp.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, prefix+strings.TrimPrefix(f.Name, "log-"), f.Usage)
+	})
+	registerOptsFlag(fs, prefix)
+	return options
+}
+
+// RegisterFlagsWithEnv registers the given Options with the FlagSet, as
+// described by RegisterFlags, and sets their default values from environment
+// variables named as described by OptionsFromEnv (e.g. with the "LOG" prefix,
+// the "log-level" and "log-format" flags default to LOG_LEVEL and LOG_FORMAT).
+// Values are taken in order of precedence from flags that are explicitly set,
+// environment variables, and then the given Options. Each flag's usage names
+// its variable. It returns an error if any variable has an invalid value.
+func RegisterFlagsWithEnv(fs *flag.FlagSet, prefix string, options ...Option) ([]Option, error) {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+	if prefix == "" {
+		prefix = "ZAPR"
+	}
+	tmp := flag.NewFlagSet("", flag.ContinueOnError)
+	for _, o := range options {
+		o.register(tmp)
+	}
+	var err error
+	tmp.VisitAll(func(f *flag.Flag) {
+		key := envKey(prefix, f.Name)
+		usage := f.Usage + " (env " + key + ")"
+		if v, ok := os.LookupEnv(key); ok && err == nil {
+			if err = f.Value.Set(v); err != nil {
+				err = fmt.Errorf("zapr: invalid environment variable: %s: %w", key, err)
+			}
+		}
+		fs.Var(f.Value, f.Name, usage)
+	})
+	if err != nil {
+		return nil, err
+	}
+	registerOptsFlag(fs, "log-")
+	return options, nil
+}
+
 // AllOptions returns all Options with the given overrides.
 func AllOptions(overrides ...Option) []Option {
 	c := configWithOptions(overrides)
 	return []Option{
 		WithWriteSyncer(c.ws),
+		WithAuditOutput(c.auditWS, c.auditNames...),
 		WithObserver(c.observer),
 		WithName(c.name),
 		WithLevel(c.level),
+		WithVModule(c.vmodule...),
+		WithLevelFunc(c.levelFunc),
+		withConfigWatcher(c.watcher),
 		WithTimeKey(c.timeKey),
 		WithLevelKey(c.levelKey),
 		WithNameKey(c.nameKey),
@@ -464,17 +1247,57 @@ func AllOptions(overrides ...Option) []Option {
 		WithFunctionKey(c.functionKey),
 		WithMessageKey(c.messageKey),
 		WithErrorKey(c.errorKey),
+		WithErrorKindKey(c.errorKindKey),
+		WithErrorCausesKey(c.causesKey),
+		WithErrorVerboseKey(c.verboseKey),
 		WithStacktraceKey(c.stacktraceKey),
+		WithGoroutineIDKey(c.goroutineKey),
+		WithCallerSourceKey(c.sourceKey),
+		WithSequenceKey(c.sequenceKey),
+		WithOriginKey(c.originKey),
+		WithKeyRenames(c.keyRenames),
+		WithKeyNormalizer(c.keyNormalizer),
+		WithRedactKeys(c.redactKeys...),
+		WithScrubber(c.scrubber),
+		WithHashedKeys(c.hashSecret, c.hashKeys...),
+		WithAuditChain(c.chainKey, c.chainSecret),
 		WithLineEnding(c.lineEnding),
 		WithEncoder(c.encoder),
+		WithConsoleWidth(c.consoleWidth),
 		WithTimeEncoder(c.timeEncoder),
 		WithLevelEncoder(c.levelEncoder),
 		WithDurationEncoder(c.durationEncoder),
 		WithCallerEncoder(c.callerEncoder),
 		WithCallerEnabled(c.enableCaller),
 		WithStacktraceEnabled(c.enableStacktrace),
+		WithStacktraceLevel(c.stacktraceLevel),
+		WithErrorLevel(c.errorLevel),
+		WithErrorLevels(c.errorLevels),
+		WithGoogleErrorReporting(c.reportService, c.reportVersion),
+		WithStacktraceOmitted(c.stacktraceOmit...),
+		WithStacktraceDepth(c.stacktraceDepth),
+		WithErrorStacktrace(c.errorStacktrace),
+		WithSortedFields(c.sortFields),
+		WithDuplicateKeys(c.duplicateKeys),
+		WithValuesNamespace(c.valuesNamespace),
+		WithValuesLimit(c.maxValues, c.evictValues),
+		WithTraceEvents(c.traceEvents),
+		WithByteBudget(c.byteBudget),
+		WithStrictFields(c.strictFields),
+		WithZapFieldsAllowed(c.zapFields),
+		WithReflectLimits(c.reflectDepth, c.reflectSize),
+		WithMaxValueLength(c.maxValueLength),
+		WithReopenSignal(c.reopenOnSignal),
+		WithSplitOutput(c.splitOutput),
+		WithBufferedOutput(c.bufferSize, c.flushInterval),
+		WithTee(c.tees...),
+		withOutputs(c.outputs),
+		WithTap(c.taps...),
+		WithClock(c.clock),
+		WithZapOptions(c.zapOptions...),
 		WithSampler(c.sampleTick, c.sampleFirst, c.sampleThereafter, c.sampleOpts...),
 		WithDevelopmentOptions(c.development),
+		WithPreset(c.preset),
 	}
 }
 
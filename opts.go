@@ -0,0 +1,140 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// registerOptsFlag registers a flag named with the prefix and "opts", unless
+// it's already registered, which sets the other flags with the prefix from
+// comma-separated key=value pairs (e.g. "level=3,format=console"). The values
+// of lists may contain commas where the text following a comma isn't a key
+// (e.g. "vmodule=gc*=3,server.go=5"). The key of a boolean flag may be given
+// without a value to set it to true (e.g. "development").
+func registerOptsFlag(fs *flag.FlagSet, prefix string) {
+	name := prefix + "opts"
+	if fs.Lookup(name) != nil {
+		return
+	}
+	fs.Var(&optsFlag{fs: fs, prefix: prefix}, name, fmt.Sprintf(
+		"Log options as comma-separated key=value pairs of the other %q flags without their prefix (e.g. \"level=3,format=console\").",
+		prefix+"*",
+	))
+}
+
+type optsFlag struct {
+	fs     *flag.FlagSet
+	prefix string
+	value  string
+}
+
+func (f *optsFlag) Get() interface{} { return f.value }
+func (f *optsFlag) String() string   { return f.value }
+
+func (f *optsFlag) Set(s string) error {
+	type pair struct{ key, value string }
+	var pairs []pair
+	for _, part := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(part, "=")
+		key = strings.TrimSpace(key)
+		if fl := f.lookup(key); fl != nil {
+			if !ok {
+				if !isBoolFlag(fl) {
+					return fmt.Errorf("zapr: log option has no value: %q", key)
+				}
+				value = "true"
+			}
+			pairs = append(pairs, pair{key, value})
+			continue
+		}
+		if len(pairs) == 0 || !acceptsCommas(f.lookup(pairs[len(pairs)-1].key)) {
+			return f.unknown(key)
+		}
+		pairs[len(pairs)-1].value += "," + part
+	}
+	for _, p := range pairs {
+		if err := f.fs.Set(f.prefix+p.key, p.value); err != nil {
+			return fmt.Errorf("zapr: invalid log option: %q: %w", p.key, err)
+		}
+	}
+	f.value = s
+	return nil
+}
+
+// lookup returns the flag with the key, excluding this flag.
+func (f *optsFlag) lookup(key string) *flag.Flag {
+	if key == "" || key == "opts" {
+		return nil
+	}
+	return f.fs.Lookup(f.prefix + key)
+}
+
+// acceptsCommas returns whether the value of the flag may contain commas,
+// such that text following a comma continues its value. Flags whose values
+// are lists or may otherwise contain commas (e.g. time layouts) implement
+// AcceptsCommas.
+func acceptsCommas(f *flag.Flag) bool {
+	if f == nil {
+		return false
+	}
+	v, ok := f.Value.(interface{ AcceptsCommas() bool })
+	return ok && v.AcceptsCommas()
+}
+
+// unknown returns an error for an unknown key, suggesting the closest keys.
+func (f *optsFlag) unknown(key string) error {
+	var keys, close []string
+	f.fs.VisitAll(func(fl *flag.Flag) {
+		k, ok := strings.CutPrefix(fl.Name, f.prefix)
+		if !ok || k == "opts" {
+			return
+		}
+		keys = append(keys, k)
+		if editDistance(key, k) <= 2 {
+			close = append(close, k)
+		}
+	})
+	if len(close) > 0 {
+		return fmt.Errorf("zapr: unknown log option: %q (did you mean %s?)", key, listNames(close))
+	}
+	return fmt.Errorf("zapr: unknown log option: %q (known options: %s)", key, strings.Join(keys, ", "))
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	v, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && v.IsBoolFlag()
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for k := range prev {
+		prev[k] = k
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for k := 1; k <= len(b); k++ {
+			cost := 1
+			if a[i-1] == b[k-1] {
+				cost = 0
+			}
+			cur[k] = prev[k-1] + cost
+			if d := prev[k] + 1; d < cur[k] {
+				cur[k] = d
+			}
+			if d := cur[k-1] + 1; d < cur[k] {
+				cur[k] = d
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
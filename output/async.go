@@ -0,0 +1,185 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// Async returns an Output that writes to out in a background goroutine, such
+// that writes never block on out. Up to queue writes are buffered. If the
+// queue is full, writes are dropped and, if the Output is observed, reported
+// as EventDropped for the "async" output. Write errors are reported as
+// EventError and returned by Sync. Closing the returned Output writes any
+// queued data and closes out, after which writes fail. It implements Reopener by reopening out,
+// if out implements it.
+func Async(out Output, queue int) Output {
+	if queue <= 0 {
+		queue = 1024
+	}
+	o := &asyncOutput{
+		out:   out,
+		queue: make(chan asyncItem, queue),
+		done:  make(chan struct{}),
+	}
+	o.wg.Add(1)
+	go o.run()
+	return o
+}
+
+type asyncItem struct {
+	data   []byte
+	synced chan struct{} // if non-nil, the item is a sync marker
+}
+
+type asyncOutput struct {
+	out   Output
+	queue chan asyncItem
+	done  chan struct{} // closed by Close
+	wg    sync.WaitGroup
+	watch atomic.Pointer[pressureWatch]
+
+	// sendMu is held for reading to enqueue writes and for writing to close
+	// done, so that no write is enqueued after the queue is drained.
+	sendMu sync.RWMutex
+
+	mu       sync.Mutex
+	closed   bool
+	err      error
+	observer Observer
+}
+
+func (o *asyncOutput) Observe(observer Observer) {
+	o.mu.Lock()
+	o.observer = observer
+	o.mu.Unlock()
+	if v, ok := o.out.(Observable); ok {
+		v.Observe(observer)
+	}
+}
+
+func (o *asyncOutput) observe(event string) {
+	o.mu.Lock()
+	observer := o.observer
+	o.mu.Unlock()
+	if observer != nil {
+		observer.ObserveOutputEvent("async", event)
+	}
+}
+
+func (o *asyncOutput) Pressure() float64 {
+	return float64(len(o.queue)) / float64(cap(o.queue))
+}
+
+func (o *asyncOutput) OnPressure(threshold float64, fn func(pressure float64)) {
+	o.watch.Store(&pressureWatch{threshold: threshold, fn: fn})
+}
+
+func (o *asyncOutput) Reopen() error { return reopen(o.out) }
+
+func (o *asyncOutput) Write(b []byte) (int, error) {
+	// The caller may reuse b after Write returns.
+	item := asyncItem{data: append([]byte(nil), b...)}
+	o.sendMu.RLock()
+	select {
+	case <-o.done:
+		o.sendMu.RUnlock()
+		return 0, io.ErrClosedPipe
+	default:
+	}
+	select {
+	case o.queue <- item:
+		o.sendMu.RUnlock()
+		o.watch.Load().check(o.Pressure())
+	default:
+		o.sendMu.RUnlock()
+		o.observe(EventDropped)
+	}
+	return len(b), nil
+}
+
+func (o *asyncOutput) Sync() error {
+	marker := asyncItem{synced: make(chan struct{})}
+	select {
+	case o.queue <- marker:
+	case <-o.done:
+		return nil
+	}
+	select {
+	case <-marker.synced:
+	case <-o.done:
+		return nil
+	}
+	err := o.out.Sync()
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err == nil {
+		err = o.err
+	}
+	o.err = nil
+	return err
+}
+
+func (o *asyncOutput) Close() error {
+	o.mu.Lock()
+	if o.closed {
+		o.mu.Unlock()
+		return nil
+	}
+	o.closed = true
+	o.sendMu.Lock()
+	close(o.done)
+	o.sendMu.Unlock()
+	o.mu.Unlock()
+
+	o.wg.Wait()
+	err := o.out.Sync()
+	if cerr := o.out.Close(); err == nil {
+		err = cerr
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err == nil {
+		err = o.err
+	}
+	return err
+}
+
+func (o *asyncOutput) run() {
+	defer o.wg.Done()
+	for {
+		select {
+		case item := <-o.queue:
+			o.handle(item)
+		case <-o.done:
+			for {
+				select {
+				case item := <-o.queue:
+					o.handle(item)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (o *asyncOutput) handle(item asyncItem) {
+	o.watch.Load().check(o.Pressure())
+	if item.synced != nil {
+		close(item.synced)
+		return
+	}
+	if _, err := o.out.Write(item.data); err != nil {
+		o.observe(EventError)
+		o.mu.Lock()
+		o.err = err
+		o.mu.Unlock()
+	}
+}
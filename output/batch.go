@@ -0,0 +1,409 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// An Observer observes output events, such as failed and retried requests.
+//
+// If a zapr.Observer also implements Observer, it's attached to
+// Observable outputs when a logger is created.
+type Observer interface {
+	// ObserveOutputEvent observes an event of the named output.
+	ObserveOutputEvent(output, event string)
+}
+
+// An Observable is an Output that reports events to an Observer.
+type Observable interface {
+	Output
+
+	// Observe sets the Observer.
+	Observe(Observer)
+}
+
+// A Pressurer is an Output that buffers data in a bounded queue and reports
+// how full it is, such that applications may shed their own load (e.g. by
+// reducing verbosity) before data is dropped.
+type Pressurer interface {
+	Output
+
+	// Pressure returns the fraction of the queue's capacity in use,
+	// from 0 to 1.
+	Pressure() float64
+
+	// OnPressure sets a function which is called asynchronously with the
+	// current pressure whenever it rises to the threshold or falls below it.
+	OnPressure(threshold float64, fn func(pressure float64))
+}
+
+// Pressure returns the pressure of the Output, if it's a Pressurer.
+// Otherwise, it returns zero.
+func Pressure(out Output) float64 {
+	if p, ok := out.(Pressurer); ok {
+		return p.Pressure()
+	}
+	return 0
+}
+
+// pressureWatch calls a function when pressure crosses a threshold.
+type pressureWatch struct {
+	threshold float64
+	fn        func(pressure float64)
+	high      atomic.Bool
+}
+
+func (w *pressureWatch) check(pressure float64) {
+	if w == nil {
+		return
+	}
+	high := pressure >= w.threshold
+	if w.high.CompareAndSwap(!high, high) {
+		go w.fn(pressure)
+	}
+}
+
+// Events reported by batch outputs.
+const (
+	EventError   = "error"   // a batch failed to send
+	EventRetry   = "retry"   // a batch is being retried
+	EventDropped = "dropped" // a batch was discarded
+)
+
+// BatchConfig is the configuration of a batch output.
+type BatchConfig struct {
+	// Name identifies the output to an Observer.
+	Name string
+
+	// Size is the maximum number of entries in a batch. The default is 100.
+	Size int
+
+	// Interval is the maximum duration an entry is buffered before its batch
+	// is sent. The default is 1s.
+	Interval time.Duration
+
+	// Queue is the maximum number of batches waiting to be sent. If the queue
+	// is full, new batches are dropped. The default is 8.
+	Queue int
+
+	// Retries is the maximum number of times a failed batch is retried.
+	// If it's negative, batches aren't retried. The default is 5.
+	Retries int
+
+	// Backoff is the delay before the first retry, which doubles with each
+	// retry up to MaxBackoff. The defaults are 100ms and 10s.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// CloseTimeout is the maximum duration Close waits for pending batches
+	// to be sent, after which the context of Send is canceled. The default
+	// is 10s.
+	CloseTimeout time.Duration
+
+	// Append appends the encoded entry to the batch.
+	// By default, the entry is appended as-is.
+	Append func(batch, entry []byte) []byte
+
+	// Send sends the batch. If it returns an error wrapped by Permanent,
+	// the batch isn't retried.
+	Send func(ctx context.Context, batch []byte) error
+}
+
+// Permanent wraps an error to indicate that a batch shouldn't be retried.
+func Permanent(err error) error {
+	return &permanentError{err}
+}
+
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// NewBatchOutput returns an Output which collects entries into batches and
+// sends them in the background. Failed batches are retried with exponential
+// backoff. Sync sends the current batch and waits for all pending batches,
+// returning the last error, if any. Close sends any pending batches without
+// retries, canceling them if they take longer than the close timeout.
+func NewBatchOutput(cfg BatchConfig) Output {
+	if cfg.Size <= 0 {
+		cfg.Size = 100
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.Queue <= 0 {
+		cfg.Queue = 8
+	}
+	if cfg.Retries < 0 {
+		cfg.Retries = 0
+	} else if cfg.Retries == 0 {
+		cfg.Retries = 5
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = 100 * time.Millisecond
+	}
+	if cfg.MaxBackoff < cfg.Backoff {
+		cfg.MaxBackoff = 10 * time.Second
+	}
+	if cfg.CloseTimeout <= 0 {
+		cfg.CloseTimeout = 10 * time.Second
+	}
+	if cfg.Append == nil {
+		cfg.Append = func(batch, entry []byte) []byte { return append(batch, entry...) }
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	o := &batchOutput{
+		cfg:    cfg,
+		ctx:    ctx,
+		cancel: cancel,
+		queue:  make(chan *batch, cfg.Queue),
+		done:   make(chan struct{}),
+	}
+	o.wg.Add(1)
+	go o.run()
+	return o
+}
+
+type batch struct {
+	data   []byte
+	synced chan struct{} // if non-nil, the batch is a sync marker
+}
+
+type batchOutput struct {
+	cfg    BatchConfig
+	ctx    context.Context
+	cancel context.CancelFunc
+	queue  chan *batch
+	done   chan struct{} // closed by Close
+	wg     sync.WaitGroup
+
+	mu       sync.Mutex
+	buf      []byte
+	n        int
+	timer    *time.Timer
+	closed   bool
+	err      error
+	observer Observer
+
+	watch atomic.Pointer[pressureWatch]
+}
+
+func (o *batchOutput) Pressure() float64 {
+	return float64(len(o.queue)) / float64(cap(o.queue))
+}
+
+func (o *batchOutput) OnPressure(threshold float64, fn func(pressure float64)) {
+	o.watch.Store(&pressureWatch{threshold: threshold, fn: fn})
+}
+
+func (o *batchOutput) Observe(observer Observer) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.observer = observer
+}
+
+func (o *batchOutput) observe(event string) {
+	o.mu.Lock()
+	observer := o.observer
+	o.mu.Unlock()
+	if observer != nil {
+		observer.ObserveOutputEvent(o.cfg.Name, event)
+	}
+}
+
+func (o *batchOutput) Write(b []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if o.n == 0 {
+		if o.timer == nil {
+			o.timer = time.AfterFunc(o.cfg.Interval, o.flushTimer)
+		} else {
+			o.timer.Reset(o.cfg.Interval)
+		}
+	}
+	o.buf = o.cfg.Append(o.buf, b)
+	if o.n++; o.n >= o.cfg.Size {
+		o.flush()
+	}
+	return len(b), nil
+}
+
+func (o *batchOutput) flushTimer() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if !o.closed {
+		o.flush()
+	}
+}
+
+// flush enqueues the current batch, dropping it if the queue is full.
+// It must be called with the lock held.
+func (o *batchOutput) flush() {
+	if o.n == 0 {
+		return
+	}
+	if o.timer != nil {
+		o.timer.Stop()
+	}
+	b := &batch{data: o.buf}
+	o.buf, o.n = nil, 0
+	select {
+	case o.queue <- b:
+		o.watch.Load().check(o.Pressure())
+	default:
+		if o.observer != nil {
+			o.observer.ObserveOutputEvent(o.cfg.Name, EventDropped)
+		}
+	}
+}
+
+func (o *batchOutput) Sync() error {
+	o.mu.Lock()
+	if o.closed {
+		o.mu.Unlock()
+		return nil
+	}
+	if o.timer != nil {
+		o.timer.Stop()
+	}
+	b := &batch{data: o.buf}
+	o.buf, o.n = nil, 0
+	marker := &batch{synced: make(chan struct{})}
+	o.mu.Unlock()
+
+	for _, b := range []*batch{b, marker} {
+		if b.synced == nil && len(b.data) == 0 {
+			continue
+		}
+		select {
+		case o.queue <- b:
+		case <-o.done:
+			return nil
+		}
+	}
+	select {
+	case <-marker.synced:
+	case <-o.done:
+		return nil
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	err := o.err
+	o.err = nil
+	return err
+}
+
+func (o *batchOutput) Close() error {
+	o.mu.Lock()
+	if o.closed {
+		o.mu.Unlock()
+		return nil
+	}
+	o.flush()
+	o.closed = true
+	close(o.done)
+	o.mu.Unlock()
+
+	sent := make(chan struct{})
+	go func() {
+		o.wg.Wait()
+		close(sent)
+	}()
+	t := time.NewTimer(o.cfg.CloseTimeout)
+	select {
+	case <-sent:
+		t.Stop()
+	case <-t.C:
+		o.cancel()
+		<-sent
+	}
+	o.cancel()
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.err
+}
+
+func (o *batchOutput) run() {
+	defer o.wg.Done()
+	for {
+		select {
+		case b := <-o.queue:
+			o.handle(b)
+		case <-o.done:
+			for {
+				select {
+				case b := <-o.queue:
+					o.handle(b)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (o *batchOutput) handle(b *batch) {
+	o.watch.Load().check(o.Pressure())
+	if b.synced != nil {
+		close(b.synced)
+		return
+	}
+	o.send(b.data)
+}
+
+func (o *batchOutput) send(data []byte) {
+	backoff := o.cfg.Backoff
+	for retry := 0; ; retry++ {
+		err := o.cfg.Send(o.ctx, data)
+		if err == nil {
+			return
+		}
+		o.observe(EventError)
+		var perm *permanentError
+		if retry == o.cfg.Retries || errors.As(err, &perm) || o.isClosed() {
+			o.fail(err)
+			return
+		}
+		o.observe(EventRetry)
+		t := time.NewTimer(backoff)
+		select {
+		case <-t.C:
+		case <-o.done:
+			t.Stop()
+			o.fail(err)
+			return
+		}
+		if backoff *= 2; backoff > o.cfg.MaxBackoff {
+			backoff = o.cfg.MaxBackoff
+		}
+	}
+}
+
+func (o *batchOutput) fail(err error) {
+	o.observe(EventDropped)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.err = err
+}
+
+func (o *batchOutput) isClosed() bool {
+	select {
+	case <-o.done:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,198 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// A Compressor provides a named streaming compression format.
+type Compressor interface {
+	NewWriter(w io.Writer) (CompressWriter, error)
+	Name() string
+}
+
+// A CompressWriter is a streaming compression writer.
+type CompressWriter interface {
+	io.WriteCloser
+
+	// Flush writes any buffered data to the underlying io.Writer such that
+	// everything written so far can be decompressed by a reader.
+	Flush() error
+}
+
+var compressors = make(map[string]Compressor)
+
+// RegisterCompressor registers the Compressor for use as an output URL parameter.
+func RegisterCompressor(c Compressor) error {
+	name := c.Name()
+	if _, ok := compressors[name]; ok {
+		return fmt.Errorf("zapr: already registered Compressor: %q", name)
+	}
+	compressors[name] = c
+	return nil
+}
+
+// Compressors returns the registered Compressors.
+func Compressors() []Compressor {
+	s := make([]Compressor, 0, len(compressors))
+	for _, c := range compressors {
+		s = append(s, c)
+	}
+	sort.Slice(s, func(i, k int) bool { return s[i].Name() < s[k].Name() })
+	return s
+}
+
+// A CompressLevel is a compression level, which trades speed for size.
+type CompressLevel string
+
+// CompressLevels supported by LeveledCompressors.
+const (
+	CompressFastest CompressLevel = "fastest"
+	CompressDefault CompressLevel = "default"
+	CompressBetter  CompressLevel = "better"
+	CompressBest    CompressLevel = "best"
+)
+
+// A LeveledCompressor is a Compressor that supports compression levels.
+type LeveledCompressor interface {
+	Compressor
+
+	// WithLevel returns a Compressor with the same name and the given level.
+	WithLevel(level CompressLevel) (Compressor, error)
+}
+
+type gzipCompressorLevel struct{ level int }
+
+func (c *gzipCompressorLevel) Name() string { return "gzip" }
+
+func (c *gzipCompressorLevel) NewWriter(w io.Writer) (CompressWriter, error) {
+	return gzip.NewWriterLevel(w, c.level)
+}
+
+func (c *gzipCompressorLevel) WithLevel(level CompressLevel) (Compressor, error) {
+	switch level {
+	case CompressFastest:
+		return &gzipCompressorLevel{level: gzip.BestSpeed}, nil
+	case CompressDefault:
+		return &gzipCompressorLevel{level: gzip.DefaultCompression}, nil
+	case CompressBetter:
+		return &gzipCompressorLevel{level: 7}, nil
+	case CompressBest:
+		return &gzipCompressorLevel{level: gzip.BestCompression}, nil
+	}
+	return nil, fmt.Errorf("zapr: unknown compression level: %q", level)
+}
+
+var gzipCompressor = Compressor(&gzipCompressorLevel{level: gzip.DefaultCompression})
+
+func init() {
+	must(RegisterCompressor(gzipCompressor))
+}
+
+// GzipCompressor compresses output in the gzip format.
+func GzipCompressor() Compressor { return gzipCompressor }
+
+// Compress returns an Output that compresses writes to out. Compressed data
+// is flushed to out when Sync is called and, if interval is positive, no
+// later than interval after it's written. Closing the returned Output closes
+// the compression stream and out.
+func Compress(out Output, c Compressor, interval time.Duration) (Output, error) {
+	zw, err := c.NewWriter(out)
+	if err != nil {
+		return nil, fmt.Errorf("zapr: failed to create %s writer: %w", c.Name(), err)
+	}
+	return &compressOutput{
+		out:      out,
+		zw:       zw,
+		interval: interval,
+	}, nil
+}
+
+type compressOutput struct {
+	out      Output
+	interval time.Duration
+
+	mu     sync.Mutex
+	zw     CompressWriter
+	timer  *time.Timer
+	dirty  bool
+	closed bool
+}
+
+func (o *compressOutput) Write(b []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return 0, io.ErrClosedPipe
+	}
+	n, err := o.zw.Write(b)
+	if n > 0 && !o.dirty {
+		o.dirty = true
+		if o.interval > 0 {
+			if o.timer == nil {
+				o.timer = time.AfterFunc(o.interval, o.flushTimer)
+			} else {
+				o.timer.Reset(o.interval)
+			}
+		}
+	}
+	return n, err
+}
+
+func (o *compressOutput) flushTimer() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if !o.closed {
+		o.flush() // Writer errors are sticky and resurface later.
+	}
+}
+
+func (o *compressOutput) flush() error {
+	if !o.dirty {
+		return nil
+	}
+	o.dirty = false
+	return o.zw.Flush()
+}
+
+func (o *compressOutput) Sync() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return nil
+	}
+	if err := o.flush(); err != nil {
+		return err
+	}
+	return o.out.Sync()
+}
+
+func (o *compressOutput) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return nil
+	}
+	o.closed = true
+	if o.timer != nil {
+		o.timer.Stop()
+	}
+	err := o.zw.Close()
+	if serr := o.out.Sync(); err == nil {
+		err = serr
+	}
+	if cerr := o.out.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,345 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// The encrypted format is a sequence of streams. Each stream is a header
+// followed by a sequence of chunks. The header is the magic string followed
+// by a random salt that's used to derive the stream's key. Each chunk is
+// a big-endian uint32 length followed by that many bytes of AES-GCM sealed
+// data, whose nonce is the chunk's index within the stream.
+//
+// Streams encrypted to a recipient have a different magic string, which is
+// followed by an ephemeral X25519 public key in place of the salt. The
+// stream's key is derived from the shared secret of the ephemeral key and
+// the recipient's key.
+const (
+	encMagic          = "ZAPRENC1"
+	encRecipientMagic = "ZAPRENC2"
+	encSaltSize       = 32
+	encChunkSize      = 64 << 10
+	encMaxSealed      = encChunkSize + 16
+)
+
+// Encrypt returns an Output that encrypts writes to out with AES-GCM using
+// the given 16, 24, or 32 byte key. Data is encrypted in chunks so that all
+// complete chunks of a truncated file can be decrypted. A chunk is written to
+// out when Sync is called, when it's full, and, if interval is positive,
+// no later than interval after its first write. Closing the returned Output
+// writes any remaining data and closes out.
+//
+// Use Decrypt to read the encrypted data.
+func Encrypt(out Output, key []byte, interval time.Duration) (Output, error) {
+	salt := make([]byte, encSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("zapr: failed to generate salt: %w", err)
+	}
+	aead, err := newStreamAEAD(key, salt)
+	if err != nil {
+		return nil, err
+	}
+	return newEncryptOutput(out, aead, append([]byte(encMagic), salt...), interval), nil
+}
+
+// EncryptToRecipient returns an Output like Encrypt, except that it encrypts
+// writes to out such that they may only be decrypted with the identity of the
+// given recipient, which is a 32 byte X25519 public key. Unlike a shared key,
+// the recipient can't be used to decrypt the output if it's compromised.
+//
+// Use DecryptWithIdentity to read the encrypted data and GenerateIdentity
+// to generate a recipient and its identity.
+func EncryptToRecipient(out Output, recipient []byte, interval time.Duration) (Output, error) {
+	pub, err := ecdh.X25519().NewPublicKey(recipient)
+	if err != nil {
+		return nil, fmt.Errorf("zapr: invalid recipient: %w", err)
+	}
+	eph, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("zapr: failed to generate ephemeral key: %w", err)
+	}
+	aead, err := recipientAEAD(eph, pub, eph.PublicKey(), pub)
+	if err != nil {
+		return nil, err
+	}
+	return newEncryptOutput(out, aead, append([]byte(encRecipientMagic), eph.PublicKey().Bytes()...), interval), nil
+}
+
+// GenerateIdentity returns a new X25519 identity and its recipient,
+// for use with EncryptToRecipient and DecryptWithIdentity.
+func GenerateIdentity() (identity, recipient []byte, err error) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("zapr: failed to generate identity: %w", err)
+	}
+	return key.Bytes(), key.PublicKey().Bytes(), nil
+}
+
+// recipientAEAD returns the AEAD of a stream with the shared secret of the
+// private and public keys, salted with the ephemeral and recipient keys.
+func recipientAEAD(priv *ecdh.PrivateKey, pub, eph, recipient *ecdh.PublicKey) (cipher.AEAD, error) {
+	secret, err := priv.ECDH(pub)
+	if err != nil {
+		return nil, fmt.Errorf("zapr: failed to derive shared secret: %w", err)
+	}
+	return newStreamAEAD(secret, append(eph.Bytes(), recipient.Bytes()...))
+}
+
+func newEncryptOutput(out Output, aead cipher.AEAD, header []byte, interval time.Duration) *encryptOutput {
+	return &encryptOutput{
+		out:      out,
+		aead:     aead,
+		interval: interval,
+		header:   header,
+		buf:      make([]byte, 0, encChunkSize),
+	}
+}
+
+// ReadKeyFile reads a hex-encoded key from the named file.
+func ReadKeyFile(name string) ([]byte, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("zapr: failed to read key file: %w", err)
+	}
+	key, err := hex.DecodeString(string(bytes.TrimSpace(b)))
+	if err != nil {
+		return nil, fmt.Errorf("zapr: invalid key file: %w", err)
+	}
+	return key, nil
+}
+
+func newStreamAEAD(key, salt []byte) (cipher.AEAD, error) {
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, fmt.Errorf("zapr: invalid key size: %d", len(key))
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(salt)
+	block, err := aes.NewCipher(mac.Sum(nil)[:len(key)])
+	if err != nil {
+		return nil, fmt.Errorf("zapr: failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+func chunkNonce(aead cipher.AEAD, index uint64) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], index)
+	return nonce
+}
+
+type encryptOutput struct {
+	out      Output
+	aead     cipher.AEAD
+	interval time.Duration
+
+	mu     sync.Mutex
+	header []byte // unwritten header
+	buf    []byte
+	index  uint64
+	sealed []byte
+	timer  *time.Timer
+	err    error
+	closed bool
+}
+
+func (o *encryptOutput) Write(b []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return 0, io.ErrClosedPipe
+	}
+	n := 0
+	for len(b) > 0 {
+		if o.err != nil {
+			return n, o.err
+		}
+		if len(o.buf) == 0 && o.interval > 0 {
+			if o.timer == nil {
+				o.timer = time.AfterFunc(o.interval, o.flushTimer)
+			} else {
+				o.timer.Reset(o.interval)
+			}
+		}
+		k := copy(o.buf[len(o.buf):cap(o.buf)], b)
+		o.buf = o.buf[:len(o.buf)+k]
+		b = b[k:]
+		n += k
+		if len(o.buf) == cap(o.buf) {
+			o.flush()
+		}
+	}
+	return n, nil
+}
+
+func (o *encryptOutput) flushTimer() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if !o.closed {
+		o.flush() // Errors are sticky and resurface later.
+	}
+}
+
+func (o *encryptOutput) flush() error {
+	if o.err != nil || len(o.buf) == 0 {
+		return o.err
+	}
+	if o.header != nil {
+		if _, o.err = o.out.Write(o.header); o.err != nil {
+			return o.err
+		}
+		o.header = nil
+	}
+	sealed := append(o.sealed[:0], 0, 0, 0, 0)
+	sealed = o.aead.Seal(sealed, chunkNonce(o.aead, o.index), o.buf, nil)
+	binary.BigEndian.PutUint32(sealed, uint32(len(sealed)-4))
+	o.sealed = sealed
+	o.index++
+	o.buf = o.buf[:0]
+	_, o.err = o.out.Write(sealed)
+	return o.err
+}
+
+func (o *encryptOutput) Sync() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return nil
+	}
+	if err := o.flush(); err != nil {
+		return err
+	}
+	return o.out.Sync()
+}
+
+func (o *encryptOutput) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return nil
+	}
+	o.closed = true
+	if o.timer != nil {
+		o.timer.Stop()
+	}
+	err := o.flush()
+	if serr := o.out.Sync(); err == nil {
+		err = serr
+	}
+	if cerr := o.out.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// ErrTruncated indicates that encrypted data ended with an incomplete chunk.
+var ErrTruncated = errors.New("zapr: truncated encrypted data")
+
+// Decrypt writes to w the decrypted data read from r, which was written by
+// an Output returned by Encrypt using the same key. If r ends with an
+// incomplete chunk, all preceding data is written and ErrTruncated is returned.
+func Decrypt(w io.Writer, r io.Reader, key []byte) error {
+	return decrypt(w, r, encMagic, func(salt []byte) (cipher.AEAD, error) {
+		return newStreamAEAD(key, salt)
+	})
+}
+
+// DecryptWithIdentity writes to w the decrypted data read from r, which was
+// written by an Output returned by EncryptToRecipient using the recipient of
+// the given identity. If r ends with an incomplete chunk, all preceding data
+// is written and ErrTruncated is returned.
+func DecryptWithIdentity(w io.Writer, r io.Reader, identity []byte) error {
+	priv, err := ecdh.X25519().NewPrivateKey(identity)
+	if err != nil {
+		return fmt.Errorf("zapr: invalid identity: %w", err)
+	}
+	return decrypt(w, r, encRecipientMagic, func(b []byte) (cipher.AEAD, error) {
+		eph, err := ecdh.X25519().NewPublicKey(b)
+		if err != nil {
+			return nil, fmt.Errorf("zapr: invalid ephemeral key: %w", err)
+		}
+		return recipientAEAD(priv, eph, eph, priv.PublicKey())
+	})
+}
+
+// decrypt writes to w the decrypted data read from r, whose streams begin
+// with the magic string followed by the salt from which their AEADs are derived.
+func decrypt(w io.Writer, r io.Reader, magic string, newAEAD func(salt []byte) (cipher.AEAD, error)) error {
+	br := bufio.NewReader(r)
+	hdr := make([]byte, len(magic)+encSaltSize)
+	var (
+		aead  cipher.AEAD
+		index uint64
+		plain []byte
+		buf   = make([]byte, encMaxSealed)
+	)
+	for {
+		if aead == nil || bytes.HasPrefix(peek(br, len(magic)), []byte(magic)) {
+			// Start of a new stream.
+			if _, err := io.ReadFull(br, hdr); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return ErrTruncated
+			}
+			if string(hdr[:len(magic)]) != magic {
+				return errors.New("zapr: invalid encrypted data header")
+			}
+			var err error
+			if aead, err = newAEAD(hdr[len(magic):]); err != nil {
+				return err
+			}
+			index = 0
+		}
+		var size [4]byte
+		if _, err := io.ReadFull(br, size[:]); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return ErrTruncated
+		}
+		n := binary.BigEndian.Uint32(size[:])
+		if n > encMaxSealed {
+			return fmt.Errorf("zapr: invalid encrypted chunk size: %d", n)
+		}
+		if _, err := io.ReadFull(br, buf[:n]); err != nil {
+			return ErrTruncated
+		}
+		var err error
+		plain, err = aead.Open(plain[:0], chunkNonce(aead, index), buf[:n], nil)
+		if err != nil {
+			return fmt.Errorf("zapr: failed to decrypt chunk: %w", err)
+		}
+		index++
+		if _, err := w.Write(plain); err != nil {
+			return err
+		}
+	}
+}
+
+func peek(br *bufio.Reader, n int) []byte {
+	b, _ := br.Peek(n)
+	return b
+}
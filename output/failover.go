@@ -0,0 +1,187 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Events reported by failover outputs.
+const (
+	EventFailover = "failover" // writes failed over to the secondary output
+	EventRecover  = "recover"  // writes recovered to the primary output
+)
+
+// FailoverConfig is the configuration of a failover output.
+type FailoverConfig struct {
+	// Name identifies the output to an Observer. The default is "failover".
+	Name string
+
+	// Primary is the output to which data is written while it's healthy.
+	Primary Output
+
+	// Secondary is the output to which data is written while the primary
+	// is failing (e.g. Stderr()).
+	Secondary Output
+
+	// Timeout is the maximum duration of a write to the primary, after which
+	// it's considered to have failed. If it's zero, there's no timeout.
+	// A timed-out write is abandoned rather than canceled, so its data is
+	// written to the secondary and may also reach the primary if the write
+	// eventually succeeds. No other write is attempted with the primary
+	// until it returns.
+	Timeout time.Duration
+
+	// Probe is the minimum duration between attempts to write to the primary
+	// while it's failing. The default is 10s.
+	Probe time.Duration
+}
+
+var errTimeout = errors.New("zapr: write timed out")
+
+// NewFailover returns an Output that writes to the primary output and fails
+// over to the secondary output when a write to the primary returns an error or
+// times out. While failed over, a write is periodically attempted with the
+// primary and, if it succeeds, writes recover to the primary. If the Output
+// is observed, it reports EventFailover and EventRecover. Closing it closes
+// both outputs. It implements Reopener by reopening the outputs which
+// implement it.
+func NewFailover(cfg FailoverConfig) Output {
+	if cfg.Name == "" {
+		cfg.Name = "failover"
+	}
+	if cfg.Probe <= 0 {
+		cfg.Probe = 10 * time.Second
+	}
+	return &failoverOutput{cfg: cfg, now: time.Now}
+}
+
+type failoverOutput struct {
+	cfg FailoverConfig
+	now func() time.Time
+
+	mu       sync.Mutex
+	failed   bool
+	probe    time.Time     // next probe of the primary
+	pending  chan struct{} // closed when a timed-out write to the primary returns
+	observer Observer
+}
+
+func (o *failoverOutput) Observe(observer Observer) {
+	o.mu.Lock()
+	o.observer = observer
+	o.mu.Unlock()
+	for _, out := range []Output{o.cfg.Primary, o.cfg.Secondary} {
+		if v, ok := out.(Observable); ok {
+			v.Observe(observer)
+		}
+	}
+}
+
+func (o *failoverOutput) Reopen() error {
+	return errors.Join(reopen(o.cfg.Primary), reopen(o.cfg.Secondary))
+}
+
+func (o *failoverOutput) Write(b []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.failed && (o.busy() || o.now().Before(o.probe)) {
+		return o.cfg.Secondary.Write(b)
+	}
+	n, err := o.writePrimary(b)
+	if err == nil {
+		if o.failed {
+			o.failed = false
+			o.observe(EventRecover)
+		}
+		return n, nil
+	}
+	o.probe = o.now().Add(o.cfg.Probe)
+	if !o.failed {
+		o.failed = true
+		o.observe(EventFailover)
+	}
+	// Only the remainder of a short write is written to the secondary.
+	m, err := o.cfg.Secondary.Write(b[n:])
+	return n + m, err
+}
+
+// busy returns whether a timed-out write to the primary hasn't returned.
+// It must be called with the lock held.
+func (o *failoverOutput) busy() bool {
+	if o.pending == nil {
+		return false
+	}
+	select {
+	case <-o.pending:
+		o.pending = nil
+		return false
+	default:
+		return true
+	}
+}
+
+// writePrimary writes to the primary, subject to the timeout.
+// It must be called with the lock held.
+func (o *failoverOutput) writePrimary(b []byte) (int, error) {
+	if o.cfg.Timeout <= 0 {
+		return o.cfg.Primary.Write(b)
+	}
+	type result struct {
+		n   int
+		err error
+	}
+	ch := make(chan result, 1)
+	done := make(chan struct{})
+	data := append([]byte(nil), b...) // the write may outlive the call
+	go func() {
+		defer close(done)
+		n, err := o.cfg.Primary.Write(data)
+		ch <- result{n, err}
+	}()
+	t := time.NewTimer(o.cfg.Timeout)
+	defer t.Stop()
+	select {
+	case r := <-ch:
+		return r.n, r.err
+	case <-t.C:
+		o.pending = done
+		return 0, errTimeout
+	}
+}
+
+// observe reports the event. It must be called with the lock held.
+func (o *failoverOutput) observe(event string) {
+	if o.observer != nil {
+		o.observer.ObserveOutputEvent(o.cfg.Name, event)
+	}
+}
+
+func (o *failoverOutput) Sync() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.failed {
+		return o.cfg.Secondary.Sync()
+	}
+	return o.cfg.Primary.Sync()
+}
+
+func (o *failoverOutput) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.pending != nil {
+		<-o.pending // don't close the primary during a write
+		o.pending = nil
+	}
+	err := o.cfg.Primary.Close()
+	if serr := o.cfg.Secondary.Close(); err == nil {
+		err = serr
+	}
+	return err
+}
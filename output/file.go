@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// A Reopener is an Output that may reopen its underlying resources, such as
+// a file which has been moved by an external rotation tool like logrotate.
+type Reopener interface {
+	Output
+
+	// Reopen reopens the Output.
+	Reopen() error
+}
+
+// reopen reopens the Output, if it implements Reopener.
+func reopen(out Output) error {
+	if v, ok := out.(Reopener); ok {
+		return v.Reopen()
+	}
+	return nil
+}
+
+// OpenFile returns an Output that appends to the named file,
+// creating it if it doesn't exist. It implements Reopener.
+func OpenFile(name string) (Output, error) {
+	f, err := openFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return &fileOutput{f: f, name: name}, nil
+}
+
+func openFile(name string) (*os.File, error) {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("zapr: failed to open output file: %w", err)
+	}
+	return f, nil
+}
+
+// OpenSharedFile returns an Output that appends to the named file, creating
+// it if it doesn't exist, and which may be shared by multiple processes.
+// Each write holds an exclusive advisory lock on the file, so that entries
+// written by cooperating processes are never interleaved.
+//
+// File locking is only supported on unix platforms.
+func OpenSharedFile(name string) (Output, error) {
+	f, err := openFile(name)
+	if err != nil {
+		return nil, err
+	}
+	// Check support up front, rather than failing every write.
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("zapr: failed to lock output file: %w", err)
+	}
+	unlockFile(f)
+	return &fileOutput{f: f, name: name, lock: true}, nil
+}
+
+func openFileURL(u *url.URL) (Output, error) {
+	name := u.Path
+	if u.Opaque != "" {
+		name = u.Opaque // relative path (e.g. "file:app.log")
+	}
+	if name == "" {
+		return nil, fmt.Errorf("zapr: missing output file path: %q", u)
+	}
+	q := u.Query()
+	if shared, _ := strconv.ParseBool(q.Get("lock")); shared {
+		// Compression and encryption are stateful streams that
+		// can't be interleaved with the writes of other processes.
+		if q.Get("compress") != "" || q.Get("key-file") != "" || q.Get("recipient-file") != "" {
+			return nil, fmt.Errorf("zapr: locked output file can't be compressed or encrypted: %q", u)
+		}
+		if strings.Contains(name, "%") {
+			return nil, fmt.Errorf("zapr: locked output file can't be rotated: %q", u)
+		}
+		return OpenSharedFile(name)
+	}
+	if strings.Contains(name, "%") {
+		retain := 0
+		if s := q.Get("retain"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return nil, fmt.Errorf("zapr: invalid output file retain count: %q: %w", s, err)
+			}
+			retain = n
+		}
+		// Compression and encryption are wrapped around each file, so that
+		// each has a whole stream.
+		return openRotatingFile(name, retain, func(out Output) (Output, error) {
+			return wrapStream(out, q)
+		})
+	}
+	return OpenFile(name)
+}
+
+type fileOutput struct {
+	mu   sync.Mutex
+	f    *os.File
+	name string
+	lock bool
+}
+
+func (o *fileOutput) Write(b []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.lock {
+		if err := lockFile(o.f); err != nil {
+			return 0, fmt.Errorf("zapr: failed to lock output file: %w", err)
+		}
+		defer unlockFile(o.f)
+	}
+	return o.f.Write(b)
+}
+
+func (o *fileOutput) Sync() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.f.Sync()
+}
+
+func (o *fileOutput) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.f.Close()
+}
+
+// Reopen opens the file by name, such that writes follow a file that has been
+// moved and replaced, and closes the previous file.
+func (o *fileOutput) Reopen() error {
+	f, err := openFile(o.name)
+	if err != nil {
+		return err
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.f.Sync()
+	o.f.Close()
+	o.f = f
+	return nil
+}
@@ -0,0 +1,19 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !unix
+
+package output
+
+import (
+	"errors"
+	"os"
+)
+
+var errLockUnsupported = errors.New("zapr: file locking is not supported on this platform")
+
+func lockFile(f *os.File) error   { return errLockUnsupported }
+func unlockFile(f *os.File) error { return errLockUnsupported }
@@ -0,0 +1,268 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventReconnect is reported by network outputs when a connection is restored.
+const EventReconnect = "reconnect"
+
+// ErrDisconnected is returned by writes to a network output while it's
+// disconnected, if it's configured to fail them.
+var ErrDisconnected = errors.New("zapr: output disconnected")
+
+// NetConfig is the configuration of a network output.
+type NetConfig struct {
+	// Network is "tcp" or "udp".
+	Network string
+
+	// Address is the remote address.
+	Address string
+
+	// TLS is the TLS configuration for TCP. If it's nil, TLS isn't used.
+	TLS *tls.Config
+
+	// Pool is the number of connections, between which writes are
+	// distributed. The default is 1.
+	Pool int
+
+	// Block sets whether writes block while disconnected.
+	// By default, they're dropped.
+	Block bool
+
+	// Fail sets whether writes fail with ErrDisconnected while disconnected,
+	// such that they may be spooled. It takes precedence over Block.
+	Fail bool
+
+	// Backoff is the delay before reconnecting, which doubles with each
+	// failure up to MaxBackoff. Delays are jittered. The defaults are
+	// 100ms and 10s.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// WriteTimeout is the limit on each write, after which the connection
+	// is considered failed. The default is 5s.
+	WriteTimeout time.Duration
+}
+
+func openNetURL(u *url.URL) (Output, error) {
+	q := u.Query()
+	cfg := NetConfig{Network: u.Scheme, Address: u.Host}
+	if u.Scheme == "tls" {
+		host, _, _ := net.SplitHostPort(u.Host)
+		cfg.Network = "tcp"
+		cfg.TLS = &tls.Config{ServerName: host}
+	}
+	if s := q.Get("pool"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("zapr: invalid output pool size: %q: %w", s, err)
+		}
+		cfg.Pool = n
+	}
+	if s := q.Get("block"); s != "" {
+		block, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("zapr: invalid output block policy: %q: %w", s, err)
+		}
+		cfg.Block = block
+	}
+	cfg.Fail = q.Get("spool") != ""
+	return Dial(cfg)
+}
+
+// Dial returns an Output that writes to a network address. Each write is sent
+// on one of a pool of connections. If a connection fails, it's reconnected in
+// the background with jittered exponential backoff, while writes to it are
+// dropped, blocked, or failed according to the configuration. Connections
+// that can't be established at first are handled the same way, so the remote
+// needn't be up when Dial is called. If the Output is observed, dropped writes
+// and reconnections are reported as events.
+func Dial(cfg NetConfig) (Output, error) {
+	if cfg.Pool <= 0 {
+		cfg.Pool = 1
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = 100 * time.Millisecond
+	}
+	if cfg.MaxBackoff < cfg.Backoff {
+		cfg.MaxBackoff = 10 * time.Second
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = 5 * time.Second
+	}
+	name := cfg.Network
+	if cfg.TLS != nil {
+		name = "tls"
+	}
+	o := &netOutput{cfg: cfg, name: name, done: make(chan struct{})}
+	for i := 0; i < cfg.Pool; i++ {
+		c := &netConn{o: o}
+		c.cond.L = &c.mu
+		if conn, err := o.dial(); err == nil {
+			c.conn = conn
+		} else {
+			go c.reconnect()
+		}
+		o.pool = append(o.pool, c)
+	}
+	return o, nil
+}
+
+type netOutput struct {
+	cfg  NetConfig
+	name string
+	pool []*netConn
+	next atomic.Uint32
+
+	mu       sync.Mutex
+	done     chan struct{}
+	closed   bool
+	observer Observer
+}
+
+func (o *netOutput) dial() (net.Conn, error) {
+	d := &net.Dialer{Timeout: 5 * time.Second}
+	if o.cfg.TLS != nil {
+		return tls.DialWithDialer(d, o.cfg.Network, o.cfg.Address, o.cfg.TLS)
+	}
+	return d.Dial(o.cfg.Network, o.cfg.Address)
+}
+
+func (o *netOutput) Observe(observer Observer) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.observer = observer
+}
+
+func (o *netOutput) observe(event string) {
+	o.mu.Lock()
+	observer := o.observer
+	o.mu.Unlock()
+	if observer != nil {
+		observer.ObserveOutputEvent(o.name, event)
+	}
+}
+
+func (o *netOutput) Write(b []byte) (int, error) {
+	c := o.pool[int(o.next.Add(1))%len(o.pool)]
+	return c.write(b)
+}
+
+func (o *netOutput) Sync() error { return nil }
+
+func (o *netOutput) Close() error {
+	o.mu.Lock()
+	if o.closed {
+		o.mu.Unlock()
+		return nil
+	}
+	o.closed = true
+	close(o.done)
+	o.mu.Unlock()
+
+	var err error
+	for _, c := range o.pool {
+		if cerr := c.close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+type netConn struct {
+	o *netOutput
+
+	mu     sync.Mutex
+	cond   sync.Cond // broadcast when connected or closed
+	conn   net.Conn  // nil while disconnected
+	closed bool
+}
+
+func (c *netConn) write(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for {
+		for c.conn == nil {
+			if c.closed {
+				return 0, os.ErrClosed
+			}
+			if c.o.cfg.Fail {
+				return 0, ErrDisconnected
+			}
+			if !c.o.cfg.Block {
+				c.o.observe(EventDropped)
+				return len(b), nil
+			}
+			c.cond.Wait()
+		}
+		c.conn.SetWriteDeadline(time.Now().Add(c.o.cfg.WriteTimeout))
+		n, err := c.conn.Write(b)
+		if err == nil {
+			return n, nil
+		}
+		c.o.observe(EventError)
+		c.conn.Close()
+		c.conn = nil
+		go c.reconnect()
+	}
+}
+
+func (c *netConn) reconnect() {
+	backoff := c.o.cfg.Backoff
+	for {
+		// Wait between half and all of the backoff.
+		t := time.NewTimer(backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1)))
+		select {
+		case <-t.C:
+		case <-c.o.done:
+			t.Stop()
+			return
+		}
+		conn, err := c.o.dial()
+		if err == nil {
+			c.mu.Lock()
+			if c.closed {
+				conn.Close()
+			} else {
+				c.conn = conn
+				c.cond.Broadcast()
+			}
+			c.mu.Unlock()
+			c.o.observe(EventReconnect)
+			return
+		}
+		if backoff *= 2; backoff > c.o.cfg.MaxBackoff {
+			backoff = c.o.cfg.MaxBackoff
+		}
+	}
+}
+
+func (c *netConn) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	c.cond.Broadcast()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
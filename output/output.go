@@ -0,0 +1,389 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package output provides outputs identified by URLs with flag integration.
+package output
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// An Output is a zapcore.WriteSyncer that holds resources
+// which are released by calling Close.
+type Output interface {
+	zapcore.WriteSyncer
+	io.Closer
+}
+
+// An Opener opens an Output for a URL.
+type Opener func(u *url.URL) (Output, error)
+
+var openers = make(map[string]Opener)
+
+// RegisterScheme registers the Opener for URLs with the given scheme.
+func RegisterScheme(scheme string, fn Opener) error {
+	scheme = strings.ToLower(scheme)
+	if _, ok := openers[scheme]; ok {
+		return fmt.Errorf("zapr: already registered output scheme: %q", scheme)
+	}
+	openers[scheme] = fn
+	return nil
+}
+
+// Schemes returns the registered schemes.
+func Schemes() []string {
+	s := make([]string, 0, len(openers))
+	for scheme := range openers {
+		s = append(s, scheme)
+	}
+	sort.Strings(s)
+	return s
+}
+
+func init() {
+	must(RegisterScheme("stderr", func(*url.URL) (Output, error) { return Stderr(), nil }))
+	must(RegisterScheme("stdout", func(*url.URL) (Output, error) { return Stdout(), nil }))
+	must(RegisterScheme("file", openFileURL))
+	must(RegisterScheme("tcp", openNetURL))
+	must(RegisterScheme("udp", openNetURL))
+	must(RegisterScheme("tls", openNetURL))
+}
+
+// Open opens the Output identified by the URL.
+//
+// The "stderr" and "stdout" schemes identify the standard streams. The "file"
+// scheme, or a URL without a scheme, identifies a file path. If the path
+// contains time directives (e.g. "app-%Y%m%d.log"), the file is rotated as
+// described by OpenRotatingFile and the "retain" query parameter sets the
+// number of files to keep. Each rotated file is compressed or encrypted as a
+// whole stream. If the "lock" query parameter is true, the file may
+// be shared by multiple processes as described by OpenSharedFile. The "tcp",
+// "udp", and "tls" schemes identify network addresses as described by Dial,
+// where the "pool" query parameter sets the number of connections and the
+// "block" query parameter sets whether writes block while disconnected. If the
+// "spool" query parameter is set, writes fail while disconnected so that they're
+// spooled.
+// Other schemes may be added with RegisterScheme.
+//
+// The following query parameters are supported by all schemes:
+//
+//	compress        name of a registered Compressor (e.g. "gzip")
+//	compress-level  level of a LeveledCompressor (e.g. "fastest", "default", "better", or "best")
+//	key-file        path of a hex-encoded AES key with which to encrypt the output
+//	recipient-file  path of a hex-encoded X25519 recipient to which to encrypt the output
+//	flush           maximum duration compressed or encrypted data is buffered (default "1s")
+//	spool           directory in which failed writes are spooled, as described by Spool
+//	spool-size      maximum number of bytes spooled (default 64MiB)
+//	failover        URL of an output to which writes fail over, as described by NewFailover
+//	async           number of writes queued for a background goroutine, as described by Async
+func Open(rawURL string) (Output, error) {
+	u, err := parseURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	fn, ok := openers[u.Scheme]
+	if !ok {
+		return nil, fmt.Errorf("zapr: unknown output scheme: %q", u.Scheme)
+	}
+	out, err := fn(u)
+	if err != nil {
+		return nil, err
+	}
+	if out, err = wrap(out, u.Query()); err != nil {
+		return nil, err
+	}
+	return &namedOutput{Output: out, name: rawURL}, nil
+}
+
+func parseURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(escapePercents(rawURL))
+	if err != nil {
+		return nil, fmt.Errorf("zapr: invalid output URL: %q: %w", rawURL, err)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme == "" {
+		switch u.Path {
+		case "stderr", "stdout":
+			u.Scheme, u.Path = u.Path, ""
+		default:
+			u.Scheme = "file"
+		}
+	}
+	return u, nil
+}
+
+func wrap(out Output, q url.Values) (Output, error) {
+	if _, ok := out.(*rotateOutput); !ok { // rotating files wrap each file
+		var err error
+		if out, err = wrapStream(out, q); err != nil {
+			return nil, err
+		}
+	}
+	if dir := q.Get("spool"); dir != "" {
+		cfg := SpoolConfig{Dir: dir}
+		if s := q.Get("spool-size"); s != "" {
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil || n <= 0 {
+				out.Close()
+				return nil, fmt.Errorf("zapr: invalid output spool size: %q", s)
+			}
+			cfg.MaxSize = n
+		}
+		sp, err := Spool(out, cfg)
+		if err != nil {
+			out.Close()
+			return nil, err
+		}
+		out = sp
+	}
+	if s := q.Get("failover"); s != "" {
+		secondary, err := Open(s)
+		if err != nil {
+			out.Close()
+			return nil, err
+		}
+		out = NewFailover(FailoverConfig{Primary: out, Secondary: secondary})
+	}
+	if s := q.Get("async"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			out.Close()
+			return nil, fmt.Errorf("zapr: invalid output async queue size: %q", s)
+		}
+		out = Async(out, n)
+	}
+	return out, nil
+}
+
+// wrapStream wraps the Output with the compression and encryption streams
+// described by the query parameters. If it fails, the Output is closed.
+func wrapStream(out Output, q url.Values) (Output, error) {
+	interval := time.Second
+	if s := q.Get("flush"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			out.Close()
+			return nil, fmt.Errorf("zapr: invalid output flush interval: %q: %w", s, err)
+		}
+		interval = d
+	}
+	if name := q.Get("key-file"); name != "" {
+		key, err := ReadKeyFile(name)
+		if err != nil {
+			out.Close()
+			return nil, err
+		}
+		enc, err := Encrypt(out, key, interval)
+		if err != nil {
+			out.Close()
+			return nil, err
+		}
+		out = enc
+	}
+	if name := q.Get("recipient-file"); name != "" {
+		if q.Get("key-file") != "" {
+			out.Close()
+			return nil, errors.New("zapr: output has both key-file and recipient-file")
+		}
+		recipient, err := ReadKeyFile(name)
+		if err != nil {
+			out.Close()
+			return nil, err
+		}
+		enc, err := EncryptToRecipient(out, recipient, interval)
+		if err != nil {
+			out.Close()
+			return nil, err
+		}
+		out = enc
+	}
+	if name := q.Get("compress"); name != "" {
+		c, ok := compressors[name]
+		if !ok {
+			out.Close()
+			return nil, fmt.Errorf("zapr: unknown Compressor: %q", name)
+		}
+		if level := q.Get("compress-level"); level != "" {
+			lc, ok := c.(LeveledCompressor)
+			if !ok {
+				out.Close()
+				return nil, fmt.Errorf("zapr: Compressor doesn't support levels: %q", name)
+			}
+			var err error
+			if c, err = lc.WithLevel(CompressLevel(level)); err != nil {
+				out.Close()
+				return nil, err
+			}
+		}
+		z, err := Compress(out, c, interval)
+		if err != nil {
+			out.Close()
+			return nil, err
+		}
+		out = z
+	}
+	return out, nil
+}
+
+// redacted replaces secrets in redacted URLs.
+const redacted = "xxxxx"
+
+// Redact returns the output URL with its secrets masked, such that it may be
+// logged. The user info is masked, as are the values of headers and of query
+// parameters whose names suggest secrets (e.g. "token" or "api-key"), except
+// for those naming files. The URLs of failover outputs are also redacted.
+func Redact(rawURL string) string {
+	u, err := url.Parse(escapePercents(rawURL))
+	if err != nil {
+		return redacted
+	}
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+		} else {
+			u.User = url.User(redacted) // it may be a token
+		}
+	}
+	if u.RawQuery != "" {
+		q := u.Query()
+		for name, values := range q {
+			for i, v := range values {
+				switch {
+				case name == "failover":
+					values[i] = Redact(v)
+				case name == "header":
+					if k, _, ok := strings.Cut(v, ":"); ok {
+						values[i] = k + ":" + redacted
+					} else {
+						values[i] = redacted
+					}
+				case isSecretParam(name):
+					values[i] = redacted
+				}
+			}
+		}
+		u.RawQuery = q.Encode()
+	}
+	return strings.ReplaceAll(u.String(), "%25", "%")
+}
+
+func isSecretParam(name string) bool {
+	name = strings.ToLower(name)
+	if strings.HasSuffix(name, "-file") {
+		return false
+	}
+	for _, s := range []string{"key", "token", "secret", "password", "auth", "credential"} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// escapePercents escapes percent signs which don't begin a valid escape
+// sequence, such that time directives in file patterns needn't be escaped.
+// Before the query, "%d" is always the day directive, even if it's followed
+// by hex digits, so it's escaped too.
+func escapePercents(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+	var b strings.Builder
+	query := false
+	for i := 0; i < len(s); i++ {
+		query = query || s[i] == '?'
+		if s[i] == '%' && (i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) || !query && s[i+1] == 'd') {
+			b.WriteString("%25")
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+type namedOutput struct {
+	Output
+	name string
+}
+
+func (o *namedOutput) String() string { return o.name }
+
+func (o *namedOutput) Observe(observer Observer) {
+	if v, ok := o.Output.(Observable); ok {
+		v.Observe(observer)
+	}
+}
+
+// Reopen reopens the Output, if it implements Reopener.
+// Outputs that are compressed or encrypted aren't reopened.
+func (o *namedOutput) Reopen() error { return reopen(o.Output) }
+
+func (o *namedOutput) Pressure() float64 { return Pressure(o.Output) }
+
+func (o *namedOutput) OnPressure(threshold float64, fn func(pressure float64)) {
+	if v, ok := o.Output.(Pressurer); ok {
+		v.OnPressure(threshold, fn)
+	}
+}
+
+type outputFlag struct {
+	ws   *zapcore.WriteSyncer
+	name string
+	out  Output // opened by Set
+}
+
+// Flag returns a flag value which opens an Output from a URL. Setting it again
+// closes the Output it previously opened.
+func Flag(ws *zapcore.WriteSyncer) flag.Value {
+	return &outputFlag{ws: ws}
+}
+
+func (f *outputFlag) Get() interface{} { return *f.ws }
+func (f *outputFlag) Set(s string) error {
+	out, err := Open(s)
+	if err != nil {
+		return err
+	}
+	*f.ws = out
+	f.name = s
+	if f.out != nil {
+		f.out.Close() // replaced
+	}
+	f.out = out
+	return nil
+}
+func (f *outputFlag) String() string {
+	if f.name != "" {
+		return f.name
+	}
+	if f.ws == nil {
+		return ""
+	}
+	if s, ok := (*f.ws).(fmt.Stringer); ok {
+		return s.String()
+	}
+	return ""
+}
+
+func must(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,1000 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestOpenCompress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log.gz")
+	out, err := Open("file://" + path + "?compress=gzip&flush=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = "hello\nworld\n"
+	if _, err := out.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := out.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := out.Write([]byte("world\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := out.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b); got != want {
+		t.Errorf("unexpected output: want: %q; got: %q", want, got)
+	}
+}
+
+func TestOpenCompressLevel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log.gz")
+	out, err := Open("file://" + path + "?compress=gzip&compress-level=best")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := out.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open("file://" + path + "?compress=gzip&compress-level=extreme"); err == nil {
+		t.Errorf("expected unknown compression level error")
+	}
+}
+
+func TestFlagReplace(t *testing.T) {
+	dir := t.TempDir()
+	ws := zapcore.WriteSyncer(&closeBuffer{})
+	f := Flag(&ws)
+	first := filepath.Join(dir, "first.log.gz")
+	if err := f.Set("file://" + first + "?compress=gzip"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ws.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	// Replacing the output closes the first, which ends its stream.
+	if err := f.Set(filepath.Join(dir, "second.log")); err != nil {
+		t.Fatal(err)
+	}
+	defer ws.(Output).Close()
+	b, err := os.ReadFile(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err = gunzip(b); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "hello\n", string(b); got != want {
+		t.Errorf("unexpected output: want: %q; got: %q", want, got)
+	}
+}
+
+func TestOpenUnknown(t *testing.T) {
+	if _, err := Open("bogus://foo"); err == nil {
+		t.Error("expected error for unknown scheme")
+	}
+	if _, err := Open("stderr?compress=bogus"); err == nil {
+		t.Error("expected error for unknown compressor")
+	}
+}
+
+func TestEncrypt(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	buf := &closeBuffer{}
+	var want []byte
+	// Write two streams, as if the file were opened twice.
+	for i := 0; i < 2; i++ {
+		out, err := Encrypt(buf, key, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range []string{"hello\n", strings.Repeat("x", encChunkSize+10), "world\n"} {
+			if _, err := out.Write([]byte(s)); err != nil {
+				t.Fatal(err)
+			}
+			want = append(want, s...)
+		}
+		if err := out.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got bytes.Buffer
+	if err := Decrypt(&got, bytes.NewReader(buf.Bytes()), key); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("unexpected decrypted data: want %d bytes; got %d bytes", len(want), got.Len())
+	}
+
+	got.Reset()
+	err := Decrypt(&got, bytes.NewReader(buf.Bytes()[:buf.Len()-1]), key)
+	if err != ErrTruncated {
+		t.Errorf("unexpected error: want: %v; got: %v", ErrTruncated, err)
+	}
+	if !bytes.HasPrefix(want, got.Bytes()) || got.Len() == 0 {
+		t.Errorf("unexpected truncated data: got %d bytes", got.Len())
+	}
+}
+
+func TestEncryptToRecipient(t *testing.T) {
+	identity, recipient, err := GenerateIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &closeBuffer{}
+	var want []byte
+	// Write two streams, as if the file were opened twice.
+	for i := 0; i < 2; i++ {
+		out, err := EncryptToRecipient(buf, recipient, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range []string{"hello\n", strings.Repeat("x", encChunkSize+10), "world\n"} {
+			if _, err := out.Write([]byte(s)); err != nil {
+				t.Fatal(err)
+			}
+			want = append(want, s...)
+		}
+		if err := out.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got bytes.Buffer
+	if err := DecryptWithIdentity(&got, bytes.NewReader(buf.Bytes()), identity); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("unexpected decrypted data: want %d bytes; got %d bytes", len(want), got.Len())
+	}
+
+	other, _, err := GenerateIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := DecryptWithIdentity(io.Discard, bytes.NewReader(buf.Bytes()), other); err == nil {
+		t.Error("expected error for the wrong identity")
+	}
+	if err := Decrypt(io.Discard, bytes.NewReader(buf.Bytes()), bytes.Repeat([]byte{7}, 32)); err == nil {
+		t.Error("expected error for a key")
+	}
+}
+
+func TestRedact(t *testing.T) {
+	for _, tt := range []struct{ url, want string }{
+		{"stderr", "stderr"},
+		{"/var/log/app-%Y%m%d.log?retain=3", "/var/log/app-%Y%m%d.log?retain=3"},
+		{"file:///var/log/app.log?key-file=/etc/key", "file:///var/log/app.log?key-file=%2Fetc%2Fkey"},
+		{"nats://s3cr3t@localhost:4222", "nats://xxxxx@localhost:4222"},
+		{"elasticsearch://user:s3cr3t@es:9200?index=app", "elasticsearch://user:xxxxx@es:9200?index=app"},
+		{"webhook://example.com/?header=Authorization:Bearer+s3cr3t", "webhook://example.com/?header=Authorization%3Axxxxx"},
+		{"splunk://example.com/?token=s3cr3t", "splunk://example.com/?token=xxxxx"},
+		{"tcp://a:1?failover=https://u:s3cr3t@b", "tcp://a:1?failover=https%3A%2F%2Fu%3Axxxxx%40b"},
+	} {
+		if got := Redact(tt.url); got != tt.want {
+			t.Errorf("unexpected redacted URL: %q: want: %q; got: %q", tt.url, tt.want, got)
+		}
+	}
+}
+
+func TestSharedFile(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	var outs []Output
+	for i := 0; i < 2; i++ {
+		out, err := Open("file://" + name + "?lock=true")
+		if err != nil {
+			t.Skip(err) // unsupported platform
+		}
+		outs = append(outs, out)
+	}
+	for i := 0; i < 10; i++ {
+		if _, err := outs[i%2].Write([]byte(fmt.Sprintf("line %d\n", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, out := range outs {
+		if err := out.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 10, strings.Count(string(b), "\n"); got != want {
+		t.Errorf("unexpected lines: want: %d; got: %d", want, got)
+	}
+
+	if out, err := Open("file://" + dir + "/app-%Y%m%d.log?lock=true"); err == nil {
+		out.Close()
+		t.Error("expected error for locked rotating file")
+	}
+}
+
+func TestLockedEncryptedFile(t *testing.T) {
+	dir := t.TempDir()
+	for _, q := range []string{"compress=gzip", "key-file=key", "recipient-file=recipient"} {
+		if out, err := Open("file://" + dir + "/app.log?lock=true&" + q); err == nil {
+			out.Close()
+			t.Errorf("expected error for locked file with %s", q)
+		}
+	}
+}
+
+type closeBuffer struct{ bytes.Buffer }
+
+func (*closeBuffer) Sync() error  { return nil }
+func (*closeBuffer) Close() error { return nil }
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	out, err := Open("file://" + dir + "/app-%Y%m%d-%H.log?retain=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	o := out.(*namedOutput).Output.(*rotateOutput)
+	now := time.Now().Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		o.now = func() time.Time { return now }
+		if _, err := out.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+		if want := filepath.Join(dir, now.Format("app-20060102-15.log")); o.name != want {
+			t.Errorf("unexpected file name: want: %q; got: %q", want, o.name)
+		}
+		now = now.Add(time.Hour)
+	}
+	names, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 {
+		t.Errorf("unexpected retained files: %q", names)
+	}
+}
+
+func TestRotatingFilePrune(t *testing.T) {
+	dir := t.TempDir()
+	foreign := []string{"app-backup1.log", "app-2023-01-021.log", "app-[20230102]1.log", "app-2023010221.log"}
+	for _, name := range foreign {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out, err := Open("file://" + dir + "/app-%Y%m%d1.log?retain=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	o := out.(*namedOutput).Output.(*rotateOutput)
+	now := time.Now().AddDate(0, 0, 1)
+	for i := 0; i < 3; i++ {
+		o.now = func() time.Time { return now }
+		if _, err := out.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+		if want := filepath.Join(dir, now.Format("app-20060102")+"1.log"); o.name != want {
+			t.Errorf("unexpected file name: want: %q; got: %q", want, o.name)
+		}
+		now = now.AddDate(0, 0, 1)
+	}
+	for _, name := range foreign {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("unexpected foreign file error: %v", err)
+		}
+	}
+	names, err := filepath.Glob(filepath.Join(dir, "app-????????1.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 {
+		t.Errorf("unexpected retained files: %q", names)
+	}
+}
+
+func TestRotatingFileStreams(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte(hex.EncodeToString(key)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name   string
+		query  string
+		decode func(b []byte) ([]byte, error)
+	}{
+		{
+			name:   "gzip",
+			query:  "compress=gzip",
+			decode: gunzip,
+		},
+		{
+			name:  "encrypted",
+			query: "compress=gzip&key-file=" + keyFile,
+			decode: func(b []byte) ([]byte, error) {
+				var buf bytes.Buffer
+				if err := Decrypt(&buf, bytes.NewReader(b), key); err != nil {
+					return nil, err
+				}
+				return gunzip(buf.Bytes())
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			out, err := Open("file://" + dir + "/app-%Y%m%d-%H.log?" + tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			o := out.(*namedOutput).Output.(*rotateOutput)
+			now := time.Now().Add(time.Hour)
+			var names []string
+			for i := 0; i < 3; i++ {
+				o.now = func() time.Time { return now }
+				for k := 0; k < 2; k++ {
+					if _, err := fmt.Fprintf(out, "hello %d\n", i); err != nil {
+						t.Fatal(err)
+					}
+				}
+				names = append(names, o.name)
+				now = now.Add(time.Hour)
+			}
+			if err := out.Close(); err != nil {
+				t.Fatal(err)
+			}
+			for i, name := range names {
+				b, err := os.ReadFile(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := tt.decode(b)
+				if err != nil {
+					t.Fatalf("failed to decode file %d: %v", i, err)
+				}
+				if want := strings.Repeat(fmt.Sprintf("hello %d\n", i), 2); string(got) != want {
+					t.Errorf("unexpected file %d: want: %q; got: %q", i, want, got)
+				}
+			}
+		})
+	}
+}
+
+func gunzip(b []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(zr)
+}
+
+type eventRecorder chan string
+
+func (r eventRecorder) ObserveOutputEvent(output, event string) { r <- output + ":" + event }
+
+func TestFileReopen(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	out, err := Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	if _, err := out.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(name, name+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := out.(Reopener).Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := out.Write([]byte("world\n")); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{name + ".1": "hello\n", name: "world\n"} {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(b); got != want {
+			t.Errorf("unexpected %s contents: want: %q; got: %q", filepath.Base(name), want, got)
+		}
+	}
+}
+
+func TestAsyncReopen(t *testing.T) {
+	dir := t.TempDir()
+	for _, query := range []string{"async=8", "failover=stderr&async=8"} {
+		out, err := Open("file://" + dir + "/app-%Y%m%d.log?" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		name := filepath.Join(dir, time.Now().Format("app-20060102.log"))
+		if _, err := out.Write([]byte("hello\n")); err != nil {
+			t.Fatal(err)
+		}
+		if err := out.Sync(); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(name, name+".1"); err != nil {
+			t.Fatal(err)
+		}
+		if err := out.(Reopener).Reopen(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := out.Write([]byte("world\n")); err != nil {
+			t.Fatal(err)
+		}
+		if err := out.Close(); err != nil {
+			t.Fatal(err)
+		}
+		for name, want := range map[string]string{name + ".1": "hello\n", name: "world\n"} {
+			b, err := os.ReadFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(b); got != want {
+				t.Errorf("unexpected %s contents with %s: want: %q; got: %q", filepath.Base(name), query, want, got)
+			}
+			os.Remove(name)
+		}
+	}
+}
+
+func TestDialReconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	conns := make(chan net.Conn, 2)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conns <- conn
+		}
+	}()
+
+	out, err := Dial(NetConfig{Network: "tcp", Address: ln.Addr().String(), Backoff: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	events := make(eventRecorder, 16)
+	out.(Observable).Observe(events)
+
+	conn := <-conns
+	if _, err := out.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	if line, _ := bufio.NewReader(conn).ReadString('\n'); line != "hello\n" {
+		t.Errorf("unexpected line: %q", line)
+	}
+	conn.Close()
+
+	// Write until the failure is detected and the connection is restored.
+	// Writes are dropped while disconnected.
+	deadline := time.Now().Add(5 * time.Second)
+	for reconnected := false; !reconnected; {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting to reconnect")
+		}
+		if _, err := out.Write([]byte("dropped\n")); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case ev := <-events:
+			reconnected = ev == "tcp:"+EventReconnect
+		case <-time.After(time.Millisecond):
+		}
+	}
+	conn = <-conns
+	if _, err := out.Write([]byte("world\n")); err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line == "world\n" {
+			break
+		}
+	}
+}
+
+func TestDialDown(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	// The remote is down, so writes fail until it's up.
+	out, err := Dial(NetConfig{Network: "tcp", Address: addr, Fail: true, Backoff: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	if _, err := out.Write([]byte("failed\n")); err != ErrDisconnected {
+		t.Errorf("unexpected write error: want: %v; got: %v", ErrDisconnected, err)
+	}
+
+	if ln, err = net.Listen("tcp", addr); err != nil {
+		t.Skipf("failed to relisten: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if _, err := out.Write([]byte("hello\n")); err == nil {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if line, _ := bufio.NewReader(conn).ReadString('\n'); line != "hello\n" {
+		t.Errorf("unexpected line: %q", line)
+	}
+}
+
+func TestDialWriteTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		// Accept connections but never read from them.
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	out, err := Dial(NetConfig{
+		Network:      "tcp",
+		Address:      ln.Addr().String(),
+		Fail:         true,
+		Backoff:      time.Hour,
+		WriteTimeout: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	events := make(eventRecorder, 16)
+	out.(Observable).Observe(events)
+
+	// Fill the socket buffers until a write times out.
+	done := make(chan error, 1)
+	go func() {
+		b := make([]byte, 1<<20)
+		for {
+			if _, err := out.Write(b); err != nil {
+				done <- err
+				return
+			}
+		}
+	}()
+	select {
+	case err := <-done:
+		if err != ErrDisconnected {
+			t.Errorf("unexpected write error: want: %v; got: %v", ErrDisconnected, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for write to time out")
+	}
+	if want, got := "tcp:"+EventError, <-events; got != want {
+		t.Errorf("unexpected event: want: %q; got: %q", want, got)
+	}
+}
+
+func TestBatchPressure(t *testing.T) {
+	release := make(chan struct{})
+	out := NewBatchOutput(BatchConfig{
+		Size:  1,
+		Queue: 4,
+		Send: func(ctx context.Context, batch []byte) error {
+			<-release
+			return nil
+		},
+	})
+	defer out.Close()
+	pressures := make(chan float64, 2)
+	out.(Pressurer).OnPressure(0.5, func(p float64) { pressures <- p })
+
+	// The first batch is received by the sender and blocks it.
+	for i := 0; i < 3; i++ {
+		if _, err := out.Write([]byte("entry\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if p := <-pressures; p < 0.5 {
+		t.Errorf("unexpected high pressure: %v", p)
+	}
+	if p := Pressure(out); p < 0.5 {
+		t.Errorf("unexpected pressure: %v", p)
+	}
+	close(release)
+	if p := <-pressures; p >= 0.5 {
+		t.Errorf("unexpected low pressure: %v", p)
+	}
+}
+
+func TestBatchCloseTimeout(t *testing.T) {
+	out := NewBatchOutput(BatchConfig{
+		Size:         1,
+		CloseTimeout: 10 * time.Millisecond,
+		Send: func(ctx context.Context, batch []byte) error {
+			<-ctx.Done() // hang until canceled
+			return ctx.Err()
+		},
+	})
+	if _, err := out.Write([]byte("entry\n")); err != nil {
+		t.Fatal(err)
+	}
+	closed := make(chan error, 1)
+	go func() { closed <- out.Close() }()
+	select {
+	case err := <-closed:
+		if err != context.Canceled {
+			t.Errorf("unexpected close error: want: %v; got: %v", context.Canceled, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting to close")
+	}
+}
+
+type blockingOutput struct {
+	bytes.Buffer
+	release chan struct{}
+}
+
+func (o *blockingOutput) Write(b []byte) (int, error) {
+	<-o.release
+	return o.Buffer.Write(b)
+}
+
+func (o *blockingOutput) Sync() error  { return nil }
+func (o *blockingOutput) Close() error { return nil }
+
+func TestAsync(t *testing.T) {
+	dst := &blockingOutput{release: make(chan struct{})}
+	out := Async(dst, 2)
+	events := make(eventRecorder, 8)
+	out.(Observable).Observe(events)
+
+	// The first write is received by the writer and blocks it,
+	// the next two are queued, and the rest are dropped.
+	for i := 0; i < 5; i++ {
+		if _, err := out.Write([]byte{'a' + byte(i)}); err != nil {
+			t.Fatal(err)
+		}
+		for i == 0 && Pressure(out) != 0 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	close(dst.release)
+	if err := out.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "abc", dst.String(); got != want {
+		t.Errorf("unexpected output: want: %q; got: %q", want, got)
+	}
+	if want, got := 2, len(events); got != want {
+		t.Errorf("unexpected dropped events: want: %d; got: %d", want, got)
+	}
+}
+
+func TestAsyncClose(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		dst := &bufferOutput{}
+		out := Async(dst, 1024)
+		var (
+			wg      sync.WaitGroup
+			written atomic.Int64
+			dropped eventCounter
+		)
+		out.(Observable).Observe(&dropped)
+		for j := 0; j < 4; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					if _, err := out.Write([]byte("a")); err != nil {
+						if err != io.ErrClosedPipe {
+							t.Errorf("unexpected write error: want: %v; got: %v", io.ErrClosedPipe, err)
+						}
+						return
+					}
+					written.Add(1)
+				}
+			}()
+		}
+		time.Sleep(time.Millisecond)
+		if err := out.Close(); err != nil {
+			t.Fatal(err)
+		}
+		wg.Wait()
+		// Every successful write is written, unless it's dropped.
+		if want, got := written.Load()-dropped.Load(), int64(dst.Len()); got != want {
+			t.Fatalf("unexpected output length: want: %d; got: %d", want, got)
+		}
+	}
+}
+
+type eventCounter struct{ atomic.Int64 }
+
+func (c *eventCounter) ObserveOutputEvent(output, event string) { c.Add(1) }
+
+type failingOutput struct {
+	bufferOutput
+	fail bool
+}
+
+func (o *failingOutput) Write(b []byte) (int, error) {
+	if o.fail {
+		return 0, io.ErrClosedPipe
+	}
+	return o.bufferOutput.Write(b)
+}
+
+type bufferOutput struct{ bytes.Buffer }
+
+func (*bufferOutput) Sync() error  { return nil }
+func (*bufferOutput) Close() error { return nil }
+
+func TestFailover(t *testing.T) {
+	primary, secondary := &failingOutput{}, &bufferOutput{}
+	out := NewFailover(FailoverConfig{Primary: primary, Secondary: secondary, Probe: time.Minute})
+	events := make(eventRecorder, 8)
+	out.(Observable).Observe(events)
+	now := time.Now()
+	out.(*failoverOutput).now = func() time.Time { return now }
+
+	write := func(s string) {
+		if _, err := out.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a")
+	primary.fail = true
+	write("b")
+	primary.fail = false
+	write("c") // before the probe
+	now = now.Add(time.Minute)
+	write("d")
+	close(events)
+
+	if want, got := "ad", primary.String(); got != want {
+		t.Errorf("unexpected primary output: want: %q; got: %q", want, got)
+	}
+	if want, got := "bc", secondary.String(); got != want {
+		t.Errorf("unexpected secondary output: want: %q; got: %q", want, got)
+	}
+	var got []string
+	for e := range events {
+		got = append(got, e)
+	}
+	if want := "failover:failover,failover:recover"; strings.Join(got, ",") != want {
+		t.Errorf("unexpected events: want: %q; got: %q", want, strings.Join(got, ","))
+	}
+}
+
+func TestFailoverTimeout(t *testing.T) {
+	primary := &blockingOutput{release: make(chan struct{})}
+	secondary := &bufferOutput{}
+	out := NewFailover(FailoverConfig{Primary: primary, Secondary: secondary, Timeout: time.Millisecond})
+	if _, err := out.Write([]byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := out.Write([]byte("b")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Close waits for the abandoned write to the primary.
+	closed := make(chan error, 1)
+	go func() { closed <- out.Close() }()
+	select {
+	case <-closed:
+		t.Fatal("closed during a write to the primary")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(primary.release)
+	if err := <-closed; err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "a", primary.String(); got != want {
+		t.Errorf("unexpected primary output: want: %q; got: %q", want, got)
+	}
+	if want, got := "ab", secondary.String(); got != want {
+		t.Errorf("unexpected secondary output: want: %q; got: %q", want, got)
+	}
+}
+
+type shortOutput struct {
+	bufferOutput
+	limit int
+}
+
+func (o *shortOutput) Write(b []byte) (int, error) {
+	if len(b) > o.limit {
+		n, _ := o.bufferOutput.Write(b[:o.limit])
+		return n, io.ErrShortWrite
+	}
+	return o.bufferOutput.Write(b)
+}
+
+func TestFailoverShortWrite(t *testing.T) {
+	primary, secondary := &shortOutput{limit: 3}, &bufferOutput{}
+	out := NewFailover(FailoverConfig{Primary: primary, Secondary: secondary})
+	n, err := out.Write([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 5, n; got != want {
+		t.Errorf("unexpected write length: want: %d; got: %d", want, got)
+	}
+	if want, got := "hel", primary.String(); got != want {
+		t.Errorf("unexpected primary output: want: %q; got: %q", want, got)
+	}
+	if want, got := "lo", secondary.String(); got != want {
+		t.Errorf("unexpected secondary output: want: %q; got: %q", want, got)
+	}
+}
+
+type flakyOutput struct {
+	mu   sync.Mutex
+	buf  bytes.Buffer
+	fail bool
+}
+
+func (o *flakyOutput) setFail(fail bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.fail = fail
+}
+
+func (o *flakyOutput) String() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.String()
+}
+
+func (o *flakyOutput) Write(b []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.fail {
+		return 0, io.ErrClosedPipe
+	}
+	return o.buf.Write(b)
+}
+
+func (*flakyOutput) Sync() error  { return nil }
+func (*flakyOutput) Close() error { return nil }
+
+func TestSpool(t *testing.T) {
+	dir := t.TempDir()
+	dst := &flakyOutput{}
+	out, err := Spool(dst, SpoolConfig{Dir: dir, Retry: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := make(eventRecorder, 8)
+	out.(Observable).Observe(events)
+
+	write := func(s string) {
+		if _, err := out.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a")
+	dst.setFail(true)
+	write("b")
+	write("c")
+	if err := out.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "a", dst.String(); got != want {
+		t.Errorf("unexpected output: want: %q; got: %q", want, got)
+	}
+	if want, got := "spool:spooled", <-events; got != want {
+		t.Errorf("unexpected event: want: %q; got: %q", want, got)
+	}
+
+	// Spooled data is replayed in order after a restart.
+	dst.setFail(false)
+	if out, err = Spool(dst, SpoolConfig{Dir: dir, Retry: time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	write("d")
+	for deadline := time.Now().Add(5 * time.Second); dst.String() != "abcd" && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	if err := out.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "abcd", dst.String(); got != want {
+		t.Errorf("unexpected output: want: %q; got: %q", want, got)
+	}
+	if names, _ := filepath.Glob(filepath.Join(dir, "*")); len(names) != 0 {
+		t.Errorf("unexpected spool files: %q", names)
+	}
+}
+
+func TestSpoolEviction(t *testing.T) {
+	dst := &flakyOutput{fail: true}
+	out, err := Spool(dst, SpoolConfig{Dir: t.TempDir(), MaxSize: 40, Retry: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := make(eventRecorder, 16)
+	out.(Observable).Observe(events)
+	for i := 0; i < 12; i++ {
+		if _, err := out.Write([]byte{'a' + byte(i)}); err != nil { // 5 bytes spooled
+			t.Fatal(err)
+		}
+	}
+	if err := out.Close(); err != nil {
+		t.Fatal(err)
+	}
+	close(events)
+	evicted := 0
+	for e := range events {
+		if e == "spool:evicted" {
+			evicted++
+		}
+	}
+	if evicted == 0 {
+		t.Error("expected evicted events")
+	}
+}
@@ -0,0 +1,273 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OpenRotatingFile returns an Output that appends to a file whose name is
+// derived from the pattern and the current local time, so that the output
+// rotates to a new file when the name changes. The pattern supports the
+// following directives:
+//
+//	%Y  four digit year
+//	%m  two digit month
+//	%d  two digit day of month
+//	%H  two digit hour
+//	%M  two digit minute
+//	%%  literal percent sign
+//
+// If retain is positive, only that many of the most recently modified files
+// matching the pattern are kept and older files are removed upon rotation.
+// It implements Reopener.
+func OpenRotatingFile(pattern string, retain int) (Output, error) {
+	return openRotatingFile(pattern, retain, nil)
+}
+
+// openRotatingFile is like OpenRotatingFile, except that if stream isn't nil,
+// each file is wrapped by it, such that stateful streams like compression and
+// encryption start and end with each file.
+func openRotatingFile(pattern string, retain int, stream func(Output) (Output, error)) (Output, error) {
+	layout, glob, period, err := parseRotatePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	o := &rotateOutput{
+		layout: layout,
+		glob:   glob,
+		period: period,
+		retain: retain,
+		stream: stream,
+		now:    time.Now,
+	}
+	if err := o.rotate(o.now()); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func parseRotatePattern(pattern string) (layout, glob string, period time.Duration, err error) {
+	var lb, gb strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		if c != '%' {
+			lb.WriteByte(c)
+			writeGlobLiteral(&gb, c)
+			continue
+		}
+		if i++; i == len(pattern) {
+			return "", "", 0, fmt.Errorf("zapr: invalid rotating file pattern: %q", pattern)
+		}
+		var s string
+		switch pattern[i] {
+		case 'Y':
+			s, period = "2006", maxPeriod(period, 24*time.Hour)
+		case 'm':
+			s, period = "01", maxPeriod(period, 24*time.Hour)
+		case 'd':
+			s, period = "02", maxPeriod(period, 24*time.Hour)
+		case 'H':
+			s, period = "15", maxPeriod(period, time.Hour)
+		case 'M':
+			s, period = "04", maxPeriod(period, time.Minute)
+		case '%':
+			lb.WriteByte('%')
+			gb.WriteString("[%]")
+			continue
+		default:
+			return "", "", 0, fmt.Errorf("zapr: invalid rotating file pattern directive: %q", pattern[i-1:i+1])
+		}
+		lb.WriteString("\x00" + s + "\x00")
+		// Each directive is formatted as exactly one digit per layout byte,
+		// so that pruning doesn't match unrelated files in the directory.
+		gb.WriteString(strings.Repeat("[0-9]", len(s)))
+	}
+	if period == 0 {
+		return "", "", 0, fmt.Errorf("zapr: rotating file pattern has no time directives: %q", pattern)
+	}
+	return lb.String(), gb.String(), period, nil
+}
+
+// writeGlobLiteral writes the byte to the glob such that it only matches itself.
+func writeGlobLiteral(gb *strings.Builder, c byte) {
+	switch c {
+	case '*', '?', '[':
+		gb.WriteString("[" + string(c) + "]")
+	case '\\':
+		if os.PathSeparator == '\\' {
+			// Backslash is a separator, not an escape, on Windows.
+			gb.WriteByte(c)
+		} else {
+			gb.WriteString(`\\`)
+		}
+	default:
+		gb.WriteByte(c)
+	}
+}
+
+// maxPeriod returns the finer of the periods, where zero is unset.
+func maxPeriod(cur, p time.Duration) time.Duration {
+	if cur == 0 || p < cur {
+		return p
+	}
+	return cur
+}
+
+// formatRotateName formats the name for the time. Layout directives are
+// delimited by NUL bytes so that the rest of the pattern is kept literally.
+func formatRotateName(layout string, t time.Time) string {
+	parts := strings.Split(layout, "\x00")
+	for i := 1; i < len(parts); i += 2 {
+		parts[i] = t.Format(parts[i])
+	}
+	return strings.Join(parts, "")
+}
+
+type rotateOutput struct {
+	layout string
+	glob   string
+	period time.Duration
+	retain int
+	stream func(Output) (Output, error)
+	now    func() time.Time
+
+	mu   sync.Mutex
+	out  Output // the current file, possibly wrapped by stream
+	name string
+	next time.Time
+}
+
+func (o *rotateOutput) Write(b []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.out == nil {
+		return 0, os.ErrClosed
+	}
+	if now := o.now(); !now.Before(o.next) {
+		if err := o.rotate(now); err != nil {
+			return 0, err
+		}
+	}
+	return o.out.Write(b)
+}
+
+func (o *rotateOutput) rotate(now time.Time) error {
+	o.next = nextPeriod(now, o.period)
+	name := formatRotateName(o.layout, now)
+	if name == o.name {
+		return nil
+	}
+	out, err := o.open(name)
+	if err != nil {
+		return err
+	}
+	if o.out != nil {
+		o.out.Sync()
+		o.out.Close()
+	}
+	o.out, o.name = out, name
+	if o.retain > 0 {
+		o.prune()
+	}
+	return nil
+}
+
+// open opens the named file and wraps it with the stream, if any.
+func (o *rotateOutput) open(name string) (Output, error) {
+	f, err := openFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if o.stream == nil {
+		return f, nil
+	}
+	return o.stream(f)
+}
+
+// nextPeriod returns the start of the local time period following now.
+func nextPeriod(now time.Time, period time.Duration) time.Time {
+	y, mo, d := now.Date()
+	switch period {
+	case time.Minute:
+		return time.Date(y, mo, d, now.Hour(), now.Minute()+1, 0, 0, now.Location())
+	case time.Hour:
+		return time.Date(y, mo, d, now.Hour()+1, 0, 0, 0, now.Location())
+	default:
+		return time.Date(y, mo, d+1, 0, 0, 0, 0, now.Location())
+	}
+}
+
+func (o *rotateOutput) prune() {
+	names, err := filepath.Glob(o.glob)
+	if err != nil || len(names) <= o.retain {
+		return
+	}
+	type file struct {
+		name    string
+		modTime time.Time
+	}
+	files := make([]file, 0, len(names))
+	for _, name := range names {
+		if name == o.name {
+			continue
+		}
+		if fi, err := os.Stat(name); err == nil && fi.Mode().IsRegular() {
+			files = append(files, file{name, fi.ModTime()})
+		}
+	}
+	sort.Slice(files, func(i, k int) bool { return files[i].modTime.After(files[k].modTime) })
+	if keep := o.retain - 1; len(files) > keep { // the current file is kept
+		for _, f := range files[keep:] {
+			os.Remove(f.name)
+		}
+	}
+}
+
+// Reopen reopens the current file. If it's wrapped by a stream, the stream
+// is ended and a new one is started in the reopened file.
+func (o *rotateOutput) Reopen() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.out == nil {
+		return os.ErrClosed
+	}
+	out, err := o.open(o.name)
+	if err != nil {
+		return err
+	}
+	o.out.Sync()
+	o.out.Close()
+	o.out = out
+	return nil
+}
+
+func (o *rotateOutput) Sync() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.out == nil {
+		return nil
+	}
+	return o.out.Sync()
+}
+
+func (o *rotateOutput) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.out == nil {
+		return nil
+	}
+	err := o.out.Close()
+	o.out = nil
+	return err
+}
@@ -0,0 +1,362 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Events reported by spool outputs.
+const (
+	EventSpooled = "spooled" // writes are being spooled to disk
+	EventEvicted = "evicted" // a spooled segment was discarded
+)
+
+const (
+	spoolPrefix = "spool-"
+	spoolSuffix = ".log"
+)
+
+// SpoolConfig is the configuration of a spool output.
+type SpoolConfig struct {
+	// Name identifies the output to an Observer. The default is "spool".
+	Name string
+
+	// Dir is the directory in which writes are spooled. It's created if it
+	// doesn't exist and it shouldn't be shared by multiple outputs.
+	Dir string
+
+	// MaxSize is the maximum number of bytes spooled to disk, beyond which
+	// the oldest spooled data is evicted. The default is 64MiB.
+	MaxSize int64
+
+	// Retry is the delay between attempts to replay spooled data.
+	// The default is 1s.
+	Retry time.Duration
+}
+
+// Spool returns an Output that writes to out, unless a write to out fails,
+// in which case writes are spooled to files on disk until they're replayed to
+// out in order by a background goroutine. Data spooled before a restart is
+// replayed as well, so data may be written more than once. If the Output is
+// observed, it reports EventSpooled when spooling begins and EventEvicted
+// when data is discarded to stay within the maximum size. Closing it leaves
+// any spooled data on disk and closes out. It implements Reopener by
+// reopening out, if out implements it.
+func Spool(out Output, cfg SpoolConfig) (Output, error) {
+	if cfg.Name == "" {
+		cfg.Name = "spool"
+	}
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = 64 << 20
+	}
+	if cfg.Retry <= 0 {
+		cfg.Retry = time.Second
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("zapr: failed to create spool directory: %w", err)
+	}
+	names, err := filepath.Glob(filepath.Join(cfg.Dir, spoolPrefix+"*"+spoolSuffix))
+	if err != nil {
+		return nil, fmt.Errorf("zapr: failed to read spool directory: %w", err)
+	}
+	sort.Strings(names) // names have fixed-width sequence numbers
+	o := &spoolOutput{
+		out:  out,
+		cfg:  cfg,
+		wake: make(chan struct{}, 1),
+		done: make(chan struct{}),
+	}
+	for _, name := range names {
+		fi, err := os.Stat(name)
+		if err != nil {
+			continue
+		}
+		seq, _ := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(filepath.Base(name), spoolPrefix), spoolSuffix), 16, 64)
+		o.segs = append(o.segs, &spoolSegment{name: name, size: fi.Size()})
+		o.size += fi.Size()
+		o.seq = seq + 1
+	}
+	o.spooling = len(o.segs) > 0
+	o.wg.Add(1)
+	go o.replay()
+	o.signal()
+	return o, nil
+}
+
+type spoolSegment struct {
+	name string
+	size int64    // written bytes
+	off  int64    // replayed bytes
+	w    *os.File // if non-nil, the segment is active
+	r    *os.File
+}
+
+func (s *spoolSegment) close() {
+	if s.w != nil {
+		s.w.Close()
+	}
+	if s.r != nil {
+		s.r.Close()
+	}
+}
+
+type spoolOutput struct {
+	out  Output
+	cfg  SpoolConfig
+	wake chan struct{}
+	done chan struct{} // closed by Close
+	wg   sync.WaitGroup
+
+	mu       sync.Mutex
+	spooling bool
+	segs     []*spoolSegment
+	size     int64 // spooled bytes on disk
+	seq      uint64
+	hdr      [4]byte
+	closed   bool
+	observer Observer
+}
+
+func (o *spoolOutput) Observe(observer Observer) {
+	o.mu.Lock()
+	o.observer = observer
+	o.mu.Unlock()
+	if v, ok := o.out.(Observable); ok {
+		v.Observe(observer)
+	}
+}
+
+// observe reports the event. It must be called with the lock held.
+func (o *spoolOutput) observe(event string) {
+	if o.observer != nil {
+		o.observer.ObserveOutputEvent(o.cfg.Name, event)
+	}
+}
+
+func (o *spoolOutput) signal() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (o *spoolOutput) Reopen() error { return reopen(o.out) }
+
+func (o *spoolOutput) Write(b []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if !o.spooling {
+		if n, err := o.out.Write(b); err == nil {
+			return n, nil
+		}
+		o.spooling = true
+		o.observe(EventSpooled)
+		o.signal()
+	}
+	if err := o.append(b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// append spools the data. It must be called with the lock held.
+func (o *spoolOutput) append(b []byte) error {
+	seg := o.active()
+	if seg == nil || seg.size >= o.segmentSize() {
+		if seg != nil {
+			seg.w.Close()
+			seg.w = nil
+		}
+		name := filepath.Join(o.cfg.Dir, fmt.Sprintf("%s%016x%s", spoolPrefix, o.seq, spoolSuffix))
+		f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_TRUNC, 0o644)
+		if err != nil {
+			return fmt.Errorf("zapr: failed to create spool file: %w", err)
+		}
+		o.seq++
+		seg = &spoolSegment{name: name, w: f}
+		o.segs = append(o.segs, seg)
+	}
+	binary.BigEndian.PutUint32(o.hdr[:], uint32(len(b)))
+	if _, err := seg.w.Write(o.hdr[:]); err != nil {
+		return fmt.Errorf("zapr: failed to write spool file: %w", err)
+	}
+	if _, err := seg.w.Write(b); err != nil {
+		return fmt.Errorf("zapr: failed to write spool file: %w", err)
+	}
+	n := int64(len(o.hdr) + len(b))
+	seg.size += n
+	o.size += n
+	for o.size > o.cfg.MaxSize && len(o.segs) > 1 {
+		o.remove(o.segs[0])
+		o.observe(EventEvicted)
+	}
+	return nil
+}
+
+func (o *spoolOutput) segmentSize() int64 {
+	return o.cfg.MaxSize / 4
+}
+
+// active returns the segment being written, if any.
+// It must be called with the lock held.
+func (o *spoolOutput) active() *spoolSegment {
+	if n := len(o.segs); n > 0 && o.segs[n-1].w != nil {
+		return o.segs[n-1]
+	}
+	return nil
+}
+
+// remove removes the oldest segment. It must be called with the lock held.
+func (o *spoolOutput) remove(seg *spoolSegment) {
+	seg.close()
+	os.Remove(seg.name)
+	o.size -= seg.size
+	o.segs = o.segs[1:]
+}
+
+func (o *spoolOutput) replay() {
+	defer o.wg.Done()
+	for {
+		select {
+		case <-o.wake:
+		case <-o.done:
+			return
+		}
+		for !o.replayAll() {
+			t := time.NewTimer(o.cfg.Retry)
+			select {
+			case <-t.C:
+			case <-o.done:
+				t.Stop()
+				return
+			}
+		}
+	}
+}
+
+// replayAll replays spooled data until there's none left, in which case
+// spooling stops and it returns true, or until a write fails.
+func (o *spoolOutput) replayAll() bool {
+	var buf []byte
+	for {
+		o.mu.Lock()
+		if o.closed {
+			o.mu.Unlock()
+			return true
+		}
+		if len(o.segs) == 0 {
+			o.spooling = false
+			o.mu.Unlock()
+			return true
+		}
+		seg := o.segs[0]
+		if seg.off == seg.size {
+			if seg.w == nil || len(o.segs) == 1 {
+				o.remove(seg)
+			}
+			o.mu.Unlock()
+			continue
+		}
+		var err error
+		buf, err = o.read(seg, buf)
+		o.mu.Unlock()
+		if err != nil {
+			// The segment is corrupt, so discard it.
+			o.mu.Lock()
+			if len(o.segs) > 0 && o.segs[0] == seg {
+				o.remove(seg)
+				o.observe(EventEvicted)
+			}
+			o.mu.Unlock()
+			continue
+		}
+		if _, err := o.out.Write(buf); err != nil {
+			return false
+		}
+		o.mu.Lock()
+		if len(o.segs) > 0 && o.segs[0] == seg { // it may have been evicted
+			seg.off += int64(len(o.hdr) + len(buf))
+		}
+		o.mu.Unlock()
+	}
+}
+
+// read reads the segment's next record. It must be called with the lock held.
+func (o *spoolOutput) read(seg *spoolSegment, buf []byte) ([]byte, error) {
+	if seg.r == nil {
+		f, err := os.Open(seg.name)
+		if err != nil {
+			return nil, err
+		}
+		seg.r = f
+	}
+	var hdr [4]byte
+	if _, err := seg.r.ReadAt(hdr[:], seg.off); err != nil {
+		return nil, err
+	}
+	n := int64(binary.BigEndian.Uint32(hdr[:]))
+	if seg.off+int64(len(hdr))+n > seg.size {
+		return nil, errors.New("zapr: invalid spool record")
+	}
+	if int64(cap(buf)) < n {
+		buf = make([]byte, n)
+	}
+	buf = buf[:n]
+	if _, err := seg.r.ReadAt(buf, seg.off+int64(len(hdr))); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+func (o *spoolOutput) Sync() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return nil
+	}
+	if seg := o.active(); seg != nil {
+		return seg.w.Sync()
+	}
+	if o.spooling {
+		return nil
+	}
+	return o.out.Sync()
+}
+
+func (o *spoolOutput) Close() error {
+	o.mu.Lock()
+	if o.closed {
+		o.mu.Unlock()
+		return nil
+	}
+	o.closed = true
+	close(o.done)
+	o.mu.Unlock()
+
+	o.wg.Wait()
+	o.mu.Lock()
+	for _, seg := range o.segs {
+		seg.close()
+	}
+	o.segs = nil
+	o.mu.Unlock()
+	return o.out.Close()
+}
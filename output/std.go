@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"os"
+	"sync"
+)
+
+var (
+	stderr = newStdOutput(os.Stderr, "stderr")
+	stdout = newStdOutput(os.Stdout, "stdout")
+)
+
+// Stderr returns an Output that writes to stderr.
+// Closing it is a no-op.
+func Stderr() Output { return stderr }
+
+// Stdout returns an Output that writes to stdout.
+// Closing it is a no-op.
+func Stdout() Output { return stdout }
+
+type stdOutput struct {
+	mu   sync.Mutex
+	f    *os.File
+	name string
+	sync bool
+}
+
+func newStdOutput(f *os.File, name string) *stdOutput {
+	// Some platforms and file types (e.g. pipes) don't support syncing.
+	// TODO: errors.Is(syscall.EINVAL)
+	return &stdOutput{
+		f:    f,
+		name: name,
+		sync: f.Sync() == nil,
+	}
+}
+
+func (o *stdOutput) Write(b []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.f.Write(b)
+}
+
+func (o *stdOutput) Sync() error {
+	if !o.sync {
+		return nil
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.f.Sync()
+}
+
+func (o *stdOutput) Close() error   { return nil }
+func (o *stdOutput) String() string { return o.name }
@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"sync/atomic"
+
+	"bursavich.dev/zapr/encoding"
+	"bursavich.dev/zapr/internal/fields"
+	"go.uber.org/zap/zapcore"
+)
+
+// An OutputOption configures an output added by WithOutput.
+type OutputOption func(*outputConfig)
+
+type outputConfig struct {
+	ws      zapcore.WriteSyncer
+	level   int
+	encoder encoding.Encoder
+}
+
+// WithOutputLevel returns an OutputOption that sets the output's verbosity
+// level. The default level is 0.
+func WithOutputLevel(level int) OutputOption {
+	return func(c *outputConfig) { c.level = level }
+}
+
+// WithOutputEncoder returns an OutputOption that sets the output's encoder.
+// By default, it's the logger's encoder.
+func WithOutputEncoder(encoder encoding.Encoder) OutputOption {
+	return func(c *outputConfig) { c.encoder = encoder }
+}
+
+// WithOutput returns an Option that adds an output with its own verbosity
+// level and encoder, which otherwise shares the logger's configuration.
+// For example, JSON may be written to a file at V(4) while console output
+// is written to stderr at V(0):
+//
+//	zapr.NewLogger(
+//		zapr.WithWriteSyncer(output.Stderr()),
+//		zapr.WithEncoder(encoding.ConsoleEncoder()),
+//		zapr.WithOutput(file,
+//			zapr.WithOutputLevel(4),
+//			zapr.WithOutputEncoder(encoding.JSONEncoder()),
+//		),
+//	)
+//
+// Errors are written to every output. Fields aren't sorted, wrapped, or
+// budgeted in added outputs. It may be used multiple times to add several
+// outputs.
+func WithOutput(ws zapcore.WriteSyncer, options ...OutputOption) Option {
+	oc := &outputConfig{ws: ws}
+	for _, fn := range options {
+		fn(oc)
+	}
+	return optionFunc(func(c *config) { c.outputs = append(c.outputs, oc) })
+}
+
+// WithoutOutputs returns an Option that removes the outputs added by
+// preceding uses of WithOutput.
+func WithoutOutputs() Option {
+	return withOutputs(nil)
+}
+
+func withOutputs(outputs []*outputConfig) Option {
+	outputs = append([]*outputConfig(nil), outputs...)
+	return optionFunc(func(c *config) { c.outputs = outputs })
+}
+
+// verbosityField returns a field that's ignored by encoders, which conveys
+// the verbosity level of an entry to verbosityCores and encoders.
+func verbosityField(level int) zapcore.Field {
+	return fields.Verbosity(level)
+}
+
+// verbosityCore discards entries above its verbosity level.
+type verbosityCore struct {
+	zapcore.Core
+	level *atomic.Int64
+}
+
+func (c *verbosityCore) With(fields []zapcore.Field) zapcore.Core {
+	return &verbosityCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c *verbosityCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *verbosityCore) Write(ent zapcore.Entry, fs []zapcore.Field) error {
+	if v, ok := fields.VerbosityOf(fs); ok && int64(v) > c.level.Load() {
+		return nil
+	}
+	return c.Core.Write(ent, fs)
+}
+
+// maxOutputLevel returns the maximum verbosity level of the logger's added
+// outputs, or -1 if there are none.
+func maxOutputLevel(c *config) int {
+	level := -1
+	for _, o := range c.outputs {
+		if o.level > level {
+			level = o.level
+		}
+	}
+	return level
+}
@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	"go.uber.org/zap/zapcore"
+)
+
+// PanicKey is the key of panic values logged by Recover.
+const PanicKey = "panic"
+
+// Panic returns a Value of the recovered panic value, which is encoded as
+// an object with the value's type and the value itself. If the value is an
+// error, its message is encoded along with the types and messages of the
+// errors it wraps.
+//
+//	if r := recover(); r != nil {
+//		log.Error(nil, "Recovered from panic", "panic", zapr.Panic(r))
+//	}
+func Panic(value interface{}) Value {
+	return objectValue{panicValue{value}}
+}
+
+// Recover recovers from a panic, if any, and logs its value as an error with
+// the message. It must be called directly by a deferred function:
+//
+//	defer zapr.Recover(log, "Handler panicked")
+func Recover(log logr.Logger, msg string) {
+	if r := recover(); r != nil {
+		// Skip the runtime's panic frame to report the panicking caller.
+		log.WithCallDepth(2).Error(nil, msg, PanicKey, Panic(r))
+	}
+}
+
+type panicValue struct{ v interface{} }
+
+func (p panicValue) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("type", typeName(p.v))
+	switch v := p.v.(type) {
+	case error:
+		enc.AddString("value", v.Error())
+		if causes := unwrapErrors(v); len(causes) > 0 {
+			return enc.AddArray("causes", errorArray(causes))
+		}
+		return nil
+	case fmt.Stringer:
+		enc.AddString("value", v.String())
+		return nil
+	default:
+		return enc.AddReflected("value", v)
+	}
+}
+
+// unwrapErrors returns the errors wrapped by err, depth-first.
+func unwrapErrors(err error) []error {
+	var errs []error
+	var walk func(error)
+	walk = func(err error) {
+		switch v := err.(type) {
+		case interface{ Unwrap() error }:
+			if e := v.Unwrap(); e != nil {
+				errs = append(errs, e)
+				walk(e)
+			}
+		case interface{ Unwrap() []error }:
+			for _, e := range v.Unwrap() {
+				if e != nil {
+					errs = append(errs, e)
+					walk(e)
+				}
+			}
+		}
+	}
+	walk(err)
+	return errs
+}
+
+type errorArray []error
+
+func (errs errorArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, err := range errs {
+		err := err
+		if err := enc.AppendObject(zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			enc.AddString("type", typeName(err))
+			enc.AddString("message", err.Error())
+			return nil
+		})); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func typeName(v interface{}) string {
+	if v == nil {
+		return "nil"
+	}
+	return reflect.TypeOf(v).String()
+}
@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"bursavich.dev/zapr/encoding"
+)
+
+var (
+	presetsMu sync.RWMutex
+	presets   = map[string][]Option{
+		"production": {
+			WithLevel(0),
+			WithEncoder(encoding.JSONEncoder()),
+			WithTimeEncoder(encoding.ISO8601TimeEncoder()),
+			WithSampler(time.Second, 100, 100),
+		},
+		"development":  {WithDevelopmentOptions(true)},
+		"gke":          {WithGooglePreset()},
+		"datadog":      {WithDatadogPreset()},
+		"low-overhead": {WithLowOverheadProfile()},
+	}
+)
+
+// RegisterPreset registers the Options as a named preset, which may be
+// selected by WithPreset or its flag, such that an organization may define
+// its logging profiles once and select them per deployment. The "production",
+// "development", "gke", "datadog", and "low-overhead" presets are registered
+// by default.
+func RegisterPreset(name string, options ...Option) error {
+	presetsMu.Lock()
+	defer presetsMu.Unlock()
+	if _, ok := presets[name]; ok {
+		return fmt.Errorf("zapr: already registered preset: %q", name)
+	}
+	presets[name] = append([]Option(nil), options...)
+	return nil
+}
+
+// Presets returns the names of the registered presets.
+func Presets() []string {
+	presetsMu.RLock()
+	defer presetsMu.RUnlock()
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func lookupPreset(name string) ([]Option, bool) {
+	presetsMu.RLock()
+	defer presetsMu.RUnlock()
+	options, ok := presets[name]
+	return options, ok
+}
+
+// WithPreset returns an Option that applies the Options of the named preset
+// before all other Options, such that they take precedence. An unknown name
+// is ignored. There's no preset by default.
+//
+// When its flag is set, the preset also changes the values of the other flags
+// in the same FlagSet which still have their default values, such that it
+// works with AllOptions and flags which have been changed take precedence.
+func WithPreset(name string) Option {
+	return opt{
+		applyFn: func(c *config) {
+			options, _ := lookupPreset(name)
+			for _, o := range sortedOptions(options) {
+				o.apply(c)
+			}
+			c.preset = name
+		},
+		registerFn: func(fs *flag.FlagSet) {
+			usage := fmt.Sprintf("Log preset (e.g. %s).", listNames(Presets()))
+			fs.Var(&presetFlag{fs: fs, name: &name}, "log-preset", usage)
+		},
+		wgt: 2,
+	}
+}
+
+type presetFlag struct {
+	fs   *flag.FlagSet
+	name *string
+}
+
+func (f *presetFlag) Get() interface{} { return *f.name }
+func (f *presetFlag) String() string {
+	if f.name == nil {
+		return ""
+	}
+	return *f.name
+}
+
+func (f *presetFlag) Set(s string) error {
+	options, ok := lookupPreset(s)
+	if !ok {
+		return fmt.Errorf("zapr: unknown preset: %q", s)
+	}
+	// Find the flag values which differ from the defaults due to the preset.
+	values := func(options ...Option) map[string]string {
+		fs := flag.NewFlagSet("", flag.ContinueOnError)
+		for _, o := range AllOptions(options...) {
+			o.register(fs)
+		}
+		m := make(map[string]string)
+		fs.VisitAll(func(fl *flag.Flag) { m[fl.Name] = fl.Value.String() })
+		return m
+	}
+	defaults, preset := values(), values(options...)
+	var err error
+	f.fs.VisitAll(func(fl *flag.Flag) {
+		v, ok := preset[fl.Name]
+		if !ok || err != nil || v == defaults[fl.Name] || !strings.HasPrefix(fl.Name, "log-") {
+			return
+		}
+		if fl.Value.String() != fl.DefValue {
+			return // changed
+		}
+		if err = fl.Value.Set(v); err != nil {
+			err = fmt.Errorf("zapr: invalid preset: %q: %s: %w", s, fl.Name, err)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	*f.name = s
+	return nil
+}
@@ -0,0 +1,200 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// redactedValue replaces the values of redacted keys.
+const redactedValue = "[REDACTED]"
+
+// keyMatcher matches keys against glob and regexp patterns.
+// Results are cached, since keys repeat.
+type keyMatcher struct {
+	globs    []string
+	regexps  []*regexp.Regexp
+	literals []string
+
+	mu    sync.RWMutex
+	cache map[string]bool
+}
+
+// newKeyMatcher returns a keyMatcher for the patterns. A pattern enclosed in
+// slashes is a regexp (e.g. "/^x-.*-key$/") and any other pattern is a glob
+// as described by path.Match (e.g. "*_token"). Globs are matched regardless
+// of case. Invalid patterns match keys which equal them, regardless of case.
+func newKeyMatcher(patterns []string) *keyMatcher {
+	if len(patterns) == 0 {
+		return nil
+	}
+	m := &keyMatcher{cache: make(map[string]bool)}
+	for _, p := range patterns {
+		if re, ok := keyRegexp(p); ok {
+			if re != nil {
+				m.regexps = append(m.regexps, re)
+				continue
+			}
+		} else if _, err := path.Match(strings.ToLower(p), ""); err == nil {
+			m.globs = append(m.globs, strings.ToLower(p))
+			continue
+		}
+		m.literals = append(m.literals, strings.ToLower(p))
+	}
+	return m
+}
+
+// keyRegexp returns the regexp of a pattern enclosed in slashes, which is nil
+// if it's invalid, and whether the pattern is enclosed in slashes.
+func keyRegexp(pattern string) (*regexp.Regexp, bool) {
+	if len(pattern) < 2 || pattern[0] != '/' || pattern[len(pattern)-1] != '/' {
+		return nil, false
+	}
+	re, _ := regexp.Compile(pattern[1 : len(pattern)-1])
+	return re, true
+}
+
+// invalidKeyPatterns returns the patterns which are invalid.
+func invalidKeyPatterns(patterns []string) []string {
+	var invalid []string
+	for _, p := range patterns {
+		if re, ok := keyRegexp(p); ok && re == nil {
+			invalid = append(invalid, p)
+		} else if _, err := path.Match(p, ""); !ok && err != nil {
+			invalid = append(invalid, p)
+		}
+	}
+	return invalid
+}
+
+func (m *keyMatcher) match(key string) bool {
+	m.mu.RLock()
+	ok, cached := m.cache[key]
+	m.mu.RUnlock()
+	if cached {
+		return ok
+	}
+	ok = m.matchUncached(key)
+	m.mu.Lock()
+	if len(m.cache) >= maxInterned {
+		m.cache = make(map[string]bool)
+	}
+	m.cache[key] = ok
+	m.mu.Unlock()
+	return ok
+}
+
+func (m *keyMatcher) matchUncached(key string) bool {
+	lower := strings.ToLower(key)
+	for _, g := range m.globs {
+		if ok, _ := path.Match(g, lower); ok {
+			return true
+		}
+	}
+	for _, re := range m.regexps {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	for _, l := range m.literals {
+		if l == lower {
+			return true
+		}
+	}
+	return false
+}
+
+// redactFields returns the fields with the values of matching keys redacted,
+// including the fields of nested and inlined objects and reflected values when
+// they're encoded. The given slice isn't modified.
+func (m *keyMatcher) redactFields(fs []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, f := range fs {
+		changed := true
+		switch {
+		case f.Type == zapcore.NamespaceType || f.Type == zapcore.SkipType:
+			changed = false // Not a value.
+		case f.Key != "" && m.match(f.Key):
+			f = zap.String(f.Key, redactedValue)
+		case f.Type == zapcore.ReflectType:
+			f, changed = m.reflectedField(f)
+		case f.Type == zapcore.ObjectMarshalerType || f.Type == zapcore.ArrayMarshalerType:
+			if lv, ok := f.Interface.(limitedValue); ok {
+				// Redact the keys of a reflected value as it's walked.
+				lv.keys = m
+				f.Interface = lv
+			} else if f.Type == zapcore.ObjectMarshalerType {
+				f.Interface = mappedObject{m: f.Interface.(zapcore.ObjectMarshaler), fn: m.redactFields}
+			} else {
+				changed = false
+			}
+		case f.Type == zapcore.InlineMarshalerType:
+			f.Interface = mappedObject{m: f.Interface.(zapcore.ObjectMarshaler), fn: m.redactFields}
+		default:
+			changed = false
+		}
+		if changed && out == nil {
+			out = append(make([]zapcore.Field, 0, len(fs)), fs[:i]...)
+		}
+		if out != nil {
+			out = append(out, f)
+		}
+	}
+	if out == nil {
+		return fs
+	}
+	return out
+}
+
+// reflectedField returns a Field which encodes the reflected struct, map,
+// slice, or array value like encoding/json, but with the values of matching
+// keys redacted, and true, or the field unmodified and false.
+func (m *keyMatcher) reflectedField(f zapcore.Field) (zapcore.Field, bool) {
+	v := indirect(reflect.ValueOf(f.Interface))
+	if !v.IsValid() || isMarshaler(v) {
+		return f, false
+	}
+	lv := limitedValue{lim: &reflectLimits{}, keys: m, v: v}
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map:
+		return zap.Object(f.Key, lv), true
+	case reflect.Slice, reflect.Array:
+		if !isBytes(v) {
+			return zap.Array(f.Key, lv), true
+		}
+	}
+	return f, false
+}
+
+// redactCore redacts the values of matching keys before they're encoded
+// by any output.
+type redactCore struct {
+	zapcore.Core
+	keys *keyMatcher
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.keys.redactFields(fields)), keys: c.keys}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, c.keys.redactFields(fields))
+}
@@ -0,0 +1,344 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// truncatedValue replaces the values which exceed the reflect limits.
+const truncatedValue = "<truncated>"
+
+// reflectLimits limits the nesting depth and encoded size of values which
+// would otherwise be encoded by reflection. Zero means there's no limit.
+type reflectLimits struct {
+	depth int
+	size  int
+}
+
+func newReflectLimits(depth, size int) *reflectLimits {
+	if depth <= 0 && size <= 0 {
+		return nil
+	}
+	return &reflectLimits{depth: depth, size: size}
+}
+
+// field returns a Field which encodes the value within the limits, like
+// encoding/json but without reflection by the encoder. Values which are
+// json.Marshalers or encoding.TextMarshalers are encoded as a whole.
+func (l *reflectLimits) field(key string, val interface{}) zapcore.Field {
+	v := indirect(reflect.ValueOf(val))
+	if !v.IsValid() || isMarshaler(v) {
+		return zap.Reflect(key, val)
+	}
+	lv := limitedValue{lim: l, v: v}
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map:
+		return zap.Object(key, lv)
+	case reflect.Slice, reflect.Array:
+		if isBytes(v) {
+			return zap.Reflect(key, val)
+		}
+		return zap.Array(key, lv)
+	}
+	return zap.Reflect(key, val)
+}
+
+// limitedValue is the top-level value of a field. Each time it's encoded,
+// it starts with a new size budget.
+// If keys is non-nil, the values of matching keys are redacted.
+type limitedValue struct {
+	lim  *reflectLimits
+	keys *keyMatcher
+	v    reflect.Value
+}
+
+func (lv limitedValue) walker() *limitWalker {
+	return &limitWalker{depth: lv.lim.depth, size: lv.lim.size, budget: lv.lim.size, keys: lv.keys}
+}
+
+func (lv limitedValue) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return limitedContainer{w: lv.walker(), v: lv.v}.MarshalLogObject(enc)
+}
+
+func (lv limitedValue) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	return limitedContainer{w: lv.walker(), v: lv.v}.MarshalLogArray(enc)
+}
+
+type limitWalker struct {
+	depth  int
+	size   int
+	budget int // remaining bytes, if size > 0
+	keys   *keyMatcher
+}
+
+// charge deducts n bytes from the budget and returns whether they fit.
+func (w *limitWalker) charge(n int) bool {
+	if w.size <= 0 {
+		return true
+	}
+	if w.budget < n {
+		w.budget = 0
+		return false
+	}
+	w.budget -= n
+	return true
+}
+
+func (w *limitWalker) exhausted() bool { return w.size > 0 && w.budget <= 0 }
+
+// limitedContainer is a struct, map, slice, or array at nesting depth d.
+type limitedContainer struct {
+	w *limitWalker
+	v reflect.Value
+	d int
+}
+
+func (c limitedContainer) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	add := func(key string, v reflect.Value) bool {
+		if c.w.exhausted() || !c.w.charge(len(key)+4) {
+			enc.AddBool("_truncated", true)
+			return false
+		}
+		if c.w.keys != nil && c.w.keys.match(key) {
+			c.w.charge(len(redactedValue) + 2)
+			enc.AddString(key, redactedValue)
+			return true
+		}
+		c.w.value(key, v, c.d+1).AddTo(enc)
+		return true
+	}
+	if c.v.Kind() == reflect.Map {
+		keys := c.v.MapKeys()
+		names := make([]string, len(keys))
+		for i, k := range keys {
+			names[i] = mapKeyName(k)
+		}
+		sort.Sort(mapKeys{keys, names})
+		for i, k := range keys {
+			if !add(names[i], c.v.MapIndex(k)) {
+				break
+			}
+		}
+		return nil
+	}
+	structFields(c.v, add)
+	return nil
+}
+
+func (c limitedContainer) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for i := 0; i < c.v.Len(); i++ {
+		if c.w.exhausted() || !c.w.charge(1) {
+			enc.AppendString(truncatedValue)
+			break
+		}
+		appendField(enc, c.w.value("", c.v.Index(i), c.d+1))
+	}
+	return nil
+}
+
+// value returns a Field for the value at nesting depth d.
+func (w *limitWalker) value(key string, v reflect.Value, d int) zapcore.Field {
+	v = indirect(v)
+	if !v.IsValid() {
+		w.charge(4)
+		return zap.Reflect(key, nil)
+	}
+	if isMarshaler(v) {
+		b, err := json.Marshal(v.Interface())
+		if err != nil {
+			return zap.String(key, fmt.Sprintf("<error: %v>", err))
+		}
+		if !w.charge(len(b)) {
+			return zap.String(key, truncatedValue)
+		}
+		return zap.Reflect(key, json.RawMessage(b))
+	}
+	if (v.Kind() == reflect.Map || v.Kind() == reflect.Slice) && v.IsNil() {
+		w.charge(4)
+		return zap.Reflect(key, nil)
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		w.charge(5)
+		return zap.Bool(key, v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		w.charge(8)
+		return zap.Int64(key, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		w.charge(8)
+		return zap.Uint64(key, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		w.charge(8)
+		return zap.Float64(key, v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		w.charge(16)
+		return zap.Complex128(key, v.Complex())
+	case reflect.String:
+		return zap.String(key, w.truncate(v.String()))
+	case reflect.Struct, reflect.Map:
+		if d >= w.depth && w.depth > 0 {
+			return zap.String(key, truncatedValue)
+		}
+		return zap.Object(key, limitedContainer{w: w, v: v, d: d})
+	case reflect.Slice, reflect.Array:
+		if isBytes(v) {
+			b := v.Bytes()
+			if !w.charge(len(b) * 4 / 3) {
+				return zap.String(key, truncatedValue)
+			}
+			return zap.Binary(key, b)
+		}
+		if d >= w.depth && w.depth > 0 {
+			return zap.String(key, truncatedValue)
+		}
+		return zap.Array(key, limitedContainer{w: w, v: v, d: d})
+	}
+	return zap.String(key, fmt.Sprintf("<%s>", v.Type()))
+}
+
+// truncate returns the string, truncated to fit the remaining budget.
+func (w *limitWalker) truncate(s string) string {
+	n := w.budget - 2
+	if w.charge(len(s) + 2) {
+		return s
+	}
+	if n < 0 {
+		n = 0
+	}
+	for n > 0 && n < len(s) && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "…"
+}
+
+// structFields calls fn with the exported fields of the struct, named and
+// omitted like encoding/json, until it returns false. Embedded structs
+// without names are flattened.
+func structFields(v reflect.Value, fn func(string, reflect.Value) bool) bool {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fv := v.Field(i)
+		if sf.Anonymous && name == "" {
+			if ev := indirect(fv); ev.IsValid() && ev.Kind() == reflect.Struct && !isMarshaler(ev) {
+				if !structFields(ev, fn) {
+					return false
+				}
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		if strings.Contains(","+opts+",", ",omitempty,") && fv.IsZero() {
+			continue
+		}
+		if !fn(name, fv) {
+			return false
+		}
+	}
+	return true
+}
+
+// indirect dereferences pointers and interfaces until reaching a value which
+// isn't one or is a marshaler. It returns the zero Value for nil.
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		if v.Kind() == reflect.Pointer && isMarshaler(v) {
+			return v
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+func isMarshaler(v reflect.Value) bool {
+	t := v.Type()
+	return v.CanInterface() && (t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType))
+}
+
+func isBytes(v reflect.Value) bool {
+	return v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8
+}
+
+func mapKeyName(k reflect.Value) string {
+	if k.Kind() == reflect.String {
+		return k.String()
+	}
+	if !k.CanInterface() {
+		return fmt.Sprint(k)
+	}
+	if m, ok := k.Interface().(encoding.TextMarshaler); ok {
+		if b, err := m.MarshalText(); err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprint(k)
+}
+
+type mapKeys struct {
+	keys  []reflect.Value
+	names []string
+}
+
+func (m mapKeys) Len() int           { return len(m.keys) }
+func (m mapKeys) Less(i, k int) bool { return m.names[i] < m.names[k] }
+func (m mapKeys) Swap(i, k int) {
+	m.keys[i], m.keys[k] = m.keys[k], m.keys[i]
+	m.names[i], m.names[k] = m.names[k], m.names[i]
+}
+
+// appendField appends the value of a Field returned by limitWalker.value.
+func appendField(enc zapcore.ArrayEncoder, f zapcore.Field) {
+	switch f.Type {
+	case zapcore.BoolType:
+		enc.AppendBool(f.Integer == 1)
+	case zapcore.Int64Type:
+		enc.AppendInt64(f.Integer)
+	case zapcore.Uint64Type:
+		enc.AppendUint64(uint64(f.Integer))
+	case zapcore.StringType:
+		enc.AppendString(f.String)
+	case zapcore.ObjectMarshalerType:
+		enc.AppendObject(f.Interface.(zapcore.ObjectMarshaler))
+	case zapcore.ArrayMarshalerType:
+		enc.AppendArray(f.Interface.(zapcore.ArrayMarshaler))
+	case zapcore.Float64Type:
+		enc.AppendFloat64(math.Float64frombits(uint64(f.Integer)))
+	case zapcore.Complex128Type:
+		enc.AppendComplex128(f.Interface.(complex128))
+	default: // binary or reflected
+		enc.AppendReflected(f.Interface)
+	}
+}
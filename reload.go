@@ -0,0 +1,312 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// ReadConfigFile reads a FileConfig from the named JSON file.
+// Unknown fields are errors.
+func ReadConfigFile(name string) (*FileConfig, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("zapr: failed to read config file: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	var cfg FileConfig
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("zapr: invalid config file: %q: %w", name, err)
+	}
+	return &cfg, nil
+}
+
+// nameLevels maps logger names to levels. A name's level is that of its
+// longest dot-separated prefix in the map (e.g. "app.db" applies to "app.db.sql").
+type nameLevels map[string]int
+
+func (m nameLevels) level(name string) int {
+	for {
+		if level, ok := m[name]; ok {
+			return level
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			return -1
+		}
+		name = name[:i]
+	}
+}
+
+func copyLevels(m map[string]int) nameLevels {
+	if len(m) == 0 {
+		return nil
+	}
+	c := make(nameLevels, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// A ConfigWatcher polls a config file and applies changes to the level,
+// sampling, and name levels of the loggers created with its Options,
+// without recreating them. Other changes take effect when loggers are
+// created.
+type ConfigWatcher struct {
+	name     string
+	interval time.Duration
+	opts     []Option
+	done     chan struct{}
+	wg       sync.WaitGroup
+	levels   atomic.Pointer[nameLevels]
+
+	mu      sync.Mutex
+	cfg     *FileConfig
+	modTime time.Time
+	size    int64
+	loggers []*reloadable
+	err     error
+	closed  bool
+}
+
+type reloadable struct {
+	level      *atomic.Int64
+	initLevel  int
+	core       zapcore.Core // unsampled root
+	sampler    *atomic.Pointer[samplerState]
+	initSample *SamplerConfig
+	sampleOpts []zapcore.SamplerOption
+}
+
+// WatchConfigFile reads the named JSON config file and polls it for changes
+// at the given interval (default 5s). Zero values in the file are ignored,
+// leaving the loggers' initial values in place. If the file is changed to
+// an invalid config, it's ignored and the error is reported by Err.
+func WatchConfigFile(name string, interval time.Duration) (*ConfigWatcher, error) {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	fi, err := os.Stat(name)
+	if err != nil {
+		return nil, fmt.Errorf("zapr: failed to read config file: %w", err)
+	}
+	cfg, err := ReadConfigFile(name)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := OptionsFromFileConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	w := &ConfigWatcher{
+		name:     name,
+		interval: interval,
+		done:     make(chan struct{}),
+		cfg:      cfg,
+		modTime:  fi.ModTime(),
+		size:     fi.Size(),
+	}
+	levels := copyLevels(cfg.NameLevels)
+	w.levels.Store(&levels)
+	w.opts = append(opts, withConfigWatcher(w), WithLevelFunc(w.nameLevel))
+	w.wg.Add(1)
+	go w.run()
+	return w, nil
+}
+
+// Options returns the Options described by the config file, which connect
+// loggers to the watcher.
+func (w *ConfigWatcher) Options() []Option {
+	return w.opts[:len(w.opts):len(w.opts)]
+}
+
+// Err returns the error of the last attempt to reload the file, if any.
+func (w *ConfigWatcher) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Close stops watching the file.
+func (w *ConfigWatcher) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.done)
+	w.mu.Unlock()
+	w.wg.Wait()
+	return nil
+}
+
+func withConfigWatcher(w *ConfigWatcher) Option {
+	return optionFunc(func(c *config) { c.watcher = w })
+}
+
+func (w *ConfigWatcher) nameLevel(name string) int {
+	return w.levels.Load().level(name)
+}
+
+func (w *ConfigWatcher) run() {
+	defer w.wg.Done()
+	t := time.NewTicker(w.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			w.poll()
+		case <-w.done:
+			return
+		}
+	}
+}
+
+func (w *ConfigWatcher) poll() {
+	fi, err := os.Stat(w.name)
+	if err != nil {
+		w.setErr(fmt.Errorf("zapr: failed to read config file: %w", err))
+		return
+	}
+	w.mu.Lock()
+	changed := !fi.ModTime().Equal(w.modTime) || fi.Size() != w.size
+	w.mu.Unlock()
+	if !changed {
+		return
+	}
+	cfg, err := ReadConfigFile(w.name)
+	if err != nil {
+		w.setErr(err)
+		return
+	}
+	if cfg.VModule != "" {
+		if _, err := ParseVModule(cfg.VModule); err != nil {
+			w.setErr(err)
+			return
+		}
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	// An invalid file is read again, since it may have been partially written.
+	w.modTime, w.size = fi.ModTime(), fi.Size()
+	w.cfg, w.err = cfg, nil
+	levels := copyLevels(cfg.NameLevels)
+	w.levels.Store(&levels)
+	for _, r := range w.loggers {
+		w.apply(r)
+	}
+}
+
+func (w *ConfigWatcher) setErr(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.err = err
+}
+
+// register connects a logger's level and core to the watcher and returns
+// the core with reloadable sampling.
+func (w *ConfigWatcher) register(c *config, core zapcore.Core) zapcore.Core {
+	r := &reloadable{
+		level:      c.levelVar,
+		initLevel:  c.level,
+		core:       core,
+		sampler:    new(atomic.Pointer[samplerState]),
+		sampleOpts: c.sampleOpts,
+	}
+	if c.sampleFirst != 0 || c.sampleThereafter != 0 {
+		r.initSample = &SamplerConfig{Tick: c.sampleTick, First: c.sampleFirst, Thereafter: c.sampleThereafter}
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.apply(r)
+	w.loggers = append(w.loggers, r)
+	return &reloadCore{Core: core, sampler: r.sampler}
+}
+
+// apply applies the current config to the logger.
+// It must be called with the lock held.
+func (w *ConfigWatcher) apply(r *reloadable) {
+	level := r.initLevel
+	if w.cfg.Level != 0 {
+		level = w.cfg.Level
+	}
+	r.level.Store(int64(level))
+
+	s := r.initSample
+	if w.cfg.Sampler != nil {
+		s = w.cfg.Sampler
+	}
+	cur := r.sampler.Load()
+	switch {
+	case s == nil || (s.First == 0 && s.Thereafter == 0):
+		if cur == nil || cur.core != nil {
+			r.sampler.Store(&samplerState{})
+		}
+	case cur == nil || cur.cfg != *s:
+		r.sampler.Store(&samplerState{
+			cfg:  *s,
+			core: zapcore.NewSamplerWithOptions(r.core, s.Tick, s.First, s.Thereafter, r.sampleOpts...),
+		})
+	}
+}
+
+// samplerState is a generation of a reloadable sampler.
+type samplerState struct {
+	cfg  SamplerConfig
+	core zapcore.Core // sampled root, or nil if sampling is disabled
+}
+
+// reloadCore samples entries with the current generation of its sampler.
+type reloadCore struct {
+	zapcore.Core // unsampled, with fields
+	fields       []zapcore.Field
+	sampler      *atomic.Pointer[samplerState]
+	cache        atomic.Pointer[sampledCore]
+}
+
+// sampledCore is a generation's sampled root with the core's fields.
+type sampledCore struct {
+	state *samplerState
+	core  zapcore.Core
+}
+
+func (c *reloadCore) With(fields []zapcore.Field) zapcore.Core {
+	return &reloadCore{
+		Core:    c.Core.With(fields),
+		fields:  append(c.fields[:len(c.fields):len(c.fields)], fields...),
+		sampler: c.sampler,
+	}
+}
+
+func (c *reloadCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	state := c.sampler.Load()
+	if state.core == nil {
+		return c.Core.Check(ent, ce)
+	}
+	sc := c.cache.Load()
+	if sc == nil || sc.state != state {
+		core := state.core
+		if len(c.fields) > 0 {
+			core = core.With(c.fields)
+		}
+		sc = &sampledCore{state: state, core: core}
+		c.cache.Store(sc)
+	}
+	return sc.core.Check(ent, ce)
+}
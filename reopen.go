@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"os"
+	"os/signal"
+
+	"bursavich.dev/zapr/output"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// A Reopener is a LogSink which may reopen its underlying output.
+// The LogSinks returned by NewLogSink, NewLazyLogSink, and TeeLogSink
+// implement it.
+type Reopener interface {
+	LogSink
+
+	// Reopen reopens the underlying output, if it's an output.Reopener,
+	// such as a file which has been moved by logrotate.
+	Reopen() error
+}
+
+// reopen reopens the writer, if it implements output.Reopener.
+func reopen(ws zapcore.WriteSyncer) error {
+	if r, ok := ws.(output.Reopener); ok {
+		return r.Reopen()
+	}
+	return nil
+}
+
+// handleReopenSignals reopens the writer whenever the process receives
+// a reopen signal, for the lifetime of the process.
+func handleReopenSignals(ws zapcore.WriteSyncer, logger *zap.Logger) {
+	if len(reopenSignals) == 0 {
+		return
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, reopenSignals...)
+	go func() {
+		for range ch {
+			if err := reopen(ws); err != nil {
+				logger.Error("Failed to reopen output", zap.Error(err))
+			}
+		}
+	}()
+}
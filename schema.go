@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// A FieldType is the JSON type of a declared field.
+type FieldType string
+
+// FieldTypes of declared fields.
+const (
+	AnyType    FieldType = ""
+	StringType FieldType = "string"
+	IntType    FieldType = "integer"
+	FloatType  FieldType = "number"
+	BoolType   FieldType = "boolean"
+	ObjectType FieldType = "object"
+	ArrayType  FieldType = "array"
+)
+
+// A FieldDeclaration declares the type of a field key.
+type FieldDeclaration struct {
+	Key  string
+	Type FieldType
+}
+
+var declared = struct {
+	sync.RWMutex
+	m map[string]FieldType
+}{m: make(map[string]FieldType)}
+
+// DeclareField declares the key and type of a field emitted by the application,
+// such that it's described by the JSON schema and allowed in strict mode.
+// It returns an error if the key is already declared with a different type.
+//
+//	zapr.DeclareField("http.status", zapr.IntType)
+func DeclareField(key string, typ FieldType) error {
+	declared.Lock()
+	defer declared.Unlock()
+	if t, ok := declared.m[key]; ok && t != typ {
+		return fmt.Errorf("zapr: already declared field %q with type %q", key, t)
+	}
+	declared.m[key] = typ
+	return nil
+}
+
+// DeclaredFields returns the declared fields.
+func DeclaredFields() []FieldDeclaration {
+	declared.RLock()
+	defer declared.RUnlock()
+	s := make([]FieldDeclaration, 0, len(declared.m))
+	for key, typ := range declared.m {
+		s = append(s, FieldDeclaration{Key: key, Type: typ})
+	}
+	sort.Slice(s, func(i, k int) bool { return s[i].Key < s[k].Key })
+	return s
+}
+
+func isDeclaredField(key string) bool {
+	declared.RLock()
+	defer declared.RUnlock()
+	_, ok := declared.m[key]
+	return ok
+}
+
+// JSONSchema returns a JSON schema of the entries logged with the given
+// Options, including the entry keys and the declared fields, which are
+// renamed as configured. It assumes the JSON encoder.
+func JSONSchema(options ...Option) ([]byte, error) {
+	c := configWithOptions(options)
+	props := make(map[string]interface{})
+	set := func(key string, typ FieldType) {
+		if key == "" {
+			return
+		}
+		if typ == AnyType {
+			props[key] = map[string]interface{}{}
+			return
+		}
+		props[key] = map[string]interface{}{"type": typ}
+	}
+	for _, f := range DeclaredFields() {
+		if k, ok := c.keyRenames[f.Key]; ok {
+			f.Key = k
+		}
+		set(f.Key, f.Type)
+	}
+	switch c.timeEncoder.Name() {
+	case "millis", "nanos", "secs":
+		set(c.timeKey, FloatType)
+	default:
+		set(c.timeKey, StringType)
+	}
+	for _, key := range []string{
+		c.levelKey, c.nameKey, c.functionKey, c.messageKey,
+		c.errorKey, c.errorKindKey, c.verboseKey, c.stacktraceKey, c.sourceKey, c.originKey,
+	} {
+		set(key, StringType)
+	}
+	if c.enableCaller {
+		if c.callerEncoder.Name() == "google" {
+			set(c.callerKey, ObjectType)
+		} else {
+			set(c.callerKey, StringType)
+		}
+	}
+	set(c.causesKey, ArrayType)
+	set(c.goroutineKey, IntType)
+	set(c.sequenceKey, IntType)
+
+	var required []string
+	for _, key := range []string{c.timeKey, c.levelKey, c.messageKey} {
+		if key != "" {
+			required = append(required, key)
+		}
+	}
+	return json.MarshalIndent(map[string]interface{}{
+		"$schema":    "https://json-schema.org/draft/2020-12/schema",
+		"type":       "object",
+		"properties": props,
+		"required":   required,
+	}, "", "  ")
+}
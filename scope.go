@@ -0,0 +1,42 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// ScopeKey is the key of the scope ID added by WithScope.
+const ScopeKey = "scope"
+
+// ScopeEndVerbosity is the verbosity at which the end of a scope is logged.
+const ScopeEndVerbosity = 1
+
+var scopeID atomic.Uint64
+
+// WithScope returns a Logger with the keys and values and a unique scope ID,
+// for tracing a multi-step operation in logs, and a function that ends the
+// scope. Ending the scope logs "Scope ended" with the scope's duration, if
+// ScopeEndVerbosity is enabled. Subsequent calls to the function do nothing.
+//
+//	log, end := zapr.WithScope(log, "job", name)
+//	defer end()
+func WithScope(log logr.Logger, keysAndValues ...interface{}) (logr.Logger, func()) {
+	id := strconv.FormatUint(scopeID.Add(1), 16)
+	log = log.WithValues(append(keysAndValues[:len(keysAndValues):len(keysAndValues)], ScopeKey, id)...)
+	start := time.Now()
+	var ended atomic.Bool
+	return log, func() {
+		if ended.CompareAndSwap(false, true) {
+			log.WithCallDepth(1).V(ScopeEndVerbosity).Info("Scope ended", DurationKey, time.Since(start))
+		}
+	}
+}
@@ -0,0 +1,205 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"fmt"
+	"regexp"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// A ScrubRule masks the matches of a regexp in string values.
+type ScrubRule struct {
+	// Name identifies the rule in the Scrubber's counts.
+	Name string
+
+	// Regexp matches the text to be masked.
+	Regexp *regexp.Regexp
+
+	// Valid reports whether a match should be masked, if it's set,
+	// such that false positives of the Regexp may be excluded.
+	Valid func(match string) bool
+
+	// Replacement replaces each match. The default is "[SCRUBBED]".
+	Replacement string
+}
+
+// Predefined ScrubRules.
+var (
+	// EmailScrubRule masks email addresses.
+	EmailScrubRule = ScrubRule{
+		Name:   "email",
+		Regexp: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+	}
+
+	// CreditCardScrubRule masks credit card numbers, which may be separated
+	// by spaces or dashes and must pass the Luhn checksum.
+	CreditCardScrubRule = ScrubRule{
+		Name:   "credit-card",
+		Regexp: regexp.MustCompile(`\b\d(?:[ \-]?\d){12,18}\b`),
+		Valid:  luhn,
+	}
+
+	// BearerTokenScrubRule masks bearer tokens (e.g. of Authorization headers).
+	BearerTokenScrubRule = ScrubRule{
+		Name:        "bearer-token",
+		Regexp:      regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`),
+		Replacement: "Bearer [SCRUBBED]",
+	}
+)
+
+// A Scrubber masks the matches of its rules in the string values of fields
+// and, optionally, the messages of entries, before they're encoded by any
+// output. It counts the matches of each rule. Values encoded by reflection
+// aren't scrubbed.
+type Scrubber struct {
+	rules   []ScrubRule
+	message bool
+	counts  []atomic.Uint64
+}
+
+// NewScrubber returns a new Scrubber with the rules, which also scrubs the
+// messages of entries if message is true.
+func NewScrubber(message bool, rules ...ScrubRule) *Scrubber {
+	rules = append([]ScrubRule(nil), rules...)
+	for i := range rules {
+		if rules[i].Replacement == "" {
+			rules[i].Replacement = "[SCRUBBED]"
+		}
+	}
+	return &Scrubber{
+		rules:   rules,
+		message: message,
+		counts:  make([]atomic.Uint64, len(rules)),
+	}
+}
+
+// Counts returns the number of matches masked by each rule, keyed by name.
+func (s *Scrubber) Counts() map[string]uint64 {
+	m := make(map[string]uint64, len(s.rules))
+	for i, r := range s.rules {
+		m[r.Name] += s.counts[i].Load()
+	}
+	return m
+}
+
+// scrub returns the string with the matches of the rules masked.
+func (s *Scrubber) scrub(v string) string {
+	for i, r := range s.rules {
+		if !r.Regexp.MatchString(v) {
+			continue
+		}
+		v = r.Regexp.ReplaceAllStringFunc(v, func(match string) string {
+			if r.Valid != nil && !r.Valid(match) {
+				return match
+			}
+			s.counts[i].Add(1)
+			return r.Replacement
+		})
+	}
+	return v
+}
+
+// scrubFields returns the fields with their string values scrubbed,
+// including the fields of nested and inlined objects when they're encoded.
+// The given slice isn't modified.
+func (s *Scrubber) scrubFields(fs []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, f := range fs {
+		var v string
+		switch f.Type {
+		case zapcore.StringType:
+			v = f.String
+		case zapcore.ByteStringType:
+			v = string(f.Interface.([]byte))
+		case zapcore.StringerType:
+			v = stringerValue(f.Interface.(fmt.Stringer))
+		case zapcore.ErrorType:
+			if err, ok := f.Interface.(error); ok && err != nil {
+				v = err.Error()
+			}
+		case zapcore.ObjectMarshalerType, zapcore.InlineMarshalerType:
+			f.Interface = mappedObject{m: f.Interface.(zapcore.ObjectMarshaler), fn: s.scrubFields}
+			if out == nil {
+				out = append(make([]zapcore.Field, 0, len(fs)), fs[:i]...)
+			}
+			out = append(out, f)
+			continue
+		}
+		if sv := s.scrub(v); sv != v {
+			f = zap.String(f.Key, sv)
+			if out == nil {
+				out = append(make([]zapcore.Field, 0, len(fs)), fs[:i]...)
+			}
+		}
+		if out != nil {
+			out = append(out, f)
+		}
+	}
+	if out == nil {
+		return fs
+	}
+	return out
+}
+
+// stringerValue returns the string of the Stringer, like zap,
+// which renders a panic in its place.
+func stringerValue(v fmt.Stringer) (s string) {
+	defer func() {
+		if r := recover(); r != nil {
+			s = fmt.Sprintf("PANIC=%v", r)
+		}
+	}()
+	return v.String()
+}
+
+// scrubCore scrubs fields and messages before they're encoded by any output.
+type scrubCore struct {
+	zapcore.Core
+	s *Scrubber
+}
+
+func (c *scrubCore) With(fields []zapcore.Field) zapcore.Core {
+	return &scrubCore{Core: c.Core.With(c.s.scrubFields(fields)), s: c.s}
+}
+
+func (c *scrubCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *scrubCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if c.s.message {
+		ent.Message = c.s.scrub(ent.Message)
+	}
+	return c.Core.Write(ent, c.s.scrubFields(fields))
+}
+
+// luhn returns true if the digits of the string pass the Luhn checksum.
+func luhn(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n > 0 && sum%10 == 0
+}
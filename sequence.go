@@ -0,0 +1,23 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// sequenceField numbers entries in the order they're written,
+// starting at one, across all descendants of a LogSink.
+func sequenceField(key string) extraField {
+	var seq atomic.Uint64
+	return func(zapcore.Entry) (zapcore.Field, bool) {
+		return zap.Uint64(key, seq.Add(1)), true
+	}
+}
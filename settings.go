@@ -0,0 +1,198 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"bursavich.dev/zapr/encoding"
+	"bursavich.dev/zapr/output"
+)
+
+// OptionsFromSettings returns the Options described by a tree of settings,
+// such as those returned by viper's AllSettings or koanf's Raw methods, so that
+// logging may be configured in the same file as the rest of an application:
+//
+//	opts, err := zapr.OptionsFromSettings(viper.Sub("log").AllSettings())
+//
+// Keys are the names of the equivalent flags without the "log-" prefix, and
+// they're matched regardless of case, dashes, and underscores (e.g. "time-key",
+// "timeKey", "time_key", and "timekey" are equivalent). Nested sections are
+// joined with their parents, such that a "sampler" section with "tick",
+// "first", and "thereafter" keys sets the "log-sampler-*" flags. Lists are
+// joined with commas, the "key-renames" section maps old keys to new keys, and
+// the "error-levels" section maps logger names to zap levels.
+//
+// The "outputs" key is a list of additional outputs, as described by
+// WithOutput. Each is either a URL or a section with "url", "level",
+// and "format" keys.
+//
+// It returns an error if any key is unknown or any value is invalid.
+func OptionsFromSettings(settings map[string]interface{}) ([]Option, error) {
+	flags := make(map[string]string)
+	var outputs []interface{}
+	for k, v := range settings {
+		if settingKey(k) == "outputs" {
+			list, ok := v.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("zapr: invalid setting: %q: not a list", k)
+			}
+			outputs = list
+			continue
+		}
+		flattenSettings(flags, k, v)
+	}
+
+	var opts []Option
+	for _, o := range AllOptions() {
+		fs := flag.NewFlagSet("", flag.ContinueOnError)
+		o.register(fs)
+		set := false
+		var err error
+		fs.VisitAll(func(f *flag.Flag) {
+			key := settingKey(strings.TrimPrefix(f.Name, "log-"))
+			v, ok := flags[key]
+			if !ok || err != nil {
+				return
+			}
+			delete(flags, key)
+			if err = fs.Set(f.Name, v); err != nil {
+				err = fmt.Errorf("zapr: invalid setting: %q: %w", key, err)
+			}
+			set = true
+		})
+		if err != nil {
+			return nil, err
+		}
+		if set {
+			opts = append(opts, o)
+		}
+	}
+	if len(flags) > 0 {
+		keys := make([]string, 0, len(flags))
+		for k := range flags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return nil, fmt.Errorf("zapr: unknown settings: %q", keys)
+	}
+
+	// Open the outputs last, so that they're not leaked by other errors.
+	var opened []output.Output
+	for _, v := range outputs {
+		out, oopts, err := outputFromSetting(v)
+		if err != nil {
+			for _, out := range opened {
+				out.Close()
+			}
+			return nil, err
+		}
+		opened = append(opened, out)
+		opts = append(opts, WithOutput(out, oopts...))
+	}
+	return opts, nil
+}
+
+// settingKey normalizes the key of a setting.
+func settingKey(key string) string {
+	key = strings.ToLower(key)
+	return strings.NewReplacer("-", "", "_", "", ".", "").Replace(key)
+}
+
+// flattenSettings adds the leaves of the tree of settings to flags,
+// keyed by their normalized paths.
+func flattenSettings(flags map[string]string, key string, v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if k := settingKey(key); k == "keyrenames" || k == "errorlevels" {
+			pairs := make([]string, 0, len(v))
+			for old, new := range v {
+				pairs = append(pairs, old+"="+settingValue(new))
+			}
+			sort.Strings(pairs)
+			flags[k] = strings.Join(pairs, ",")
+			return
+		}
+		for k, v := range v {
+			flattenSettings(flags, key+"-"+k, v)
+		}
+	default:
+		flags[settingKey(key)] = settingValue(v)
+	}
+}
+
+// settingValue formats the value of a setting as a flag value.
+func settingValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, e := range v {
+			parts[i] = settingValue(e)
+		}
+		return strings.Join(parts, ",")
+	case []string:
+		return strings.Join(v, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func outputFromSetting(v interface{}) (output.Output, []OutputOption, error) {
+	var rawURL, level, format string
+	switch v := v.(type) {
+	case string:
+		rawURL = v
+	case map[string]interface{}:
+		for k, v := range v {
+			switch settingKey(k) {
+			case "url":
+				rawURL = settingValue(v)
+			case "level":
+				level = settingValue(v)
+			case "format":
+				format = settingValue(v)
+			default:
+				return nil, nil, fmt.Errorf("zapr: unknown output setting: %q", k)
+			}
+		}
+	default:
+		return nil, nil, fmt.Errorf("zapr: invalid output setting: %v", v)
+	}
+	var oopts []OutputOption
+	if level != "" {
+		n, err := strconv.Atoi(level)
+		if err != nil {
+			return nil, nil, fmt.Errorf("zapr: invalid output level: %q", level)
+		}
+		oopts = append(oopts, WithOutputLevel(n))
+	}
+	if format != "" {
+		var e encoding.Encoder
+		if err := encoding.EncoderFlag(&e).Set(format); err != nil {
+			return nil, nil, err
+		}
+		oopts = append(oopts, WithOutputEncoder(e))
+	}
+	if rawURL == "" {
+		return nil, nil, fmt.Errorf("zapr: output setting has no url")
+	}
+	out, err := output.Open(rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, oopts, nil
+}
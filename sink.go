@@ -10,9 +10,13 @@ package zapr
 
 import (
 	"bytes"
+	"fmt"
 	"log"
 	"reflect"
+	"runtime"
+	"sync/atomic"
 
+	"bursavich.dev/zapr/output"
 	"github.com/go-logr/logr"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -31,13 +35,55 @@ type LogSink interface {
 	Flush() error
 }
 
+// LevelSink is a LogSink whose verbosity level may be changed at runtime,
+// such that operators may raise the verbosity of a live process. The level
+// is shared by all sinks derived from it.
+type LevelSink interface {
+	LogSink
+
+	// SetLevel sets the verbosity level.
+	SetLevel(level int)
+
+	// Level returns the verbosity level.
+	Level() int
+}
+
 type sink struct {
 	logger   *zap.Logger
 	depth    int
 	errKey   string
+	kindKey  string
+	causeKey string
+	verbKey  string
+	errStack bool // whether stacktraces carried by errors are logged
 	logLevel int
-	maxLevel int
+	level    *atomic.Int64 // shared by derived sinks
+	outLevel int           // maximum level of added outputs
+	vmodule  *vmodule
+	levelFn  func(name string) int
+	name     string
+	infoZap  zapcore.Level
+	errZap   zapcore.Level
+	errZaps  errorLevels // overrides errZap for named loggers
+	audits   []string    // names of audit loggers
+	audit    bool        // whether entries bypass the level
 	observer Observer
+	renames  map[string]string
+	normKey  *keyNormalizer
+	strict   bool
+	allowZap bool // whether zap Fields are allowed in key-values
+	anyLimit *reflectLimits
+	tagV     bool // whether entries are tagged with their verbosity
+	origKey  string
+	origin   string // where the logger was derived
+	flusher  *flusher
+	ws       zapcore.WriteSyncer
+	cfg      *config
+
+	// If values are limited, base is the logger without values.
+	limit  *valuesLimit
+	base   *zap.Logger
+	values []zapcore.Field
 }
 
 // NewLogger returns a new Logger with the given options and a flush function.
@@ -47,19 +93,118 @@ func NewLogger(options ...Option) (logr.Logger, LogSink) {
 }
 
 // NewLogSink returns a new LogSink with the given options.
+// It implements LevelSink and Reopener.
 func NewLogSink(options ...Option) LogSink {
 	const depth = 1
 	c := configWithOptions(options)
-	return &sink{
+	s := &sink{
 		logger:   newLogger(c).WithOptions(zap.AddCallerSkip(depth)),
 		errKey:   c.errorKey,
+		kindKey:  c.errorKindKey,
+		causeKey: c.causesKey,
+		verbKey:  c.verboseKey,
+		errStack: c.errorStacktrace,
 		depth:    depth,
 		logLevel: 0,
-		maxLevel: c.level,
+		level:    c.levelVar,
+		outLevel: maxOutputLevel(c),
+		vmodule:  newVModule(c.vmodule),
+		levelFn:  c.levelFunc,
+		name:     c.name,
+		infoZap:  zapcore.InfoLevel,
+		errZaps:  copyErrorLevels(c.errorLevels),
 		observer: c.observer,
+		renames:  c.keyRenames,
+		normKey:  newKeyNormalizer(c.keyNormalizer),
+		strict:   c.strictFields,
+		allowZap: c.zapFields,
+		anyLimit: newReflectLimits(c.reflectDepth, c.reflectSize),
+		tagV:     len(c.outputs) > 0,
+		origKey:  c.originKey,
+		limit:    newValuesLimit(c.maxValues, c.evictValues),
+		ws:       c.ws,
+		cfg:      c,
 	}
+	s.errZap = s.errZaps.level(s.name, c.errorLevel)
+	if c.auditWS != nil {
+		s.audits = c.auditNames
+		s.audit = hasLoggerPrefix(s.name, s.audits)
+	}
+	if s.limit != nil {
+		s.base = s.logger
+	}
+	s.flusher = newFlusher(s.logger.Sync, c.observer)
+	if c.reopenOnSignal {
+		handleReopenSignals(c.ws, s.Underlying())
+	}
+	return s
 }
 
+// NewLogSinkFromZap returns a new LogSink which writes to the given logger.
+// It implements LevelSink.
+// Options which configure the sink, such as the error keys, verbosity level,
+// Observer, and zap options, are applied. Options which configure the encoder
+// or output are ignored. If there's an Observer, entries are observed with
+// zero bytes, since their encoded size isn't known.
+func NewLogSinkFromZap(l *zap.Logger, options ...Option) LogSink {
+	const depth = 1
+	c := configWithOptions(options)
+	l = l.WithOptions(c.zapOptions...)
+	if c.observer != nil {
+		c.observer.Init(loggerName(l))
+		l = l.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return &observerCore{Core: core, observer: c.observer}
+		}))
+	}
+	s := &sink{
+		logger:   l.WithOptions(zap.AddCallerSkip(depth)),
+		errKey:   c.errorKey,
+		kindKey:  c.errorKindKey,
+		causeKey: c.causesKey,
+		verbKey:  c.verboseKey,
+		errStack: c.errorStacktrace,
+		depth:    depth,
+		logLevel: 0,
+		level:    c.levelVar,
+		outLevel: -1,
+		vmodule:  newVModule(c.vmodule),
+		levelFn:  c.levelFunc,
+		name:     loggerName(l),
+		infoZap:  zapcore.InfoLevel,
+		errZaps:  copyErrorLevels(c.errorLevels),
+		observer: c.observer,
+		renames:  c.keyRenames,
+		normKey:  newKeyNormalizer(c.keyNormalizer),
+		strict:   c.strictFields,
+		allowZap: c.zapFields,
+		anyLimit: newReflectLimits(c.reflectDepth, c.reflectSize),
+		origKey:  c.originKey,
+		limit:    newValuesLimit(c.maxValues, c.evictValues),
+		cfg:      c,
+	}
+	s.errZap = s.errZaps.level(s.name, c.errorLevel)
+	if s.limit != nil {
+		s.base = s.logger
+	}
+	s.flusher = newFlusher(s.logger.Sync, c.observer)
+	return s
+}
+
+// NewCore returns a new zapcore.Core with the given options and its Observer,
+// which may be nil, so that it can be embedded in other zap loggers. Options
+// which configure a zap.Logger rather than its core, such as the name,
+// caller annotations, and development mode, are ignored.
+func NewCore(options ...Option) (zapcore.Core, Observer) {
+	c := configWithOptions(options)
+	return buildCore(c), c.observer
+}
+
+// Outputs of split loggers, which may be replaced by tests.
+var (
+	splitStdout = output.Stdout
+	splitStderr = output.Stderr
+)
+
 // newLogger returns a new zap.Logger with the given config.
 func newLogger(c *config) *zap.Logger {
 	var opts []zap.Option
@@ -69,15 +214,19 @@ func newLogger(c *config) *zap.Logger {
 	if c.enableCaller {
 		opts = append(opts, zap.AddCaller())
 	}
-	if c.enableStacktrace {
-		opts = append(opts, zap.AddStacktrace(zapcore.ErrorLevel))
+	if c.enableStacktrace && c.stacktraceDepth <= 0 && len(c.stacktraceOmit) == 0 {
+		opts = append(opts, zap.AddStacktrace(c.stacktraceLevel))
 	}
-	if c.sampleFirst != 0 || c.sampleThereafter != 0 {
-		opts = append(opts, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
-			return zapcore.NewSamplerWithOptions(core, c.sampleTick, c.sampleFirst, c.sampleThereafter, c.sampleOpts...)
-		}))
+	if c.clock != nil {
+		opts = append(opts, zap.WithClock(c.clock))
 	}
-	enc := c.encoder.NewEncoder(zapcore.EncoderConfig{
+	opts = append(opts, c.zapOptions...)
+	return zap.New(buildCore(c), opts...).Named(c.name)
+}
+
+// buildCore returns a new zapcore.Core with the given config.
+func buildCore(c *config) zapcore.Core {
+	encCfg := zapcore.EncoderConfig{
 		TimeKey:        c.timeKey,
 		LevelKey:       c.levelKey,
 		NameKey:        c.nameKey,
@@ -90,16 +239,160 @@ func newLogger(c *config) *zap.Logger {
 		EncodeLevel:    c.levelEncoder.LevelEncoder(),
 		EncodeDuration: c.durationEncoder.DurationEncoder(),
 		EncodeCaller:   c.callerEncoder.CallerEncoder(),
-	})
+	}
+	enc := c.encoder.NewEncoder(encCfg)
+	if c.duplicateKeys != AllowDuplicateKeys {
+		enc = newDedupeEncoder(enc, c.duplicateKeys, encCfg)
+	}
+	if c.sortFields {
+		enc = &sortedEncoder{enc: enc}
+	}
+	if c.valuesNamespace != "" {
+		enc = &namespaceEncoder{enc: enc, key: c.valuesNamespace}
+	}
+	auditEnc := enc // without wrapping, budgeting, or observing
+	if width := c.consoleWidth; width != 0 && c.encoder.Name() == "console" {
+		if width < 0 {
+			width = terminalWidth()
+		}
+		if width > 0 {
+			enc = &wrapEncoder{Encoder: enc, width: width}
+		}
+	}
+	var budget *byteBudget
+	if c.byteBudget > 0 {
+		budget = newByteBudget(c.byteBudget, c.clock)
+		enc = &budgetEncoder{Encoder: enc, budget: budget}
+	}
 	if c.observer != nil {
 		enc = &observerEncoder{
 			Encoder:  enc,
 			observer: c.observer,
 		}
 		c.observer.Init(c.name)
+		if o, ok := c.observer.(output.Observer); ok {
+			if ws, ok := c.ws.(output.Observable); ok {
+				ws.Observe(o)
+			}
+		}
+	}
+	newCore := func(ws zapcore.WriteSyncer, enab zapcore.LevelEnabler) zapcore.Core {
+		if c.bufferSize > 0 {
+			ws = &zapcore.BufferedWriteSyncer{
+				WS:            ws,
+				Size:          c.bufferSize,
+				FlushInterval: c.flushInterval,
+			}
+		}
+		if c.chainKey != "" {
+			return newChainCore(enc, ws, enab, newAuditChain(c.chainKey, c.chainSecret))
+		}
+		return zapcore.NewCore(enc, ws, enab)
+	}
+	var core zapcore.Core
+	if c.splitOutput {
+		core = newCheckedTee(
+			newCore(splitStdout(), zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+				return zapcore.InfoLevel <= l && l < zapcore.ErrorLevel
+			})),
+			newCore(splitStderr(), zapcore.ErrorLevel),
+		)
+	} else {
+		core = newCore(c.ws, zapcore.InfoLevel)
+	}
+	if budget != nil {
+		core = &budgetCore{Core: core, budget: budget}
+	}
+	tees := c.tees
+	if len(c.outputs) > 0 {
+		core = &verbosityCore{Core: core, level: c.levelVar}
+		tees = tees[:len(tees):len(tees)]
+		for _, o := range c.outputs {
+			e := c.encoder
+			if o.encoder != nil {
+				e = o.encoder
+			}
+			level := new(atomic.Int64)
+			level.Store(int64(o.level))
+			var oc zapcore.Core
+			if c.chainKey != "" {
+				oc = newChainCore(e.NewEncoder(encCfg), o.ws, zapcore.InfoLevel, newAuditChain(c.chainKey, c.chainSecret))
+			} else {
+				oc = zapcore.NewCore(e.NewEncoder(encCfg), o.ws, zapcore.InfoLevel)
+			}
+			tees = append(tees, &verbosityCore{Core: oc, level: level})
+		}
+	}
+	if len(c.taps) > 0 {
+		tees = append(tees[:len(tees):len(tees)], newTapCore(zapcore.InfoLevel, c.taps))
+	}
+	if len(tees) > 0 {
+		core = newCheckedTee(append([]zapcore.Core{core}, tees...)...)
+	}
+	core = wrapCore(c, core)
+	if c.watcher != nil {
+		core = c.watcher.register(c, core)
+	} else if c.sampleFirst != 0 || c.sampleThereafter != 0 {
+		core = zapcore.NewSamplerWithOptions(core, c.sampleTick, c.sampleFirst, c.sampleThereafter, c.sampleOpts...)
+	}
+	if c.auditWS != nil {
+		var audit zapcore.Core
+		if c.chainKey != "" {
+			audit = newChainCore(auditEnc, c.auditWS, zapcore.DebugLevel, newAuditChain(c.chainKey, c.chainSecret))
+		} else {
+			audit = zapcore.NewCore(auditEnc, c.auditWS, zapcore.DebugLevel)
+		}
+		core = &auditCore{Core: core, audit: wrapCore(c, &syncCore{Core: audit}), names: c.auditNames}
+	}
+	return core
+}
+
+// wrapCore returns the core wrapped by the cores which transform entries
+// before they're encoded.
+func wrapCore(c *config, core zapcore.Core) zapcore.Core {
+	if t := newValueTruncator(c.maxValueLength, c.observer); t != nil {
+		core = &truncateCore{Core: core, t: t}
+	}
+	if keys := newKeyMatcher(c.redactKeys); keys != nil {
+		core = &redactCore{Core: core, keys: keys}
+	}
+	if c.scrubber != nil {
+		core = &scrubCore{Core: core, s: c.scrubber}
+	}
+	if h := newKeyHasher(c.hashSecret, c.hashKeys); h != nil {
+		core = &hashCore{Core: core, h: h}
+	}
+	if c.reportService != "" {
+		core = &errorReportingCore{
+			Core:    core,
+			service: serviceContext{service: c.reportService, version: c.reportVersion},
+		}
+	}
+	if c.enableStacktrace && (c.stacktraceDepth > 0 || len(c.stacktraceOmit) > 0) {
+		core = &stackCore{
+			Core:  core,
+			level: c.stacktraceLevel,
+			omit:  c.stacktraceOmit,
+			depth: c.stacktraceDepth,
+		}
+	}
+	if c.traceEvents {
+		core = &traceCore{Core: core}
 	}
-	core := zapcore.NewCore(enc, c.ws, zapcore.InfoLevel)
-	return zap.New(core, opts...).Named(c.name)
+	var extra []extraField
+	if c.goroutineKey != "" {
+		extra = append(extra, goroutineIDField(c.goroutineKey))
+	}
+	if c.sourceKey != "" {
+		extra = append(extra, callerSourceField(c.sourceKey))
+	}
+	if c.sequenceKey != "" {
+		extra = append(extra, sequenceField(c.sequenceKey))
+	}
+	if len(extra) > 0 {
+		core = &extraCore{Core: core, extra: extra}
+	}
+	return core
 }
 
 func (s *sink) sweeten(kvs []interface{}) []zapcore.Field {
@@ -107,9 +400,13 @@ func (s *sink) sweeten(kvs []interface{}) []zapcore.Field {
 		return nil
 	}
 	fields := make([]zapcore.Field, 0, len(kvs)/2)
+	var undeclared []string
 	for i, n := 0, len(kvs)-1; i <= n; {
 		switch key := kvs[i].(type) {
 		case string:
+			if s.strict && !isDeclaredField(key) {
+				undeclared = append(undeclared, key)
+			}
 			if i == n {
 				s.sweetenDPanic("Ignored key without a value.",
 					zap.Int("position", i),
@@ -117,20 +414,31 @@ func (s *sink) sweeten(kvs []interface{}) []zapcore.Field {
 				)
 				return fields
 			}
-			val := kvs[i+1]
-			if x, ok := val.(logr.Marshaler); ok {
-				val = x.MarshalLog()
-			}
-			fields = append(fields, zap.Any(key, val))
+			fields = append(fields, valueField(s.renameKey(key), kvs[i+1], s.anyLimit))
 			i += 2
 		case zapcore.Field:
-			s.sweetenDPanic("Zap Field passed to logr",
-				zap.Int("position", i),
-				zap.String("key", key.Key),
-			)
+			if !s.allowZap {
+				s.sweetenDPanic("Zap Field passed to logr",
+					zap.Int("position", i),
+					zap.String("key", key.Key),
+				)
+			}
+			if s.strict && !isDeclaredField(key.Key) {
+				undeclared = append(undeclared, key.Key)
+			}
+			key.Key = s.renameKey(key.Key)
 			fields = append(fields, key)
 			i++
 		default:
+			if f, ok := slogAttrField(key); ok {
+				if s.strict && f.Key != "" && !isDeclaredField(f.Key) {
+					undeclared = append(undeclared, f.Key)
+				}
+				f.Key = s.renameKey(f.Key)
+				fields = append(fields, f)
+				i++
+				continue
+			}
 			s.sweetenDPanic("Ignored key-value pair with non-string key",
 				zap.Int("position", i),
 				zap.Any("type", reflect.TypeOf(key).String()),
@@ -138,49 +446,206 @@ func (s *sink) sweeten(kvs []interface{}) []zapcore.Field {
 			i += 2
 		}
 	}
+	if len(undeclared) > 0 {
+		s.sweetenDPanic("Undeclared field keys", zap.Strings("keys", undeclared))
+	}
 	return fields
 }
 
+// valueField returns the Field of the key and value, which is marshaled
+// if it's a logr.Marshaler and resolved if it's an slog.LogValuer.
+// If it's from Lazy, it's computed when the Field is encoded. If it would
+// be encoded by reflection and there are limits, it's encoded within them.
+func valueField(key string, val interface{}, lim *reflectLimits) zapcore.Field {
+	switch x := val.(type) {
+	case Value:
+		return x.field(key)
+	case lazyValue:
+		return zapcore.Field{Key: key, Type: zapcore.InlineMarshalerType, Interface: &lazyField{key: key, fn: x, lim: lim}}
+	case logr.Marshaler:
+		val = marshalLog(x)
+	}
+	if f, ok := slogValueField(key, val); ok {
+		return f
+	}
+	f := zap.Any(key, val)
+	if f.Type == zapcore.ReflectType && lim != nil {
+		return lim.field(key, val)
+	}
+	return f
+}
+
+// marshalLog returns the value of the Marshaler, as required by logr.
+// Like logr's funcr, a panic is rendered in place of the value, unless
+// the Marshaler is a nil pointer, which is rendered as nil.
+func marshalLog(x logr.Marshaler) (v interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			if rv := reflect.ValueOf(x); rv.Kind() == reflect.Pointer && rv.IsNil() {
+				v = nil
+				return
+			}
+			v = fmt.Sprintf("<panic: %v>", r)
+		}
+	}()
+	return x.MarshalLog()
+}
+
 func (s *sink) sweetenDPanic(msg string, fields ...zapcore.Field) {
 	s.logger.WithOptions(zap.AddCallerSkip(1)).DPanic(msg, fields...)
 }
 
 func (s *sink) Init(info logr.RuntimeInfo) {
 	s.logger = s.logger.WithOptions(zap.AddCallerSkip(info.CallDepth))
+	if s.base != nil {
+		s.base = s.base.WithOptions(zap.AddCallerSkip(info.CallDepth))
+	}
+}
+
+func (s *sink) Enabled(level int) bool { return s.enabled(level) }
+
+// enabled must be called directly by a method called by the logr.Logger,
+// so that call sites are identified for vmodule rules.
+func (s *sink) enabled(level int) bool {
+	if s.audit || int64(level) <= s.level.Load() || level <= s.outLevel {
+		return true
+	}
+	if s.levelFn != nil && level <= s.levelFn(s.name) {
+		return true
+	}
+	// Skip runtime.Callers, enabled, and the sink method, plus the call depth.
+	return s.vmodule != nil && s.vmodule.enabled(level, 3+s.depth)
 }
 
-func (s *sink) Enabled(level int) bool { return level <= s.maxLevel }
+// SetLevel sets the verbosity level of the sink and all sinks derived from it.
+func (s *sink) SetLevel(level int) { s.level.Store(int64(level)) }
+
+// Level returns the verbosity level of the sink.
+func (s *sink) Level() int { return int(s.level.Load()) }
 
 func (s *sink) Info(level int, msg string, keysAndValues ...interface{}) {
-	if level > s.maxLevel {
+	if !s.enabled(level) {
 		return
 	}
-	if ce := s.logger.Check(zapcore.InfoLevel, msg); ce != nil {
-		ce.Write(s.sweeten(keysAndValues)...)
+	if ce := s.logger.Check(s.infoZap, msg); ce != nil {
+		fields := s.sweeten(keysAndValues)
+		if s.origin != "" {
+			fields = append(fields, zap.String(s.origKey, s.origin))
+		}
+		if s.tagV {
+			v := level
+			if int64(v) > s.level.Load() && v > s.outLevel {
+				v = 0 // raised by a level func or vmodule rules
+			}
+			fields = append(fields, verbosityField(v))
+		} else if level > 0 {
+			fields = append(fields, verbosityField(level))
+		}
+		ce.Write(fields...)
 	}
 }
 
 func (s *sink) Error(err error, msg string, keysAndValues ...interface{}) {
-	if ce := s.logger.Check(zapcore.ErrorLevel, msg); ce != nil {
+	if ce := s.logger.Check(s.errZap, msg); ce != nil {
 		kvs := keysAndValues
-		if s.errKey != "" && err != nil {
-			kvs = make([]interface{}, 0, len(keysAndValues)+2)
+		if err != nil && (s.errKey != "" || s.kindKey != "" || s.causeKey != "" || s.verbKey != "") {
+			kvs = make([]interface{}, 0, len(keysAndValues)+8)
 			kvs = append(kvs, keysAndValues...)
-			kvs = append(kvs, s.errKey, err.Error())
+			if s.errKey != "" {
+				kvs = append(kvs, s.errKey, err.Error())
+			}
+			if s.kindKey != "" {
+				kvs = append(kvs, s.kindKey, reflect.TypeOf(err).String())
+			}
+			if s.causeKey != "" {
+				if causes := errorCauses(err); len(causes) > 0 {
+					kvs = append(kvs, s.causeKey, causes)
+				}
+			}
+			if s.verbKey != "" {
+				if verbose, ok := errorVerbose(err); ok {
+					kvs = append(kvs, s.verbKey, verbose)
+				}
+			}
 		}
-		ce.Write(s.sweeten(kvs)...)
+		if err != nil && s.errStack {
+			if stack := errorStacktrace(err); stack != "" {
+				ce.Stack = stack
+			}
+		}
+		fields := s.sweeten(kvs)
+		if s.origin != "" {
+			fields = append(fields, zap.String(s.origKey, s.origin))
+		}
+		ce.Write(fields...)
+	}
+}
+
+// withOrigin returns a copy of the sink whose origin is the caller of the
+// method calling withOrigin, if origins are enabled.
+func (s *sink) withOrigin() *sink {
+	if s.origKey == "" {
+		return s
+	}
+	// Skip withOrigin, the sink's method, and the logr.Logger's method.
+	_, file, line, ok := runtime.Caller(2 + s.depth)
+	if !ok {
+		return s
 	}
+	v := *s
+	v.origin = zapcore.NewEntryCaller(0, file, line, ok).TrimmedPath()
+	return &v
 }
 
 func (s *sink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	s = s.withOrigin()
+	if s.limit != nil {
+		return s.withLimitedValues(s.sweeten(keysAndValues))
+	}
 	v := *s
 	v.logger = s.logger.With(s.sweeten(keysAndValues)...)
 	return &v
 }
 
-func (s *sink) WithName(name string) logr.LogSink {
+func (s *sink) withLimitedValues(fields []zapcore.Field) logr.LogSink {
+	if len(fields) == 0 {
+		return s
+	}
+	values := make([]zapcore.Field, 0, len(s.values)+len(fields))
+	values = append(append(values, s.values...), fields...)
+	if n := len(values) - s.limit.max; n > 0 {
+		if !s.limit.evict {
+			s.logger.WithOptions(zap.AddCallerSkip(1)).DPanic("Refused values exceeding the limit",
+				zap.Int("limit", s.limit.max),
+				zap.Int("count", len(values)),
+			)
+			return s
+		}
+		values = values[n:]
+	}
 	v := *s
+	v.values = values
+	v.logger = s.limit.with(s.base, values)
+	return &v
+}
+
+func (s *sink) WithName(name string) logr.LogSink {
+	v := *s.withOrigin()
 	v.logger = v.logger.Named(name)
+	if v.name == "" {
+		v.name = name
+	} else if name != "" {
+		v.name += "." + name
+	}
+	if v.errZaps != nil {
+		v.errZap = v.errZaps.level(v.name, v.cfg.errorLevel)
+	}
+	if v.audits != nil {
+		v.audit = hasLoggerPrefix(v.name, v.audits)
+	}
+	if v.base != nil {
+		v.base = v.base.Named(name)
+	}
 	if v.observer != nil {
 		v.observer.Init(loggerName(v.logger))
 	}
@@ -193,6 +658,9 @@ func (s *sink) WithCallDepth(depth int) logr.LogSink {
 	}
 	v := *s
 	v.logger = v.logger.WithOptions(zap.AddCallerSkip(depth))
+	if v.base != nil {
+		v.base = v.base.WithOptions(zap.AddCallerSkip(depth))
+	}
 	v.depth += depth
 	return &v
 }
@@ -201,7 +669,10 @@ func (s *sink) Underlying() *zap.Logger {
 	return s.logger.WithOptions(zap.AddCallerSkip(-s.depth))
 }
 
-func (s *sink) Flush() error { return s.logger.Sync() }
+// Flush coalesces concurrent calls, such that they share the underlying sync.
+func (s *sink) Flush() error { return s.flusher.flush() }
+
+func (s *sink) Reopen() error { return reopen(s.ws) }
 
 var runtimeInfo logr.RuntimeInfo
 
@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21
+
+package zapr
+
+import (
+	"log/slog"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// slogAttrField returns the Field of the key, if it's an slog.Attr.
+func slogAttrField(key interface{}) (zapcore.Field, bool) {
+	a, ok := key.(slog.Attr)
+	if !ok {
+		return zapcore.Field{}, false
+	}
+	return attrField(a), true
+}
+
+// slogValueField returns the Field of the key and value,
+// if the value is an slog.Value or slog.LogValuer.
+func slogValueField(key string, val interface{}) (zapcore.Field, bool) {
+	switch val.(type) {
+	case slog.Value, slog.LogValuer:
+		return attrField(slog.Attr{Key: key, Value: slog.AnyValue(val)}), true
+	}
+	return zapcore.Field{}, false
+}
+
+// attrField returns the Field of the Attr, resolving its LogValuers. As with
+// slog's handlers, empty Attrs and groups are skipped and the Attrs of groups
+// with empty keys are inlined.
+func attrField(a slog.Attr) zapcore.Field {
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindGroup:
+		attrs := v.Group()
+		if len(attrs) == 0 {
+			return zap.Skip()
+		}
+		if a.Key == "" {
+			return zap.Inline(slogGroup(attrs))
+		}
+		return zap.Object(a.Key, slogGroup(attrs))
+	}
+	if a.Key == "" && v.Equal(slog.Value{}) {
+		return zap.Skip()
+	}
+	switch v.Kind() {
+	case slog.KindString:
+		return zap.String(a.Key, v.String())
+	case slog.KindInt64:
+		return zap.Int64(a.Key, v.Int64())
+	case slog.KindUint64:
+		return zap.Uint64(a.Key, v.Uint64())
+	case slog.KindFloat64:
+		return zap.Float64(a.Key, v.Float64())
+	case slog.KindBool:
+		return zap.Bool(a.Key, v.Bool())
+	case slog.KindDuration:
+		return zap.Duration(a.Key, v.Duration())
+	case slog.KindTime:
+		return zap.Time(a.Key, v.Time())
+	default:
+		return zap.Any(a.Key, v.Any())
+	}
+}
+
+type slogGroup []slog.Attr
+
+func (g slogGroup) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, a := range g {
+		attrField(a).AddTo(enc)
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !go1.21
+
+package zapr
+
+import "go.uber.org/zap/zapcore"
+
+func slogAttrField(key interface{}) (zapcore.Field, bool) {
+	return zapcore.Field{}, false
+}
+
+func slogValueField(key string, val interface{}) (zapcore.Field, bool) {
+	return zapcore.Field{}, false
+}
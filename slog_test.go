@@ -0,0 +1,63 @@
+//go:build go1.21
+
+package zapr
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+type slogUser struct {
+	name     string
+	password string
+}
+
+func (u slogUser) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("name", u.name))
+}
+
+func TestSlog(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	log, _ := NewLogger(
+		WithWriteSyncer(zapcore.AddSync(buf)),
+		WithKeyRenames(map[string]string{"req": "request"}),
+	)
+	log.Info("test",
+		slog.Group("req", slog.String("method", "GET"), slog.Int("status", 200)),
+		slog.Group("", slog.Bool("inlined", true)),
+		slog.Attr{},
+		slog.Group("empty"),
+		"user", slogUser{"alice", "hunter2"},
+		"value", slog.Float64Value(1.5),
+		slog.Any("attrUser", slogUser{"bob", "hunter3"}),
+	)
+	t.Log("\n" + strings.TrimSpace(buf.String())) // help debugging
+
+	if strings.Contains(buf.String(), "hunter") {
+		t.Errorf("unexpected hidden field: %s", buf.String())
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	delete(got, "time")
+	delete(got, "caller")
+	want := map[string]interface{}{
+		"level":    "INFO",
+		"message":  "test",
+		"request":  map[string]interface{}{"method": "GET", "status": 200.0},
+		"inlined":  true,
+		"user":     map[string]interface{}{"name": "alice"},
+		"value":    1.5,
+		"attrUser": map[string]interface{}{"name": "bob"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected entry: want: %v; got: %v", want, got)
+	}
+}
@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"sort"
+
+	"bursavich.dev/zapr/internal/fields"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// sortedEncoder encodes fields in order of their keys, after the entry's
+// header. Context fields are recorded rather than encoded so that they may
+// be sorted together with each entry's fields.
+type sortedEncoder struct {
+	fields.Recorder
+	enc zapcore.Encoder // without context
+}
+
+func (enc *sortedEncoder) Clone() zapcore.Encoder {
+	return &sortedEncoder{
+		Recorder: enc.Recorder.Clone(),
+		enc:      enc.enc,
+	}
+}
+
+func (enc *sortedEncoder) EncodeEntry(ent zapcore.Entry, fs []zapcore.Field) (*buffer.Buffer, error) {
+	return enc.enc.EncodeEntry(ent, sortedFields(enc.With(fs)))
+}
+
+// sortedFields returns a sorted copy of the fields. Inlined objects are
+// expanded so that their fields are sorted with the others, and the fields
+// of nested objects are sorted when they're encoded.
+func sortedFields(fs []zapcore.Field) []zapcore.Field {
+	out := appendSortable(make([]zapcore.Field, 0, len(fs)), fs)
+	sortFields(out)
+	return out
+}
+
+func appendSortable(out, fs []zapcore.Field) []zapcore.Field {
+	for _, f := range fs {
+		switch f.Type {
+		case zapcore.InlineMarshalerType:
+			var r fields.Recorder
+			if err := f.Interface.(zapcore.ObjectMarshaler).MarshalLogObject(&r); err == nil {
+				out = appendSortable(out, r.Fields)
+				continue
+			}
+		case zapcore.ObjectMarshalerType:
+			f.Interface = mappedObject{m: f.Interface.(zapcore.ObjectMarshaler), fn: sortedFields}
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+// sortFields sorts fields by key. Fields following a namespace belong to
+// it, so each run of fields between namespaces is sorted separately.
+func sortFields(fs []zapcore.Field) {
+	for len(fs) > 0 {
+		n := 0
+		for n < len(fs) && fs[n].Type != zapcore.NamespaceType {
+			n++
+		}
+		run := fs[:n]
+		sort.SliceStable(run, func(i, k int) bool { return run[i].Key < run[k].Key })
+		if n < len(fs) {
+			n++ // skip the namespace
+		}
+		fs = fs[n:]
+	}
+}
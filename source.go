@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func callerSourceField(key string) extraField {
+	return func(ent zapcore.Entry) (zapcore.Field, bool) {
+		if ent.Level < zapcore.ErrorLevel || !ent.Caller.Defined {
+			return zapcore.Field{}, false
+		}
+		line, ok := sourceLine(ent.Caller.File, ent.Caller.Line)
+		if !ok {
+			return zapcore.Field{}, false
+		}
+		return zap.String(key, line), true
+	}
+}
+
+type sourcePos struct {
+	file string
+	line int
+}
+
+// sourceLines caches source lines, which are only read for errors
+// from a bounded set of call sites.
+var sourceLines sync.Map // map[sourcePos]string
+
+func sourceLine(file string, line int) (string, bool) {
+	k := sourcePos{file, line}
+	if v, ok := sourceLines.Load(k); ok {
+		s := v.(string)
+		return s, s != ""
+	}
+	s := readSourceLine(file, line)
+	sourceLines.Store(k, s)
+	return s, s != ""
+}
+
+func readSourceLine(file string, line int) string {
+	f, err := os.Open(file)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		if n == line {
+			return strings.TrimSpace(sc.Text())
+		}
+	}
+	return ""
+}
@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// stackCore captures stacktraces of at most depth frames, if it's positive,
+// in place of zap's stacktraces, which are unlimited. They're captured when
+// entries are checked, except for those of the omitted loggers and their
+// descendants, so that they're neither captured for dropped entries nor
+// captured only to be discarded.
+type stackCore struct {
+	zapcore.Core
+	level zapcore.LevelEnabler
+	omit  []string
+	depth int
+}
+
+func (c *stackCore) With(fields []zapcore.Field) zapcore.Core {
+	return &stackCore{Core: c.Core.With(fields), level: c.level, omit: c.omit, depth: c.depth}
+}
+
+func (c *stackCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	ce = c.Core.Check(ent, ce)
+	if ce != nil && ce.Stack == "" && c.level.Enabled(ent.Level) && !hasLoggerPrefix(ent.LoggerName, c.omit) {
+		ce.Stack = takeStacktrace(c.depth)
+	}
+	return ce
+}
+
+// maxInternalFrames bounds the frames of zap, logr, and the sink which
+// precede the caller.
+const maxInternalFrames = 32
+
+// takeStacktrace returns a stacktrace of at most depth frames, if it's
+// positive, formatted like zap's, excluding the leading frames of zap, logr,
+// and the sink.
+func takeStacktrace(depth int) string {
+	if depth < 0 {
+		depth = 0
+	}
+	pcs := make([]uintptr, depth+maxInternalFrames)
+	n := runtime.Callers(2, pcs)
+	for depth <= 0 && n == len(pcs) {
+		pcs = make([]uintptr, 2*len(pcs))
+		n = runtime.Callers(2, pcs)
+	}
+	frames := runtime.CallersFrames(pcs[:n])
+	var b strings.Builder
+	for n := 0; depth <= 0 || n < depth; {
+		f, more := frames.Next()
+		if n > 0 || !isInternalFrame(f.Function) {
+			if n > 0 {
+				b.WriteByte('\n')
+			}
+			b.WriteString(f.Function)
+			b.WriteString("\n\t")
+			b.WriteString(f.File)
+			b.WriteByte(':')
+			b.WriteString(strconv.Itoa(f.Line))
+			n++
+		}
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
+
+// isInternalFrame returns true if the function belongs to zap, logr, or one
+// of this package's types, such as the sink and its cores.
+func isInternalFrame(fn string) bool {
+	return strings.HasPrefix(fn, "go.uber.org/zap") ||
+		strings.HasPrefix(fn, "github.com/go-logr/logr.") ||
+		strings.HasPrefix(fn, "bursavich.dev/zapr.(*")
+}
+
+// hasLoggerPrefix returns true if the logger name equals one of the names
+// or is a descendant of it (e.g. "foo.bar" is a descendant of "foo").
+func hasLoggerPrefix(logger string, names []string) bool {
+	for _, name := range names {
+		if strings.HasPrefix(logger, name) && (len(logger) == len(name) || logger[len(name)] == '.') {
+			return true
+		}
+	}
+	return false
+}
+
+// listFlag is a flag value for a comma-separated list.
+type listFlag struct {
+	s *[]string
+}
+
+func (f *listFlag) Get() interface{} { return *f.s }
+
+func (f *listFlag) Set(s string) error {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	*f.s = list
+	return nil
+}
+
+// AcceptsCommas reports that the value may contain commas.
+func (f *listFlag) AcceptsCommas() bool { return true }
+
+func (f *listFlag) String() string {
+	if f.s == nil {
+		return ""
+	}
+	return strings.Join(*f.s, ",")
+}
@@ -0,0 +1,188 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// An EntrySnapshot is a copy of a logged entry whose fields, including those
+// inherited from the logger, have been materialized into plain values.
+// It may be retained without interfering with the logger.
+type EntrySnapshot struct {
+	zapcore.Entry
+
+	// Fields are the entry's fields, as encoded by zapcore.MapObjectEncoder.
+	// Marshaled objects are maps and marshaled arrays are slices. Reflected
+	// values are retained as given if they don't contain references (e.g.
+	// pointers, maps, or slices), or else they're copied as decoded from
+	// encoding/json. Each snapshot has its own deep copy.
+	Fields map[string]interface{}
+}
+
+// A Tap receives snapshots of logged entries. It's called synchronously by
+// the logger, so it mustn't block. Use ChanTap to process entries
+// asynchronously.
+type Tap interface {
+	Tap(EntrySnapshot)
+}
+
+// TapFunc is an adapter which allows a function to be used as a Tap.
+type TapFunc func(EntrySnapshot)
+
+// Tap calls fn(e).
+func (fn TapFunc) Tap(e EntrySnapshot) { fn(e) }
+
+// ChanTap returns a Tap which sends snapshots to the channel,
+// dropping them if the channel isn't ready to receive.
+func ChanTap(ch chan<- EntrySnapshot) Tap {
+	return TapFunc(func(e EntrySnapshot) {
+		select {
+		case ch <- e:
+		default:
+		}
+	})
+}
+
+// tapCore sends snapshots of entries to taps.
+type tapCore struct {
+	zapcore.LevelEnabler
+	taps   []Tap
+	fields map[string]interface{}
+}
+
+func newTapCore(enab zapcore.LevelEnabler, taps []Tap) *tapCore {
+	return &tapCore{LevelEnabler: enab, taps: taps}
+}
+
+func (c *tapCore) encode(fields []zapcore.Field) map[string]interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+	for k, v := range c.fields {
+		enc.Fields[k] = copyValue(v)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	for k, v := range enc.Fields {
+		enc.Fields[k] = snapshotValue(v)
+	}
+	return enc.Fields
+}
+
+func (c *tapCore) With(fields []zapcore.Field) zapcore.Core {
+	return &tapCore{
+		LevelEnabler: c.LevelEnabler,
+		taps:         c.taps,
+		fields:       c.encode(fields),
+	}
+}
+
+func (c *tapCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *tapCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	snap := EntrySnapshot{Entry: ent}
+	for i, tap := range c.taps {
+		if i == 0 {
+			snap.Fields = c.encode(fields)
+		} else {
+			snap.Fields = copyFields(snap.Fields)
+		}
+		tap.Tap(snap)
+	}
+	return nil
+}
+
+func (c *tapCore) Sync() error { return nil }
+
+// copyFields returns a deep copy of the fields, such that taps may modify
+// their own maps and the values within them.
+func copyFields(fields map[string]interface{}) map[string]interface{} {
+	m := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		m[k] = copyValue(v)
+	}
+	return m
+}
+
+// copyValue returns a deep copy of the maps and slices of a snapshot value.
+func copyValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		return copyFields(x)
+	case []interface{}:
+		s := make([]interface{}, len(x))
+		for i, v := range x {
+			s[i] = copyValue(v)
+		}
+		return s
+	}
+	return v
+}
+
+// snapshotValue returns the encoded value, with any reflected value which
+// contains references replaced by a copy decoded from encoding/json, such
+// that the snapshot doesn't share memory with the logged value.
+func snapshotValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for k, v := range x {
+			x[k] = snapshotValue(v)
+		}
+		return x
+	case []interface{}:
+		for i, v := range x {
+			x[i] = snapshotValue(v)
+		}
+		return x
+	case []byte:
+		return append([]byte(nil), x...)
+	case nil, time.Time:
+		return v
+	}
+	if !hasReferences(reflect.TypeOf(v)) {
+		return v
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("<error: %v>", err)
+	}
+	var out interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return fmt.Sprintf("<error: %v>", err)
+	}
+	return out
+}
+
+// hasReferences returns whether values of the type may share memory,
+// other than immutable strings.
+func hasReferences(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Array:
+		return hasReferences(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if hasReferences(t.Field(i).Type) {
+				return true
+			}
+		}
+		return false
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map,
+		reflect.Pointer, reflect.Slice, reflect.UnsafePointer:
+		return true
+	}
+	return false
+}
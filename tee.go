@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"errors"
+
+	"github.com/go-logr/logr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TeeLogSink returns a LogSink which fans out to all of the given sinks,
+// such as a local console sink and a remote shipping sink. Each entry is
+// written to the sinks for which its level is enabled. The sinks should be
+// new (e.g. from NewLogSink), since they're initialized by the tee.
+func TeeLogSink(sinks ...LogSink) LogSink {
+	switch len(sinks) {
+	case 0:
+		return discard
+	case 1:
+		return sinks[0]
+	}
+	return &teeSink{sinks: append([]LogSink(nil), sinks...)}
+}
+
+type teeSink struct {
+	sinks []LogSink
+}
+
+func (t *teeSink) Init(info logr.RuntimeInfo) {
+	info.CallDepth++
+	for _, s := range t.sinks {
+		s.Init(info)
+	}
+}
+
+func (t *teeSink) Enabled(level int) bool {
+	for _, s := range t.sinks {
+		if s.Enabled(level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *teeSink) Info(level int, msg string, keysAndValues ...any) {
+	for _, s := range t.sinks {
+		if s.Enabled(level) {
+			s.Info(level, msg, keysAndValues...)
+		}
+	}
+}
+
+func (t *teeSink) Error(err error, msg string, keysAndValues ...any) {
+	for _, s := range t.sinks {
+		s.Error(err, msg, keysAndValues...)
+	}
+}
+
+func (t *teeSink) WithValues(keysAndValues ...any) logr.LogSink {
+	return t.each(func(s LogSink) logr.LogSink { return s.WithValues(keysAndValues...) })
+}
+
+func (t *teeSink) WithName(name string) logr.LogSink {
+	return t.each(func(s LogSink) logr.LogSink { return s.WithName(name) })
+}
+
+func (t *teeSink) WithCallDepth(depth int) logr.LogSink {
+	return t.each(func(s LogSink) logr.LogSink { return s.WithCallDepth(depth) })
+}
+
+func (t *teeSink) each(fn func(LogSink) logr.LogSink) *teeSink {
+	sinks := make([]LogSink, len(t.sinks))
+	for i, s := range t.sinks {
+		sinks[i] = fn(s).(LogSink)
+	}
+	return &teeSink{sinks: sinks}
+}
+
+// Underlying returns a logger whose core tees the cores of the sinks'
+// underlying loggers.
+func (t *teeSink) Underlying() *zap.Logger {
+	cores := make([]zapcore.Core, 0, len(t.sinks))
+	for _, s := range t.sinks {
+		if l := s.Underlying(); l != nil {
+			cores = append(cores, l.Core())
+		}
+	}
+	return zap.New(zapcore.NewTee(cores...))
+}
+
+func (t *teeSink) Flush() error {
+	var errs []error
+	for _, s := range t.sinks {
+		if err := s.Flush(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Reopen reopens the outputs of the sinks which implement Reopener.
+func (t *teeSink) Reopen() error {
+	var errs []error
+	for _, s := range t.sinks {
+		if r, ok := s.(Reopener); ok {
+			if err := r.Reopen(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
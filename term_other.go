@@ -0,0 +1,14 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package zapr
+
+import "os"
+
+// fileWidth returns the width of the terminal of the file, if any.
+func fileWidth(f *os.File) int { return 0 }
@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// Keys and values of entries logged by Timed.
+const (
+	OutcomeKey  = "outcome"
+	DurationKey = "duration"
+
+	OutcomeStarted   = "started"
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+)
+
+// TimedStartVerbosity is the verbosity at which the start of an operation
+// is logged by Timed.
+const TimedStartVerbosity = 2
+
+// Timed logs the start of an operation, if TimedStartVerbosity is enabled,
+// and returns a function that logs its completion with its duration and
+// outcome. If the operation failed, the function's error is logged as an
+// error. Each entry has the message and the keys and values.
+//
+//	done := zapr.Timed(log, "Syncing cache", "cache", name)
+//	err := syncCache()
+//	done(err)
+func Timed(log logr.Logger, msg string, keysAndValues ...interface{}) func(err error) {
+	log = log.WithValues(keysAndValues...)
+	log.WithCallDepth(1).V(TimedStartVerbosity).Info(msg, OutcomeKey, OutcomeStarted)
+	start := time.Now()
+	return func(err error) {
+		d := time.Since(start)
+		if err != nil {
+			log.WithCallDepth(1).Error(err, msg, OutcomeKey, OutcomeFailed, DurationKey, d)
+			return
+		}
+		log.WithCallDepth(1).Info(msg, OutcomeKey, OutcomeSucceeded, DurationKey, d)
+	}
+}
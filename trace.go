@@ -0,0 +1,42 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"context"
+	"runtime/trace"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// traceCore emits a runtime/trace log event for each entry,
+// if execution tracing is enabled.
+type traceCore struct {
+	zapcore.Core
+}
+
+func (c *traceCore) With(fields []zapcore.Field) zapcore.Core {
+	return &traceCore{Core: c.Core.With(fields)}
+}
+
+func (c *traceCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *traceCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if trace.IsEnabled() {
+		msg := ent.Message
+		if ent.LoggerName != "" {
+			msg = ent.LoggerName + ": " + msg
+		}
+		trace.Log(context.Background(), ent.Level.String(), msg)
+	}
+	return c.Core.Write(ent, fields)
+}
@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"encoding/base64"
+	"strconv"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// A TruncationObserver is an Observer which also observes truncated values.
+type TruncationObserver interface {
+	Observer
+
+	// ObserveValueTruncated observes a string or byte value which was
+	// truncated by the given number of bytes.
+	ObserveValueTruncated(bytes int)
+}
+
+// valueTruncator truncates string and byte values which exceed its length.
+type valueTruncator struct {
+	max      int
+	observer TruncationObserver
+}
+
+func newValueTruncator(max int, observer Observer) *valueTruncator {
+	if max <= 0 {
+		return nil
+	}
+	t := &valueTruncator{max: max}
+	t.observer, _ = observer.(TruncationObserver)
+	return t
+}
+
+// truncate returns the string cut short at a rune boundary, followed by
+// a marker of the number of bytes removed, if it exceeds the max length.
+func (t *valueTruncator) truncate(s string) (string, bool) {
+	if len(s) <= t.max {
+		return s, false
+	}
+	n := t.max
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + t.marker(len(s)-n), true
+}
+
+// marker observes the truncation of n bytes and returns its marker.
+func (t *valueTruncator) marker(n int) string {
+	if t.observer != nil {
+		t.observer.ObserveValueTruncated(n)
+	}
+	return "…(+" + strconv.Itoa(n) + " bytes)"
+}
+
+// truncateField returns the field with its value truncated and true,
+// or the field unmodified and false.
+func (t *valueTruncator) truncateField(f zapcore.Field) (zapcore.Field, bool) {
+	switch f.Type {
+	case zapcore.StringType:
+		if v, ok := t.truncate(f.String); ok {
+			return zap.String(f.Key, v), true
+		}
+	case zapcore.ByteStringType:
+		if v, ok := t.truncate(string(f.Interface.([]byte))); ok {
+			return zap.String(f.Key, v), true
+		}
+	case zapcore.BinaryType:
+		// Truncate the bytes before they're encoded as base64.
+		if b := f.Interface.([]byte); len(b) > t.max {
+			return zap.String(f.Key, base64.StdEncoding.EncodeToString(b[:t.max])+t.marker(len(b)-t.max)), true
+		}
+	case zapcore.ObjectMarshalerType, zapcore.InlineMarshalerType:
+		f.Interface = mappedObject{m: f.Interface.(zapcore.ObjectMarshaler), fn: t.truncateFields}
+		return f, true
+	}
+	return f, false
+}
+
+// truncateFields returns the fields with their string and byte values
+// truncated, including the fields of nested and inlined objects when
+// they're encoded. The given slice isn't modified.
+func (t *valueTruncator) truncateFields(fs []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, f := range fs {
+		f, ok := t.truncateField(f)
+		if ok && out == nil {
+			out = append(make([]zapcore.Field, 0, len(fs)), fs[:i]...)
+		}
+		if out != nil {
+			out = append(out, f)
+		}
+	}
+	if out == nil {
+		return fs
+	}
+	return out
+}
+
+// truncateCore truncates values before they're encoded by any output.
+type truncateCore struct {
+	zapcore.Core
+	t *valueTruncator
+}
+
+func (c *truncateCore) With(fields []zapcore.Field) zapcore.Core {
+	return &truncateCore{Core: c.Core.With(c.t.truncateFields(fields)), t: c.t}
+}
+
+func (c *truncateCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *truncateCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, c.t.truncateFields(fields))
+}
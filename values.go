@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// maxInterned is the maximum number of interned loggers.
+// The cache is cleared when it's full.
+const maxInterned = 1024
+
+// valuesLimit limits the values inherited by loggers. Since zap encodes values
+// as they're added, the limited values are retained separately so that the
+// logger may be rebuilt from its base without the oldest values.
+type valuesLimit struct {
+	max   int
+	evict bool
+
+	mu    sync.Mutex
+	cache map[internKey]*zap.Logger
+}
+
+type internKey struct {
+	base   *zap.Logger
+	fields string
+}
+
+func newValuesLimit(max int, evict bool) *valuesLimit {
+	if max <= 0 {
+		return nil
+	}
+	return &valuesLimit{
+		max:   max,
+		evict: evict,
+		cache: make(map[internKey]*zap.Logger),
+	}
+}
+
+// with returns the base logger with the fields, sharing the logger of an
+// identical chain of fields if it's been interned.
+func (l *valuesLimit) with(base *zap.Logger, fields []zapcore.Field) *zap.Logger {
+	key, ok := internFields(fields)
+	if !ok {
+		return base.With(fields...)
+	}
+	k := internKey{base: base, fields: key}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if logger, ok := l.cache[k]; ok {
+		return logger
+	}
+	if len(l.cache) >= maxInterned {
+		l.cache = make(map[internKey]*zap.Logger)
+	}
+	logger := base.With(fields...)
+	l.cache[k] = logger
+	return logger
+}
+
+// internFields returns a key which uniquely identifies the fields,
+// if they're all of simple types that can be compared by value.
+func internFields(fields []zapcore.Field) (string, bool) {
+	var b strings.Builder
+	for _, f := range fields {
+		switch f.Type {
+		case zapcore.BoolType, zapcore.DurationType,
+			zapcore.Float64Type, zapcore.Float32Type,
+			zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type,
+			zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type,
+			zapcore.StringType:
+		default:
+			return "", false
+		}
+		b.WriteString(strconv.Quote(f.Key))
+		b.WriteByte(byte(f.Type))
+		b.WriteString(strconv.FormatInt(f.Integer, 36))
+		b.WriteString(strconv.Quote(f.String))
+	}
+	return b.String(), true
+}
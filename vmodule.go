@@ -0,0 +1,164 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"flag"
+	"fmt"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// A VModuleRule raises the verbosity level for call sites in files matching
+// its pattern. If the pattern contains a slash, it's matched against the full
+// path of the file; otherwise it's matched against the file's base name. In
+// either case, a ".go" suffix is ignored. Patterns use the syntax of path.Match
+// (e.g. "gc*" or "*/server/*").
+type VModuleRule struct {
+	Pattern string
+	Level   int
+}
+
+func (r VModuleRule) String() string {
+	return r.Pattern + "=" + strconv.Itoa(r.Level)
+}
+
+// ParseVModule parses comma-separated rules in the style of glog's -vmodule
+// flag (e.g. "gc*=3,server.go=5").
+func ParseVModule(s string) ([]VModuleRule, error) {
+	var rules []VModuleRule
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		pattern, value, ok := strings.Cut(part, "=")
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("zapr: invalid vmodule rule: %q", part)
+		}
+		level, err := strconv.Atoi(value)
+		if err != nil || level < 0 {
+			return nil, fmt.Errorf("zapr: invalid vmodule level: %q", part)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("zapr: invalid vmodule pattern: %q: %w", pattern, err)
+		}
+		rules = append(rules, VModuleRule{Pattern: pattern, Level: level})
+	}
+	return rules, nil
+}
+
+// WithVModule returns an Option that raises the verbosity level for call sites
+// in files matching the rules, where the first matching rule applies. It's
+// evaluated once per call site and cached. There are no rules by default.
+func WithVModule(rules ...VModuleRule) Option {
+	rules = append([]VModuleRule(nil), rules...)
+	return opt{
+		applyFn: func(c *config) { c.vmodule = rules },
+		registerFn: func(fs *flag.FlagSet) {
+			fs.Var(&vmoduleFlag{&rules}, "log-vmodule", "Log verbosity levels per file (e.g. \"gc*=3,server.go=5\").")
+		},
+	}
+}
+
+type vmoduleFlag struct {
+	rules *[]VModuleRule
+}
+
+func (f *vmoduleFlag) Get() interface{} { return *f.rules }
+func (f *vmoduleFlag) Set(s string) error {
+	rules, err := ParseVModule(s)
+	if err != nil {
+		return err
+	}
+	*f.rules = rules
+	return nil
+}
+
+// AcceptsCommas reports that the value may contain commas.
+func (f *vmoduleFlag) AcceptsCommas() bool { return true }
+
+func (f *vmoduleFlag) String() string {
+	if f.rules == nil {
+		return ""
+	}
+	parts := make([]string, len(*f.rules))
+	for i, r := range *f.rules {
+		parts[i] = r.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// vmodule caches the verbosity levels of call sites.
+type vmodule struct {
+	rules []VModuleRule
+	max   int
+	cache sync.Map // map[uintptr]int
+}
+
+func newVModule(rules []VModuleRule) *vmodule {
+	if len(rules) == 0 {
+		return nil
+	}
+	v := &vmodule{rules: rules}
+	for _, r := range rules {
+		if r.Level > v.max {
+			v.max = r.Level
+		}
+	}
+	return v
+}
+
+// logrFrame marks call sites in the logr package, which are skipped since
+// some logr.Logger methods call others (e.g. Info calls Enabled).
+const logrFrame = -2
+
+// enabled returns true if the level is enabled for the caller identified by
+// skip, as with runtime.Callers.
+func (v *vmodule) enabled(level, skip int) bool {
+	if level > v.max {
+		return false
+	}
+	for ; ; skip++ {
+		var pcs [1]uintptr
+		if runtime.Callers(skip+1, pcs[:]) == 0 {
+			return false
+		}
+		max, ok := v.cache.Load(pcs[0])
+		if !ok {
+			frame, _ := runtime.CallersFrames(pcs[:]).Next()
+			if strings.HasPrefix(frame.Function, "github.com/go-logr/logr.") {
+				max = logrFrame
+			} else {
+				max = v.level(frame.File)
+			}
+			v.cache.Store(pcs[0], max)
+		}
+		if max != logrFrame {
+			return level <= max.(int)
+		}
+	}
+}
+
+// level returns the level of the first rule matching the file, or -1.
+func (v *vmodule) level(file string) int {
+	file = strings.TrimSuffix(file, ".go")
+	base := path.Base(file)
+	for _, r := range v.rules {
+		pattern := strings.TrimSuffix(r.Pattern, ".go")
+		name := base
+		if strings.Contains(pattern, "/") {
+			name = file
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return r.Level
+		}
+	}
+	return -1
+}
@@ -0,0 +1,31 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"github.com/go-logr/logr"
+	"go.uber.org/zap/zapcore"
+)
+
+// Warn returns a Logger whose Info entries are logged at zap's warn level,
+// for conditions which are unexpected but not errors. Verbosity is unchanged,
+// so Warn(log).V(1).Info logs a warning only if V(1) is enabled. If the
+// Logger's LogSink isn't from this package, it's returned unchanged.
+//
+// By convention, a Logger used for warnings is derived from another Logger
+// at the point of use rather than stored:
+//
+//	zapr.Warn(log).Info("Deprecated option set", "option", name)
+func Warn(log logr.Logger) logr.Logger {
+	s, ok := log.GetSink().(*sink)
+	if !ok || s.infoZap == zapcore.WarnLevel {
+		return log
+	}
+	v := *s
+	v.infoZap = zapcore.WarnLevel
+	return log.WithSink(&v)
+}
@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zapr
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+	"unicode/utf8"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// wrapIndent is the indentation of continuation lines.
+const wrapIndent = "    "
+
+var wrapPool = buffer.NewPool()
+
+// wrapEncoder wraps lines of encoded entries that are wider than width,
+// preferably after spaces or commas, and indents continuation lines.
+type wrapEncoder struct {
+	zapcore.Encoder
+	width int
+}
+
+func (enc *wrapEncoder) Clone() zapcore.Encoder {
+	return &wrapEncoder{Encoder: enc.Encoder.Clone(), width: enc.width}
+}
+
+func (enc *wrapEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	buf, err := enc.Encoder.EncodeEntry(ent, fields)
+	if err != nil {
+		return buf, err
+	}
+	out := wrapPool.Get()
+	b := buf.Bytes()
+	for len(b) > 0 {
+		line := b
+		if i := bytes.IndexByte(b, '\n'); i >= 0 {
+			line, b = b[:i+1], b[i+1:]
+		} else {
+			b = nil
+		}
+		wrapLine(out, line, enc.width)
+	}
+	buf.Free()
+	return out, nil
+}
+
+// wrapLine appends the line to buf, wrapped at the width. Tabs advance to the
+// next multiple of eight columns and ANSI escape sequences have no width.
+func wrapLine(buf *buffer.Buffer, line []byte, width int) {
+	col, start, brk := 0, 0, -1
+	for i := 0; i < len(line); {
+		c := line[i]
+		switch {
+		case c == '\x1b':
+			if k := bytes.IndexByte(line[i:], 'm'); k >= 0 {
+				i += k + 1
+				continue
+			}
+		case c == '\n':
+			i++
+			continue
+		case c == '\t':
+			col += 8 - col%8
+		default:
+			col++
+		}
+		_, size := utf8.DecodeRune(line[i:])
+		if col > width && i > start {
+			end := i
+			if brk > start {
+				end = brk
+			}
+			buf.Write(bytes.TrimRight(line[start:end], " "))
+			buf.AppendByte('\n')
+			buf.AppendString(wrapIndent)
+			start, brk = end, -1
+			for start < len(line) && line[start] == ' ' {
+				start++
+			}
+			col = len(wrapIndent)
+			i = start
+			continue
+		}
+		i += size
+		if c == ' ' || c == ',' {
+			brk = i
+		}
+	}
+	buf.Write(line[start:])
+}
+
+// terminalWidth returns the width of the terminal, from the COLUMNS
+// environment variable or stderr. It returns zero if it's unknown.
+func terminalWidth() int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	return fileWidth(os.Stderr)
+}
@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zaprbucket
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const gcsMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// GCSConfig is the configuration of a Google Cloud Storage Uploader.
+type GCSConfig struct {
+	// Bucket is the name of the bucket.
+	Bucket string
+
+	// Endpoint is the base URL of the service.
+	// The default is "https://storage.googleapis.com".
+	Endpoint string
+
+	// Token returns an OAuth 2.0 access token. By default, the token is
+	// $GOOGLE_OAUTH_ACCESS_TOKEN or is fetched from the metadata server.
+	Token func(ctx context.Context) (string, error)
+
+	// Client is the HTTP client. The default is http.DefaultClient.
+	Client *http.Client
+}
+
+// NewGCS returns an Uploader which puts objects in a Google Cloud Storage
+// bucket using the JSON API's simple upload.
+func NewGCS(cfg GCSConfig) (Uploader, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("zapr: missing GCS bucket")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://storage.googleapis.com"
+	}
+	if u, err := url.Parse(cfg.Endpoint); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("zapr: invalid GCS endpoint: %q", cfg.Endpoint)
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	if cfg.Token == nil {
+		if tok := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); tok != "" {
+			cfg.Token = func(context.Context) (string, error) { return tok, nil }
+		} else {
+			cfg.Token = (&metadataToken{client: cfg.Client}).get
+		}
+	}
+	return &gcsUploader{cfg: cfg}, nil
+}
+
+type gcsUploader struct {
+	cfg GCSConfig
+}
+
+func (g *gcsUploader) Upload(ctx context.Context, key string, body []byte) error {
+	tok, err := g.cfg.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("zapr: failed to get GCS token: %w", err)
+	}
+	u := strings.TrimSuffix(g.cfg.Endpoint, "/") + "/upload/storage/v1/b/" + url.PathEscape(g.cfg.Bucket) +
+		"/o?" + url.Values{"uploadType": {"media"}, "name": {key}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+tok)
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	resp, err := g.cfg.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("zapr: failed to upload GCS object: %w", err)
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("zapr: failed to upload GCS object: %s: %s", resp.Status, msg)
+	}
+	return nil
+}
+
+// metadataToken caches access tokens from the GCE metadata server.
+type metadataToken struct {
+	client *http.Client
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+func (m *metadataToken) get(ctx context.Context) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.token != "" && time.Now().Before(m.expiry) {
+		return m.token, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gcsMetadataTokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata server: %s", resp.Status)
+	}
+	var v struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		return "", err
+	}
+	// Refresh a minute early.
+	m.token, m.expiry = v.AccessToken, time.Now().Add(time.Duration(v.ExpiresIn)*time.Second-time.Minute)
+	return m.token, nil
+}
@@ -0,0 +1,511 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package zaprbucket provides an output for zapr which buffers entries into
+// rotating local segment files and uploads completed segments to an object
+// storage bucket, such as Amazon S3 or Google Cloud Storage.
+//
+// Importing the package registers the "s3" and "gs" output schemes,
+// whose host is the bucket and whose path is the object key prefix:
+//
+//	s3://my-bucket/logs/%Y/%m/%d/?dir=/var/spool/app&region=us-west-2
+//	gs://my-bucket/logs/%Y/%m/%d/?dir=/var/spool/app&segment-interval=1m
+//
+// The following query parameters are supported:
+//
+//	dir               directory in which segments are buffered (required)
+//	segment-size      maximum number of bytes per segment (default 64MiB)
+//	segment-interval  maximum duration of a segment (default "5m")
+//	retain            number of uploaded segments kept in dir (default 0)
+//	upload-timeout    maximum duration of an upload (default "1m")
+//	close-timeout     maximum duration of uploads upon close (default "10s")
+//	endpoint          base URL of the storage service (e.g. "http://localhost:9000")
+//	region            S3 region (default $AWS_REGION or "us-east-1")
+package zaprbucket
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"bursavich.dev/zapr/output"
+)
+
+func init() {
+	must(output.RegisterScheme("s3", openURL))
+	must(output.RegisterScheme("gs", openURL))
+}
+
+func openURL(u *url.URL) (output.Output, error) {
+	q := u.Query()
+	cfg := Config{
+		Dir:    q.Get("dir"),
+		Prefix: strings.TrimPrefix(u.Path, "/"),
+	}
+	if s := q.Get("segment-size"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("zapr: invalid bucket segment-size: %q: %w", s, err)
+		}
+		cfg.SegmentSize = n
+	}
+	if s := q.Get("segment-interval"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("zapr: invalid bucket segment-interval: %q: %w", s, err)
+		}
+		cfg.SegmentInterval = d
+	}
+	if s := q.Get("retain"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("zapr: invalid bucket retain: %q: %w", s, err)
+		}
+		cfg.Retain = n
+	}
+	if s := q.Get("upload-timeout"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("zapr: invalid bucket upload-timeout: %q: %w", s, err)
+		}
+		cfg.UploadTimeout = d
+	}
+	if s := q.Get("close-timeout"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("zapr: invalid bucket close-timeout: %q: %w", s, err)
+		}
+		cfg.CloseTimeout = d
+	}
+	var err error
+	switch u.Scheme {
+	case "s3":
+		cfg.Uploader, err = NewS3(S3Config{
+			Bucket:   u.Host,
+			Region:   q.Get("region"),
+			Endpoint: q.Get("endpoint"),
+		})
+	case "gs":
+		cfg.Uploader, err = NewGCS(GCSConfig{
+			Bucket:   u.Host,
+			Endpoint: q.Get("endpoint"),
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return New(cfg)
+}
+
+// An Uploader uploads objects to a bucket.
+type Uploader interface {
+	// Upload uploads the body as the object with the given key.
+	Upload(ctx context.Context, key string, body []byte) error
+}
+
+// UploaderFunc is a function that implements Uploader.
+type UploaderFunc func(ctx context.Context, key string, body []byte) error
+
+// Upload calls fn(ctx, key, body).
+func (fn UploaderFunc) Upload(ctx context.Context, key string, body []byte) error {
+	return fn(ctx, key, body)
+}
+
+// Config is the configuration of a bucket output.
+type Config struct {
+	// Dir is the directory in which segments are buffered. It's created if it
+	// doesn't exist and it shouldn't be shared by multiple outputs.
+	Dir string
+
+	// Uploader uploads completed segments.
+	Uploader Uploader
+
+	// Prefix is prepended to the name of each segment to form its object key.
+	// It may contain the following directives, which are replaced with the
+	// UTC start time of the segment or the host name:
+	//
+	//	%Y  four digit year
+	//	%m  two digit month
+	//	%d  two digit day of month
+	//	%H  two digit hour
+	//	%h  host name
+	//	%%  literal percent sign
+	Prefix string
+
+	// SegmentSize is the maximum number of bytes per segment.
+	// The default is 64MiB.
+	SegmentSize int64
+
+	// SegmentInterval is the maximum duration of a segment.
+	// The default is 5m.
+	SegmentInterval time.Duration
+
+	// Retain is the number of uploaded segments kept in Dir.
+	// By default, segments are removed once they're uploaded.
+	Retain int
+
+	// UploadTimeout is the maximum duration of an upload, after which it's
+	// canceled and retried. The default is 1m.
+	UploadTimeout time.Duration
+
+	// CloseTimeout is the maximum duration of uploads when the Output is
+	// closed, after which they're canceled. The default is 10s.
+	CloseTimeout time.Duration
+}
+
+const (
+	segmentPrefix   = "segment-"
+	segmentSuffix   = ".log"
+	uploadedSuffix  = ".uploaded"
+	minBackoff      = 100 * time.Millisecond
+	maxBackoff      = 10 * time.Second
+	defaultInterval = 5 * time.Minute
+	defaultUpload   = time.Minute
+	defaultClose    = 10 * time.Second
+)
+
+// New returns an Output that writes entries to segment files in a local
+// directory and uploads them in the background once they're complete.
+// A segment is complete when it reaches the maximum size or duration,
+// or when the Output is closed. Failed uploads are retried with backoff
+// and segments left by a previous process are uploaded as well. If the
+// Output is observed, it reports events for the "bucket" output.
+func New(cfg Config) (output.Output, error) {
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("zapr: missing bucket segment directory")
+	}
+	if cfg.Uploader == nil {
+		return nil, fmt.Errorf("zapr: missing bucket Uploader")
+	}
+	if err := validatePrefix(cfg.Prefix); err != nil {
+		return nil, err
+	}
+	if cfg.SegmentSize <= 0 {
+		cfg.SegmentSize = 64 << 20
+	}
+	if cfg.SegmentInterval <= 0 {
+		cfg.SegmentInterval = defaultInterval
+	}
+	if cfg.UploadTimeout <= 0 {
+		cfg.UploadTimeout = defaultUpload
+	}
+	if cfg.CloseTimeout <= 0 {
+		cfg.CloseTimeout = defaultClose
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("zapr: failed to create bucket segment directory: %w", err)
+	}
+	pending, err := filepath.Glob(filepath.Join(cfg.Dir, segmentPrefix+"*"+segmentSuffix))
+	if err != nil {
+		return nil, fmt.Errorf("zapr: failed to read bucket segment directory: %w", err)
+	}
+	sort.Strings(pending) // names have fixed-width timestamps
+	host, _ := os.Hostname()
+	ctx, cancel := context.WithCancel(context.Background())
+	o := &bucketOutput{
+		cfg:     cfg,
+		host:    host,
+		now:     time.Now,
+		ctx:     ctx,
+		cancel:  cancel,
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		pending: pending,
+	}
+	o.wg.Add(1)
+	go o.run()
+	o.signal()
+	return o, nil
+}
+
+type bucketOutput struct {
+	cfg    Config
+	host   string
+	now    func() time.Time
+	ctx    context.Context
+	cancel context.CancelFunc
+	wake   chan struct{}
+	done   chan struct{} // closed by Close
+	wg     sync.WaitGroup
+
+	mu       sync.Mutex
+	f        *os.File
+	size     int64
+	timer    *time.Timer
+	pending  []string // completed segments
+	closed   bool
+	err      error
+	observer output.Observer
+}
+
+func (o *bucketOutput) Observe(observer output.Observer) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.observer = observer
+}
+
+func (o *bucketOutput) observe(event string) {
+	o.mu.Lock()
+	observer := o.observer
+	o.mu.Unlock()
+	if observer != nil {
+		observer.ObserveOutputEvent("bucket", event)
+	}
+}
+
+func (o *bucketOutput) signal() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (o *bucketOutput) Write(b []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if o.f == nil {
+		name := filepath.Join(o.cfg.Dir, fmt.Sprintf("%s%020d%s", segmentPrefix, o.now().UnixNano(), segmentSuffix))
+		f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return 0, fmt.Errorf("zapr: failed to create bucket segment: %w", err)
+		}
+		o.f, o.size = f, 0
+		if o.timer == nil {
+			o.timer = time.AfterFunc(o.cfg.SegmentInterval, o.rotateTimer)
+		} else {
+			o.timer.Reset(o.cfg.SegmentInterval)
+		}
+	}
+	n, err := o.f.Write(b)
+	if o.size += int64(n); o.size >= o.cfg.SegmentSize {
+		o.complete()
+	}
+	return n, err
+}
+
+func (o *bucketOutput) rotateTimer() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if !o.closed {
+		o.complete()
+	}
+}
+
+// complete closes the current segment and queues it for upload.
+// It must be called with the lock held.
+func (o *bucketOutput) complete() {
+	if o.f == nil {
+		return
+	}
+	if o.timer != nil {
+		o.timer.Stop()
+	}
+	o.f.Close()
+	o.pending = append(o.pending, o.f.Name())
+	o.f = nil
+	o.signal()
+}
+
+func (o *bucketOutput) Sync() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.f == nil {
+		return nil
+	}
+	return o.f.Sync()
+}
+
+// Close completes the current segment and attempts to upload pending segments
+// without retries, canceling them after the close timeout. Segments which fail
+// to upload are left in the directory.
+func (o *bucketOutput) Close() error {
+	o.mu.Lock()
+	if o.closed {
+		o.mu.Unlock()
+		return nil
+	}
+	o.complete()
+	o.closed = true
+	close(o.done)
+	o.mu.Unlock()
+
+	t := time.AfterFunc(o.cfg.CloseTimeout, o.cancel)
+	o.wg.Wait()
+	t.Stop()
+	o.cancel()
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.err
+}
+
+func (o *bucketOutput) run() {
+	defer o.wg.Done()
+	backoff := minBackoff
+	for {
+		select {
+		case <-o.wake:
+		case <-o.done:
+		}
+		if o.isClosed() {
+			for name, ok := o.next(); ok; name, ok = o.next() {
+				if err := o.upload(name); err != nil {
+					o.fail(err)
+					o.pop() // leave it for the next process
+				}
+			}
+			return
+		}
+		for name, ok := o.next(); ok && !o.isClosed(); name, ok = o.next() {
+			if err := o.upload(name); err == nil {
+				backoff = minBackoff
+				continue
+			}
+			o.observe(output.EventRetry)
+			t := time.NewTimer(backoff)
+			select {
+			case <-t.C:
+			case <-o.done:
+				t.Stop()
+			}
+			if backoff *= 2; backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		}
+	}
+}
+
+func (o *bucketOutput) isClosed() bool {
+	select {
+	case <-o.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// next returns the oldest pending segment, if any.
+func (o *bucketOutput) next() (string, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.pending) == 0 {
+		return "", false
+	}
+	return o.pending[0], true
+}
+
+func (o *bucketOutput) pop() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.pending = o.pending[1:]
+}
+
+func (o *bucketOutput) fail(err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.err = err
+}
+
+// upload uploads the segment and, if it succeeds, removes it from the queue.
+func (o *bucketOutput) upload(name string) error {
+	body, err := os.ReadFile(name)
+	if err != nil {
+		// It's unreadable, so there's no point in retrying.
+		o.observe(output.EventDropped)
+		o.pop()
+		return nil
+	}
+	base := filepath.Base(name)
+	ns, _ := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(base, segmentPrefix), segmentSuffix), 10, 64)
+	key := formatPrefix(o.cfg.Prefix, time.Unix(0, ns).UTC(), o.host) + base
+	if len(body) > 0 {
+		ctx, cancel := context.WithTimeout(o.ctx, o.cfg.UploadTimeout)
+		err := o.cfg.Uploader.Upload(ctx, key, body)
+		cancel()
+		if err != nil {
+			o.observe(output.EventError)
+			return err
+		}
+	}
+	o.pop()
+	if o.cfg.Retain <= 0 {
+		os.Remove(name)
+		return nil
+	}
+	os.Rename(name, name+uploadedSuffix)
+	o.prune()
+	return nil
+}
+
+// prune removes the oldest uploaded segments beyond the number retained.
+func (o *bucketOutput) prune() {
+	names, err := filepath.Glob(filepath.Join(o.cfg.Dir, segmentPrefix+"*"+segmentSuffix+uploadedSuffix))
+	if err != nil || len(names) <= o.cfg.Retain {
+		return
+	}
+	sort.Strings(names)
+	for _, name := range names[:len(names)-o.cfg.Retain] {
+		os.Remove(name)
+	}
+}
+
+func validatePrefix(prefix string) error {
+	for i := 0; i < len(prefix); i++ {
+		if prefix[i] != '%' {
+			continue
+		}
+		if i++; i == len(prefix) || !strings.ContainsRune("YmdHh%", rune(prefix[i])) {
+			return fmt.Errorf("zapr: invalid bucket prefix template: %q", prefix)
+		}
+	}
+	return nil
+}
+
+// formatPrefix formats the prefix template for the time and host.
+func formatPrefix(prefix string, t time.Time, host string) string {
+	if !strings.Contains(prefix, "%") {
+		return prefix
+	}
+	var b strings.Builder
+	for i := 0; i < len(prefix); i++ {
+		if c := prefix[i]; c != '%' || i+1 == len(prefix) {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch prefix[i] {
+		case 'Y':
+			b.WriteString(t.Format("2006"))
+		case 'm':
+			b.WriteString(t.Format("01"))
+		case 'd':
+			b.WriteString(t.Format("02"))
+		case 'H':
+			b.WriteString(t.Format("15"))
+		case 'h':
+			b.WriteString(host)
+		default:
+			b.WriteByte(prefix[i])
+		}
+	}
+	return b.String()
+}
+
+func must(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,232 @@
+package zaprbucket
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFormatPrefix(t *testing.T) {
+	date := time.Date(2023, 6, 1, 13, 0, 0, 0, time.UTC)
+	if want, got := "logs/2023/06/01/13/web-1/%", formatPrefix("logs/%Y/%m/%d/%H/%h/%%", date, "web-1"); got != want {
+		t.Errorf("unexpected prefix: want: %q; got: %q", want, got)
+	}
+	if err := validatePrefix("logs/%M"); err == nil {
+		t.Error("expected error for invalid prefix template")
+	}
+}
+
+type uploads struct {
+	mu      sync.Mutex
+	objects map[string]string
+	fail    bool
+}
+
+func (u *uploads) Upload(ctx context.Context, key string, body []byte) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.fail {
+		return io.ErrUnexpectedEOF
+	}
+	u.objects[key] = string(body)
+	return nil
+}
+
+func (u *uploads) len() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return len(u.objects)
+}
+
+func TestOutput(t *testing.T) {
+	dir := t.TempDir()
+	up := &uploads{objects: make(map[string]string)}
+	out, err := New(Config{
+		Dir:         dir,
+		Uploader:    up,
+		Prefix:      "logs/%Y/",
+		SegmentSize: 8,
+		Retain:      1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"aaaa\n", "bbbb\n", "cccc\n"} {
+		if _, err := out.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := out.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 2, up.len(); got != want {
+		t.Fatalf("unexpected uploads: want: %d; got: %d", want, got)
+	}
+	var bodies []string
+	for key, body := range up.objects {
+		if prefix := "logs/" + time.Now().UTC().Format("2006") + "/segment-"; !strings.HasPrefix(key, prefix) {
+			t.Errorf("unexpected key: want prefix: %q; got: %q", prefix, key)
+		}
+		bodies = append(bodies, body)
+	}
+	if all := strings.Join(bodies, ""); len(all) != 15 || !strings.Contains(all, "aaaa\nbbbb\n") {
+		t.Errorf("unexpected bodies: %q", bodies)
+	}
+	names, _ := filepath.Glob(filepath.Join(dir, "*"))
+	if len(names) != 1 || !strings.HasSuffix(names[0], uploadedSuffix) {
+		t.Errorf("unexpected retained segments: %q", names)
+	}
+}
+
+func TestOutputPending(t *testing.T) {
+	dir := t.TempDir()
+	up := &uploads{objects: make(map[string]string), fail: true}
+	out, err := New(Config{Dir: dir, Uploader: up})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := out.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := out.Close(); err == nil {
+		t.Error("expected upload error")
+	}
+
+	// The segment is uploaded by the next output.
+	up.mu.Lock()
+	up.fail = false
+	up.mu.Unlock()
+	if out, err = New(Config{Dir: dir, Uploader: up}); err != nil {
+		t.Fatal(err)
+	}
+	if err := out.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 1, up.len(); got != want {
+		t.Errorf("unexpected uploads: want: %d; got: %d", want, got)
+	}
+	if names, _ := filepath.Glob(filepath.Join(dir, "*")); len(names) != 0 {
+		t.Errorf("unexpected segments: %q", names)
+	}
+}
+
+func TestOutputTimeout(t *testing.T) {
+	dir := t.TempDir()
+	deadlines := make(chan time.Time, 1)
+	up := UploaderFunc(func(ctx context.Context, key string, body []byte) error {
+		deadline, _ := ctx.Deadline()
+		select {
+		case deadlines <- deadline:
+		default:
+		}
+		<-ctx.Done() // hang until canceled
+		return ctx.Err()
+	})
+	out, err := New(Config{Dir: dir, Uploader: up, UploadTimeout: time.Hour, CloseTimeout: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := out.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if err := out.Close(); err == nil {
+		t.Error("expected upload error")
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("unexpected close duration: %v", d)
+	}
+	if deadline := <-deadlines; deadline.IsZero() || deadline.After(time.Now().Add(time.Hour)) {
+		t.Errorf("unexpected upload deadline: %v", deadline)
+	}
+	if names, _ := filepath.Glob(filepath.Join(dir, "*")); len(names) != 1 {
+		t.Errorf("unexpected segments: %q", names)
+	}
+}
+
+func TestS3(t *testing.T) {
+	var path, auth, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		path, auth, body = r.URL.EscapedPath(), r.Header.Get("Authorization"), string(b)
+	}))
+	defer srv.Close()
+
+	up, err := NewS3(S3Config{
+		Bucket:          "logs",
+		Region:          "us-west-2",
+		Endpoint:        srv.URL,
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := up.Upload(context.Background(), "app/a b.log", []byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "/logs/app/a%20b.log", path; got != want {
+		t.Errorf("unexpected path: want: %q; got: %q", want, got)
+	}
+	if want := "AWS4-HMAC-SHA256 Credential=AKID/"; !strings.HasPrefix(auth, want) || !strings.Contains(auth, "/us-west-2/s3/aws4_request") {
+		t.Errorf("unexpected authorization: %q", auth)
+	}
+	if want, got := "hello\n", body; got != want {
+		t.Errorf("unexpected body: want: %q; got: %q", want, got)
+	}
+}
+
+func TestS3Signature(t *testing.T) {
+	up, err := NewS3(S3Config{Bucket: "examplebucket", AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := up.(*s3Uploader)
+	s.now = func() time.Time { return time.Date(2013, 5, 24, 0, 0, 0, 0, time.UTC) }
+	req, _ := http.NewRequest(http.MethodPut, "https://examplebucket.s3.us-east-1.amazonaws.com/test.txt", nil)
+	req.Header.Set("Content-Type", "text/plain")
+	s.sign(req, []byte("Welcome to Amazon S3."))
+	if want, got := "20130524T000000Z", req.Header.Get("X-Amz-Date"); got != want {
+		t.Errorf("unexpected date: want: %q; got: %q", want, got)
+	}
+	want := "AWS4-HMAC-SHA256 Credential=AKID/20130524/us-east-1/s3/aws4_request, " +
+		"SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date, Signature="
+	if got := req.Header.Get("Authorization"); !strings.HasPrefix(got, want) || len(got) != len(want)+64 {
+		t.Errorf("unexpected authorization: want prefix: %q; got: %q", want, got)
+	}
+}
+
+func TestGCS(t *testing.T) {
+	var name, auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if want, got := "/upload/storage/v1/b/logs/o", r.URL.Path; got != want {
+			t.Errorf("unexpected path: want: %q; got: %q", want, got)
+		}
+		name, auth = r.URL.Query().Get("name"), r.Header.Get("Authorization")
+	}))
+	defer srv.Close()
+
+	os.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "token")
+	defer os.Unsetenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+	up, err := NewGCS(GCSConfig{Bucket: "logs", Endpoint: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := up.Upload(context.Background(), "app/segment.log", []byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "app/segment.log", name; got != want {
+		t.Errorf("unexpected name: want: %q; got: %q", want, got)
+	}
+	if want, got := "Bearer token", auth; got != want {
+		t.Errorf("unexpected authorization: want: %q; got: %q", want, got)
+	}
+}
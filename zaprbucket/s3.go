@@ -0,0 +1,179 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zaprbucket
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// S3Config is the configuration of an S3 Uploader.
+type S3Config struct {
+	// Bucket is the name of the bucket.
+	Bucket string
+
+	// Region is the region of the bucket.
+	// The default is $AWS_REGION or "us-east-1".
+	Region string
+
+	// Endpoint is the base URL of an S3-compatible service, in which case
+	// path-style requests are used. By default, virtual-hosted-style requests
+	// are sent to Amazon S3.
+	Endpoint string
+
+	// AccessKeyID, SecretAccessKey, and SessionToken are the credentials.
+	// The defaults are $AWS_ACCESS_KEY_ID, $AWS_SECRET_ACCESS_KEY, and
+	// $AWS_SESSION_TOKEN.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// Client is the HTTP client. The default is http.DefaultClient.
+	Client *http.Client
+}
+
+// NewS3 returns an Uploader which puts objects in an S3 bucket,
+// signing requests with AWS Signature Version 4.
+func NewS3(cfg S3Config) (Uploader, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("zapr: missing S3 bucket")
+	}
+	if cfg.Region == "" {
+		if cfg.Region = os.Getenv("AWS_REGION"); cfg.Region == "" {
+			cfg.Region = "us-east-1"
+		}
+	}
+	if cfg.AccessKeyID == "" && cfg.SecretAccessKey == "" {
+		cfg.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		cfg.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		cfg.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("zapr: missing S3 credentials")
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	base := &url.URL{Scheme: "https", Host: cfg.Bucket + ".s3." + cfg.Region + ".amazonaws.com", Path: "/"}
+	if cfg.Endpoint != "" {
+		u, err := url.Parse(cfg.Endpoint)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, fmt.Errorf("zapr: invalid S3 endpoint: %q", cfg.Endpoint)
+		}
+		base = &url.URL{Scheme: u.Scheme, Host: u.Host, Path: strings.TrimSuffix(u.Path, "/") + "/" + cfg.Bucket + "/"}
+	}
+	return &s3Uploader{cfg: cfg, base: base, now: time.Now}, nil
+}
+
+type s3Uploader struct {
+	cfg  S3Config
+	base *url.URL
+	now  func() time.Time
+}
+
+func (s *s3Uploader) Upload(ctx context.Context, key string, body []byte) error {
+	u := *s.base
+	u.Path += key
+	u.RawPath = s3EscapePath(u.Path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	s.sign(req, body)
+	resp, err := s.cfg.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("zapr: failed to upload S3 object: %w", err)
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("zapr: failed to upload S3 object: %s: %s", resp.Status, msg)
+	}
+	return nil
+}
+
+// sign adds an AWS Signature Version 4 authorization header to the request.
+func (s *s3Uploader) sign(req *http.Request, body []byte) {
+	now := s.now().UTC()
+	date := now.Format("20060102")
+	stamp := now.Format("20060102T150405Z")
+	sum := sha256.Sum256(body)
+	payload := hex.EncodeToString(sum[:])
+
+	req.Header.Set("X-Amz-Content-Sha256", payload)
+	req.Header.Set("X-Amz-Date", stamp)
+	if s.cfg.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.cfg.SessionToken)
+	}
+	names := []string{"content-type", "host", "x-amz-content-sha256", "x-amz-date"}
+	if s.cfg.SessionToken != "" {
+		names = append(names, "x-amz-security-token")
+	}
+	var headers strings.Builder
+	for _, name := range names {
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = req.URL.Host
+		}
+		headers.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	signed := strings.Join(names, ";")
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		headers.String(),
+		signed,
+		payload,
+	}, "\n")
+	scope := date + "/" + s.cfg.Region + "/s3/aws4_request"
+	sum = sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + stamp + "\n" + scope + "\n" + hex.EncodeToString(sum[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretAccessKey), date)
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	sig := hex.EncodeToString(hmacSHA256(key, toSign))
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.AccessKeyID, scope, signed, sig,
+	))
+}
+
+// s3EscapePath escapes all but unreserved characters and slashes, as required
+// by the canonical request.
+func s3EscapePath(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		switch c := path[i]; {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
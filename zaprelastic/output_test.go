@@ -0,0 +1,82 @@
+package zaprelastic
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bursavich.dev/zapr"
+)
+
+func TestFormatIndex(t *testing.T) {
+	date := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	if want, got := "logs-2023.06.01%", formatIndex("logs-%Y.%m.%d%%", date); got != want {
+		t.Errorf("unexpected index: want: %q; got: %q", want, got)
+	}
+	if err := validateIndex("logs-%H"); err == nil {
+		t.Error("expected error for invalid index pattern")
+	}
+}
+
+func TestOutput(t *testing.T) {
+	var lines []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if want, got := "/_bulk", r.URL.Path; got != want {
+			t.Errorf("unexpected path: want: %q; got: %q", want, got)
+		}
+		if user, pass, _ := r.BasicAuth(); user != "user" || pass != "pass" {
+			t.Errorf("unexpected basic auth: %q:%q", user, pass)
+		}
+		sc := bufio.NewScanner(r.Body)
+		for sc.Scan() {
+			lines = append(lines, sc.Text())
+		}
+		w.Write([]byte(`{"errors":true,"items":[{"create":{"status":201}},{"create":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"bad"}}}]}`))
+	}))
+	defer srv.Close()
+
+	out, err := New(Config{URL: srv.URL, Index: "app-%Y", Username: "user", Password: "pass"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	log, sink := zapr.NewLogger(zapr.WithWriteSyncer(out))
+	log.Info("hello")
+	log.Info("world")
+	err = sink.Flush()
+	if want := "rejected 1 Elasticsearch documents"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("unexpected error: want: %q; got: %v", want, err)
+	}
+
+	t.Log("\n" + strings.Join(lines, "\n")) // help debugging
+	if len(lines) != 4 {
+		t.Fatalf("unexpected lines: want: 4; got: %d", len(lines))
+	}
+	wantIndex := "app-" + time.Now().UTC().Format("2006")
+	for i, want := range []string{"hello", "world"} {
+		var action struct {
+			Create struct {
+				Index string `json:"_index"`
+			} `json:"create"`
+		}
+		if err := json.Unmarshal([]byte(lines[2*i]), &action); err != nil {
+			t.Fatal(err)
+		}
+		if got := action.Create.Index; got != wantIndex {
+			t.Errorf("unexpected index: want: %q; got: %q", wantIndex, got)
+		}
+		var doc struct {
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal([]byte(lines[2*i+1]), &doc); err != nil {
+			t.Fatal(err)
+		}
+		if got := doc.Message; got != want {
+			t.Errorf("unexpected message: want: %q; got: %q", want, got)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package zaprfluent provides a Fluentd forward protocol output for zapr,
+// which is supported by Fluentd and Fluent Bit.
+//
+// Importing the package registers the "fluent+tcp" and "fluent+unix" output
+// schemes:
+//
+//	fluent+tcp://localhost:24224?tag=app&ack=true
+//	fluent+unix:///var/run/fluent.sock?tag=app
+//
+// The following query parameters are supported:
+//
+//	tag  tag of events (default "zapr")
+//	ack  whether to wait for the server to acknowledge each event
+package zaprfluent
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"bursavich.dev/zapr/output"
+)
+
+// DefaultTag is the default tag of events.
+const DefaultTag = "zapr"
+
+const (
+	dialTimeout = 5 * time.Second
+	ackTimeout  = 10 * time.Second
+)
+
+func init() {
+	must(output.RegisterScheme("fluent+tcp", func(u *url.URL) (output.Output, error) {
+		return openURL("tcp", u.Host, u.Query())
+	}))
+	must(output.RegisterScheme("fluent+unix", func(u *url.URL) (output.Output, error) {
+		return openURL("unix", u.Path, u.Query())
+	}))
+}
+
+func openURL(network, addr string, q url.Values) (output.Output, error) {
+	cfg := Config{
+		Network: network,
+		Address: addr,
+		Tag:     q.Get("tag"),
+	}
+	if s := q.Get("ack"); s != "" {
+		ack, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("zapr: invalid Fluent ack: %q: %w", s, err)
+		}
+		cfg.Ack = ack
+	}
+	return Dial(cfg)
+}
+
+// Config is the configuration of a Fluent forward protocol output.
+type Config struct {
+	// Network is "tcp" or "unix".
+	Network string
+
+	// Address is the server address or socket path.
+	Address string
+
+	// Tag is the tag of events. The default is DefaultTag.
+	Tag string
+
+	// Ack enables waiting for the server to acknowledge each event.
+	Ack bool
+}
+
+// Dial returns an Output that sends each write to the server as an event in
+// the forward protocol's message mode. Entries that are valid JSON objects,
+// such as those from the JSON encoder, are sent as records and others are
+// sent in the "message" field. If a write fails, including a missing or
+// mismatched acknowledgment, it reconnects and retries once.
+func Dial(cfg Config) (output.Output, error) {
+	if cfg.Tag == "" {
+		cfg.Tag = DefaultTag
+	}
+	o := &forwardOutput{cfg: cfg, now: time.Now}
+	if err := o.dial(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+type forwardOutput struct {
+	cfg Config
+	now func() time.Time
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+	buf  []byte
+}
+
+func (o *forwardOutput) dial() error {
+	conn, err := net.DialTimeout(o.cfg.Network, o.cfg.Address, dialTimeout)
+	if err != nil {
+		return fmt.Errorf("zapr: failed to dial Fluent output: %w", err)
+	}
+	o.conn = conn
+	o.r = bufio.NewReader(conn)
+	return nil
+}
+
+func (o *forwardOutput) Write(b []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	chunk := ""
+	if o.cfg.Ack {
+		var id [16]byte
+		rand.Read(id[:])
+		chunk = base64.StdEncoding.EncodeToString(id[:])
+	}
+	o.buf = o.appendMessage(o.buf[:0], b, chunk)
+	for retry := 0; ; retry++ {
+		if o.conn == nil {
+			if err := o.dial(); err != nil {
+				return 0, err
+			}
+		}
+		err := o.send(chunk)
+		if err == nil {
+			return len(b), nil
+		}
+		o.conn.Close()
+		o.conn = nil
+		if retry > 0 {
+			return 0, err
+		}
+	}
+}
+
+func (o *forwardOutput) send(chunk string) error {
+	if chunk != "" {
+		o.conn.SetDeadline(time.Now().Add(ackTimeout))
+		defer o.conn.SetDeadline(time.Time{})
+	}
+	if _, err := o.conn.Write(o.buf); err != nil {
+		return err
+	}
+	if chunk == "" {
+		return nil
+	}
+	resp, err := readStringMap(o.r)
+	if err != nil {
+		return fmt.Errorf("zapr: failed to read Fluent ack: %w", err)
+	}
+	if resp["ack"] != chunk {
+		return fmt.Errorf("zapr: mismatched Fluent ack: %q", resp["ack"])
+	}
+	return nil
+}
+
+// appendMessage appends the entry as a message: [tag, time, record, option].
+func (o *forwardOutput) appendMessage(b, entry []byte, chunk string) []byte {
+	n := 3
+	if chunk != "" {
+		n = 4
+	}
+	b = appendArrayHeader(b, n)
+	b = appendString(b, o.cfg.Tag)
+	b = appendEventTime(b, o.now())
+	var record map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(entry))
+	dec.UseNumber()
+	if err := dec.Decode(&record); err != nil || record == nil || dec.More() {
+		record = map[string]interface{}{"message": string(bytes.TrimSpace(entry))}
+	}
+	b = appendJSON(b, record)
+	if chunk != "" {
+		b = appendMapHeader(b, 1)
+		b = appendString(b, "chunk")
+		b = appendString(b, chunk)
+	}
+	return b
+}
+
+func (o *forwardOutput) Sync() error { return nil }
+
+func (o *forwardOutput) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.conn == nil {
+		return nil
+	}
+	err := o.conn.Close()
+	o.conn = nil
+	return err
+}
+
+func must(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
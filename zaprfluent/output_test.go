@@ -0,0 +1,86 @@
+package zaprfluent
+
+import (
+	"bufio"
+	"net"
+	"testing"
+
+	"bursavich.dev/zapr"
+)
+
+type message struct {
+	tag    string
+	record map[string]string
+	option map[string]string
+}
+
+func readMessage(r *bufio.Reader) (*message, error) {
+	n, err := readHeader(r, 0x90, 0xdc, 0xdd)
+	if err != nil {
+		return nil, err
+	}
+	var m message
+	if m.tag, err = readString(r); err != nil {
+		return nil, err
+	}
+	if _, err := r.Discard(10); err != nil { // EventTime
+		return nil, err
+	}
+	if m.record, err = readStringMap(r); err != nil {
+		return nil, err
+	}
+	if n == 4 {
+		if m.option, err = readStringMap(r); err != nil {
+			return nil, err
+		}
+	}
+	return &m, nil
+}
+
+func TestOutputAck(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	msgs := make(chan *message, 2)
+	go func() {
+		for conns := 0; ; conns++ {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			r := bufio.NewReader(conn)
+			m, err := readMessage(r)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if conns == 0 {
+				conn.Close() // drop the first message without an ack
+				continue
+			}
+			msgs <- m
+			conn.Write(appendString(appendString(appendMapHeader(nil, 1), "ack"), m.option["chunk"]))
+			conn.Close()
+		}
+	}()
+
+	out, err := Dial(Config{Network: "tcp", Address: ln.Addr().String(), Tag: "test", Ack: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	log, _ := zapr.NewLogger(zapr.WithWriteSyncer(out))
+	log.Info("hello", "key", "value")
+
+	m := <-msgs
+	if want, got := "test", m.tag; got != want {
+		t.Errorf("unexpected tag: want: %q; got: %q", want, got)
+	}
+	for key, want := range map[string]string{"message": "hello", "key": "value", "level": "INFO"} {
+		if got := m.record[key]; got != want {
+			t.Errorf("unexpected %s: want: %q; got: %q", key, want, got)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package zaprgelf provides a Graylog Extended Log Format (GELF) encoder
+// and outputs for zapr.
+//
+// Importing the package registers the "gelf" Encoder and the "gelf+udp" and
+// "gelf+tcp" output schemes, so that logs may be shipped to Graylog with flags:
+//
+//	-log-format=gelf -log-output=gelf+udp://graylog.example.com:12201
+package zaprgelf
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+
+	"bursavich.dev/zapr/encoding"
+	"bursavich.dev/zapr/internal/fields"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var gelfEncoder = encoding.Encoder(encoderCtor{})
+
+func init() {
+	if err := encoding.RegisterEncoder(gelfEncoder); err != nil {
+		panic(err)
+	}
+}
+
+// Encoder returns an Encoder which encodes entries as GELF 1.1 messages.
+//
+// The entry's message is the short message and its stacktrace, if any,
+// is appended to the message for the full message. The logger name, caller,
+// function, and fields are additional fields prefixed with an underscore.
+// Levels are mapped to syslog severities, so logr's Info is informational (6)
+// at verbosity zero and debug (7) at greater verbosity, and logr's Error is
+// error (3).
+//
+// Messages are not terminated, as framing is left to the output.
+func Encoder() encoding.Encoder { return gelfEncoder }
+
+type encoderCtor struct{}
+
+func (encoderCtor) Name() string { return "gelf" }
+
+func (encoderCtor) NewEncoder(cfg zapcore.EncoderConfig) zapcore.Encoder {
+	host, _ := os.Hostname()
+	return &encoder{cfg: cfg, host: host}
+}
+
+var pool = buffer.NewPool()
+
+type encoder struct {
+	fields.Recorder
+	cfg  zapcore.EncoderConfig
+	host string
+}
+
+func (enc *encoder) Clone() zapcore.Encoder {
+	return &encoder{
+		Recorder: enc.Recorder.Clone(),
+		cfg:      enc.cfg,
+		host:     enc.host,
+	}
+}
+
+func (enc *encoder) EncodeEntry(ent zapcore.Entry, fs []zapcore.Field) (*buffer.Buffer, error) {
+	level := Severity(ent.Level)
+	if v, ok := fields.VerbosityOf(fs); ok && v > 0 && ent.Level == zapcore.InfoLevel {
+		level = 7 // debug
+	}
+	m := map[string]interface{}{
+		"version":       "1.1",
+		"host":          enc.host,
+		"short_message": ent.Message,
+		"timestamp":     float64(ent.Time.UnixNano()) / 1e9,
+		"level":         level,
+	}
+	for k, v := range fields.Map(enc.With(fs)) {
+		m[additionalKey(k)] = v
+	}
+	if ent.Stack != "" {
+		m["full_message"] = ent.Message + "\n" + ent.Stack
+	}
+	if ent.LoggerName != "" && enc.cfg.NameKey != "" {
+		m["_logger"] = ent.LoggerName
+	}
+	if ent.Caller.Defined {
+		if enc.cfg.CallerKey != "" {
+			m["_caller"] = ent.Caller.TrimmedPath()
+		}
+		if enc.cfg.FunctionKey != "" {
+			m["_function"] = ent.Caller.Function
+		}
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	buf := pool.Get()
+	buf.Write(b)
+	return buf, nil
+}
+
+var invalidKeyChars = regexp.MustCompile(`[^\w.\-]`)
+
+func additionalKey(k string) string {
+	k = "_" + invalidKeyChars.ReplaceAllString(k, "_")
+	if k == "_id" { // reserved
+		k = "_id_"
+	}
+	return k
+}
+
+// Severity returns the syslog severity for the level.
+func Severity(lvl zapcore.Level) int {
+	switch lvl {
+	case zapcore.DebugLevel:
+		return 7 // debug
+	case zapcore.InfoLevel:
+		return 6 // informational
+	case zapcore.WarnLevel:
+		return 4 // warning
+	case zapcore.ErrorLevel:
+		return 3 // error
+	case zapcore.DPanicLevel:
+		return 2 // critical
+	case zapcore.PanicLevel:
+		return 1 // alert
+	case zapcore.FatalLevel:
+		return 0 // emergency
+	default:
+		if lvl < zapcore.DebugLevel {
+			return 7
+		}
+		return 0
+	}
+}
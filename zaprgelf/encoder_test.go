@@ -0,0 +1,63 @@
+package zaprgelf_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"bursavich.dev/zapr"
+	"bursavich.dev/zapr/zaprgelf"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestEncoder(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	log, _ := zapr.NewLogger(
+		zapr.WithEncoder(zaprgelf.Encoder()),
+		zapr.WithWriteSyncer(zapcore.AddSync(buf)),
+		zapr.WithName("test"),
+	)
+	log.WithValues("id", 7).Error(errors.New("boom"), "hello", "foo bar", "baz")
+	t.Log(buf.String()) // help debugging
+
+	var msg map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &msg); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]interface{}{
+		"version":       "1.1",
+		"short_message": "hello",
+		"level":         3.0,
+		"_logger":       "test",
+		"_id_":          7.0,
+		"_foo_bar":      "baz",
+		"_error":        "boom",
+	} {
+		if got := msg[key]; got != want {
+			t.Errorf("unexpected %s: want: %v; got: %v", key, want, got)
+		}
+	}
+}
+
+func TestEncoderVerbosity(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	log, _ := zapr.NewLogger(
+		zapr.WithEncoder(zaprgelf.Encoder()),
+		zapr.WithWriteSyncer(zapcore.AddSync(buf)),
+		zapr.WithLevel(1),
+	)
+	for v, want := range []float64{6, 7} {
+		buf.Reset()
+		log.V(v).Info("hello")
+		t.Log(buf.String()) // help debugging
+
+		var msg map[string]interface{}
+		if err := json.Unmarshal(buf.Bytes(), &msg); err != nil {
+			t.Fatal(err)
+		}
+		if got := msg["level"]; got != want {
+			t.Errorf("unexpected level at V(%d): want: %v; got: %v", v, want, got)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zaprgelf
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"bursavich.dev/zapr/output"
+)
+
+// DefaultChunkSize is the default maximum size of UDP datagrams.
+const DefaultChunkSize = 1420
+
+const (
+	chunkHeaderSize = 12
+	maxChunks       = 128
+	dialTimeout     = 5 * time.Second
+)
+
+func init() {
+	must(output.RegisterScheme("gelf+udp", func(u *url.URL) (output.Output, error) {
+		size := DefaultChunkSize
+		if s := u.Query().Get("chunk-size"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return nil, fmt.Errorf("zapr: invalid GELF chunk size: %q: %w", s, err)
+			}
+			size = n
+		}
+		return DialUDP(u.Host, size)
+	}))
+	must(output.RegisterScheme("gelf+tcp", func(u *url.URL) (output.Output, error) {
+		return DialTCP(u.Host)
+	}))
+}
+
+// DialUDP returns an Output that sends each GELF message to the address over
+// UDP, which is split into chunks if it's larger than chunkSize. A write may
+// contain several messages, such as when output is buffered.
+func DialUDP(addr string, chunkSize int) (output.Output, error) {
+	if chunkSize <= chunkHeaderSize {
+		return nil, fmt.Errorf("zapr: invalid GELF chunk size: %d", chunkSize)
+	}
+	conn, err := net.DialTimeout("udp", addr, dialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("zapr: failed to dial GELF output: %w", err)
+	}
+	var seed [8]byte
+	rand.Read(seed[:])
+	return &udpOutput{
+		conn:      conn,
+		chunkSize: chunkSize,
+		seed:      binary.BigEndian.Uint64(seed[:]),
+	}, nil
+}
+
+type udpOutput struct {
+	conn      net.Conn
+	chunkSize int
+	seed      uint64
+	seq       atomic.Uint64
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+var errMessageTooLarge = errors.New("zapr: GELF message too large")
+
+func (o *udpOutput) Write(b []byte) (int, error) {
+	if err := splitMessages(b, o.send); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// send sends the message, in chunks if it's too large for one datagram.
+func (o *udpOutput) send(b []byte) error {
+	if len(b) <= o.chunkSize {
+		_, err := o.conn.Write(b)
+		return err
+	}
+	size := o.chunkSize - chunkHeaderSize
+	count := (len(b) + size - 1) / size
+	if count > maxChunks {
+		return errMessageTooLarge
+	}
+	id := o.seed ^ o.seq.Add(1)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i := 0; i < count; i++ {
+		data := b[i*size:]
+		if len(data) > size {
+			data = data[:size]
+		}
+		buf := append(o.buf[:0], 0x1e, 0x0f)
+		buf = binary.BigEndian.AppendUint64(buf, id)
+		buf = append(buf, byte(i), byte(count))
+		buf = append(buf, data...)
+		o.buf = buf
+		if _, err := o.conn.Write(buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *udpOutput) Sync() error  { return nil }
+func (o *udpOutput) Close() error { return o.conn.Close() }
+
+// DialTCP returns an Output that sends each GELF message to the address as
+// a null-terminated message over TCP. A write may contain several messages,
+// such as when output is buffered. If a write fails, it reconnects and
+// retries once.
+func DialTCP(addr string) (output.Output, error) {
+	conn, err := net.DialTimeout("tcp", addr, dialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("zapr: failed to dial GELF output: %w", err)
+	}
+	return &tcpOutput{addr: addr, conn: conn}, nil
+}
+
+type tcpOutput struct {
+	addr string
+
+	mu   sync.Mutex
+	conn net.Conn
+	buf  []byte
+}
+
+func (o *tcpOutput) Write(b []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.buf = o.buf[:0]
+	if err := splitMessages(b, func(m []byte) error {
+		o.buf = append(append(o.buf, m...), 0)
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+	for retry := 0; ; retry++ {
+		if o.conn == nil {
+			conn, err := net.DialTimeout("tcp", o.addr, dialTimeout)
+			if err != nil {
+				return 0, fmt.Errorf("zapr: failed to dial GELF output: %w", err)
+			}
+			o.conn = conn
+		}
+		_, err := o.conn.Write(o.buf)
+		if err == nil {
+			return len(b), nil
+		}
+		o.conn.Close()
+		o.conn = nil
+		if retry > 0 {
+			return 0, err
+		}
+	}
+}
+
+func (o *tcpOutput) Sync() error { return nil }
+
+func (o *tcpOutput) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.conn == nil {
+		return nil
+	}
+	err := o.conn.Close()
+	o.conn = nil
+	return err
+}
+
+// splitMessages calls fn with each of the JSON messages in b.
+func splitMessages(b []byte, fn func([]byte) error) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	for {
+		var m json.RawMessage
+		if err := dec.Decode(&m); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("zapr: invalid GELF message: %w", err)
+		}
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+}
+
+func must(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,50 @@
+package zaprgelf_test
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"bursavich.dev/zapr"
+	"bursavich.dev/zapr/zaprgelf"
+)
+
+func TestUDPBuffered(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	out, err := zaprgelf.DialUDP(conn.LocalAddr().String(), zaprgelf.DefaultChunkSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	log, sink := zapr.NewLogger(
+		zapr.WithEncoder(zaprgelf.Encoder()),
+		zapr.WithWriteSyncer(out),
+		zapr.WithBufferedOutput(1<<10, time.Hour),
+	)
+	log.Info("first")
+	log.Info("second")
+	if err := sink.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	b := make([]byte, 1<<16)
+	for _, want := range []string{"first", "second"} {
+		n, _, err := conn.ReadFrom(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var msg map[string]interface{}
+		if err := json.Unmarshal(b[:n], &msg); err != nil {
+			t.Fatalf("unexpected datagram: %q: %v", b[:n], err)
+		}
+		if got := msg["short_message"]; got != want {
+			t.Errorf("unexpected message: want: %q; got: %q", want, got)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package zaprhttp provides HTTP handlers for zapr.
+package zaprhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"bursavich.dev/zapr"
+	"github.com/go-logr/logr"
+)
+
+// BenchPath is the conventional path of the benchmark handler.
+const BenchPath = "/debug/logging/bench"
+
+const (
+	defaultBenchDuration = time.Second
+	maxBenchDuration     = 10 * time.Second
+)
+
+// BenchResult is the result of a logging benchmark.
+type BenchResult struct {
+	Entries          int           `json:"entries"`
+	Duration         time.Duration `json:"duration"`
+	EntriesPerSecond float64       `json:"entriesPerSecond"`
+	AllocsPerOp      float64       `json:"allocsPerOp"`
+	BytesPerOp       float64       `json:"bytesPerOp"`
+	OutputBytesPerOp float64       `json:"outputBytesPerOp,omitempty"`
+}
+
+type benchHandler struct {
+	options []zapr.Option
+
+	mu      sync.Mutex
+	out     *countingWriter
+	discard *benchLogger // created on first use
+	live    *benchLogger // created on first use
+}
+
+// benchLogger is a logger which is created once and reused by benchmarks,
+// so that its resources (e.g. signal handlers) aren't acquired per request.
+type benchLogger struct {
+	log  logr.Logger
+	sink zapr.LogSink
+}
+
+func newBenchLogger(options []zapr.Option) *benchLogger {
+	log, sink := zapr.NewLogger(options...)
+	return &benchLogger{log: log.WithName("bench").WithValues("bench", true), sink: sink}
+}
+
+// BenchHandler returns an opt-in handler which runs a short logging benchmark
+// with a logger created from the given options and reports the result as JSON.
+// The options should be the same as the live logger's. Entries are written to
+// a discarding output in place of all of the logger's outputs, including tees,
+// taps, and audit outputs, unless the "output" query parameter is "live", in
+// which case the request must be a POST. The "duration" query parameter sets
+// the duration (default "1s", max "10s").
+//
+// Allocations are measured process-wide, so they're approximate on a busy
+// process. Only one benchmark runs at a time. The logger is created upon the
+// first benchmark of each output and reused thereafter.
+//
+//	mux.Handle(zaprhttp.BenchPath, zaprhttp.BenchHandler(zaprOptions...))
+func BenchHandler(options ...zapr.Option) http.Handler {
+	return &benchHandler{options: options}
+}
+
+func (h *benchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d := defaultBenchDuration
+	if s := r.URL.Query().Get("duration"); s != "" {
+		v, err := time.ParseDuration(s)
+		if err != nil || v <= 0 || v > maxBenchDuration {
+			http.Error(w, "invalid duration: "+strconv.Quote(s), http.StatusBadRequest)
+			return
+		}
+		d = v
+	}
+	live := false
+	switch s := r.URL.Query().Get("output"); s {
+	case "", "discard":
+	case "live":
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "live output requires POST", http.StatusMethodNotAllowed)
+			return
+		}
+		live = true
+	default:
+		http.Error(w, "invalid output: "+strconv.Quote(s), http.StatusBadRequest)
+		return
+	}
+	if !h.mu.TryLock() {
+		http.Error(w, "benchmark already running", http.StatusTooManyRequests)
+		return
+	}
+	var res *BenchResult
+	if live {
+		if h.live == nil {
+			h.live = newBenchLogger(h.options)
+		}
+		res = runBench(h.live, d)
+	} else {
+		if h.discard == nil {
+			h.out = &countingWriter{}
+			h.discard = newBenchLogger(append(h.options[:len(h.options):len(h.options)],
+				zapr.WithWriteSyncer(h.out),
+				zapr.WithSplitOutput(false),
+				zapr.WithoutOutputs(),
+				zapr.WithTee(),
+				zapr.WithTap(),
+				zapr.WithAuditOutput(nil),
+				zapr.WithReopenSignal(false),
+			))
+		}
+		h.out.n.Store(0)
+		res = runBench(h.discard, d)
+		if res.Entries > 0 {
+			res.OutputBytesPerOp = float64(h.out.n.Load()) / float64(res.Entries)
+		}
+	}
+	h.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+func runBench(bl *benchLogger, d time.Duration) *BenchResult {
+	log := bl.log
+	err := errBench{}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	deadline := start.Add(d)
+	n := 0
+	for ; n&0xff != 0 || time.Now().Before(deadline); n++ {
+		if n%8 == 0 {
+			log.Error(err, "Benchmark entry", "n", n, "path", "/debug/logging/bench")
+		} else {
+			log.Info("Benchmark entry", "n", n, "path", "/debug/logging/bench", "duration", time.Millisecond)
+		}
+	}
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+	bl.sink.Flush()
+
+	return &BenchResult{
+		Entries:          n,
+		Duration:         elapsed,
+		EntriesPerSecond: float64(n) / elapsed.Seconds(),
+		AllocsPerOp:      float64(after.Mallocs-before.Mallocs) / float64(n),
+		BytesPerOp:       float64(after.TotalAlloc-before.TotalAlloc) / float64(n),
+	}
+}
+
+type errBench struct{}
+
+func (errBench) Error() string { return "benchmark error" }
+
+type countingWriter struct {
+	n atomic.Int64
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	w.n.Add(int64(len(b)))
+	return len(b), nil
+}
+
+func (w *countingWriter) Sync() error { return nil }
@@ -0,0 +1,77 @@
+package zaprhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bursavich.dev/zapr"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestBenchHandler(t *testing.T) {
+	h := BenchHandler(zapr.WithLevel(1))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", BenchPath+"?duration=10ms", nil))
+	if want, got := http.StatusOK, rec.Code; got != want {
+		t.Fatalf("unexpected status: want: %d; got: %d", want, got)
+	}
+	t.Log(rec.Body.String()) // help debugging
+
+	var res BenchResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Entries == 0 || res.EntriesPerSecond <= 0 {
+		t.Errorf("unexpected entries: %d (%f/s)", res.Entries, res.EntriesPerSecond)
+	}
+	if res.OutputBytesPerOp <= 0 {
+		t.Errorf("unexpected output bytes per op: %f", res.OutputBytesPerOp)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", BenchPath+"?duration=1h", nil))
+	if want, got := http.StatusBadRequest, rec.Code; got != want {
+		t.Errorf("unexpected status: want: %d; got: %d", want, got)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", BenchPath+"?duration=10ms&output=live", nil))
+	if want, got := http.StatusMethodNotAllowed, rec.Code; got != want {
+		t.Errorf("unexpected status: want: %d; got: %d", want, got)
+	}
+}
+
+func TestBenchHandlerDiscard(t *testing.T) {
+	var live bytes.Buffer
+	var taps int
+	h := BenchHandler(
+		zapr.WithWriteSyncer(zapcore.AddSync(&live)),
+		zapr.WithOutput(zapcore.AddSync(&live)),
+		zapr.WithTee(zapcore.NewCore(zapcore.NewJSONEncoder(zapcore.EncoderConfig{}), zapcore.AddSync(&live), zapcore.DebugLevel)),
+		zapr.WithTap(zapr.TapFunc(func(zapr.EntrySnapshot) { taps++ })),
+		zapr.WithAuditOutput(zapcore.AddSync(&live)),
+	)
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", BenchPath+"?duration=10ms", nil))
+		if want, got := http.StatusOK, rec.Code; got != want {
+			t.Fatalf("unexpected status: want: %d; got: %d", want, got)
+		}
+	}
+	if live.Len() != 0 || taps != 0 {
+		t.Errorf("unexpected live output: %d bytes and %d taps", live.Len(), taps)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", BenchPath+"?duration=10ms&output=live", nil))
+	if want, got := http.StatusOK, rec.Code; got != want {
+		t.Fatalf("unexpected status: want: %d; got: %d", want, got)
+	}
+	if live.Len() == 0 || taps == 0 {
+		t.Errorf("unexpected live output: %d bytes and %d taps", live.Len(), taps)
+	}
+}
@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zaprhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"bursavich.dev/zapr"
+)
+
+// LevelPath is the conventional path of the level handler.
+const LevelPath = "/debug/logging/level"
+
+// LevelState is the state reported by the level handler.
+type LevelState struct {
+	Level       int        `json:"level"`
+	RevertLevel *int       `json:"revertLevel,omitempty"`
+	RevertAt    *time.Time `json:"revertAt,omitempty"`
+}
+
+type levelHandler struct {
+	sink zapr.LevelSink
+
+	mu       sync.Mutex
+	timer    *time.Timer
+	revert   int
+	revertAt time.Time
+}
+
+// LevelHandler returns a handler which reads and changes the verbosity level
+// of the sink, following the semantics of zap.AtomicLevel's handler.
+//
+// GET requests report the current level as JSON (e.g. {"level":2}). PUT and
+// POST requests change the level, which is given either as JSON (e.g.
+// {"level":4,"ttl":"10m"}) or as form values (e.g. "level=4&ttl=10m").
+// If a TTL is given, the level reverts when it expires to the level which
+// preceded the first unexpired change. Errors are reported as JSON
+// (e.g. {"error":"..."}).
+//
+//	mux.Handle(zaprhttp.LevelPath, zaprhttp.LevelHandler(sink.(zapr.LevelSink)))
+func LevelHandler(sink zapr.LevelSink) http.Handler {
+	return &levelHandler{sink: sink}
+}
+
+func (h *levelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		level, ttl, err := decodeLevel(r)
+		if err != nil {
+			writeLevelError(w, http.StatusBadRequest, err)
+			return
+		}
+		h.setLevel(level, ttl)
+	default:
+		writeLevelError(w, http.StatusMethodNotAllowed, errors.New("only GET, PUT, and POST are supported"))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.state())
+}
+
+func (h *levelHandler) setLevel(level int, ttl time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.timer != nil {
+		h.timer.Stop()
+	} else if ttl > 0 {
+		h.revert = h.sink.Level()
+	}
+	h.timer = nil
+	h.sink.SetLevel(level)
+	if ttl > 0 {
+		var t *time.Timer
+		t = time.AfterFunc(ttl, func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			if h.timer == t {
+				h.sink.SetLevel(h.revert)
+				h.timer = nil
+			}
+		})
+		h.timer = t
+		h.revertAt = time.Now().Add(ttl)
+	}
+}
+
+func (h *levelHandler) state() *LevelState {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := &LevelState{Level: h.sink.Level()}
+	if h.timer != nil {
+		revert, at := h.revert, h.revertAt
+		s.RevertLevel, s.RevertAt = &revert, &at
+	}
+	return s
+}
+
+func decodeLevel(r *http.Request) (level int, ttl time.Duration, err error) {
+	var req struct {
+		Level *int   `json:"level"`
+		TTL   string `json:"ttl"`
+	}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		if s := r.FormValue("level"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return 0, 0, errors.New("invalid level: " + strconv.Quote(s))
+			}
+			req.Level = &n
+		}
+		req.TTL = r.FormValue("ttl")
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return 0, 0, errors.New("invalid request body: " + err.Error())
+	}
+	if req.Level == nil {
+		return 0, 0, errors.New("must specify a level")
+	}
+	if *req.Level < 0 {
+		return 0, 0, errors.New("invalid level: " + strconv.Itoa(*req.Level))
+	}
+	if req.TTL != "" {
+		if ttl, err = time.ParseDuration(req.TTL); err != nil || ttl <= 0 {
+			return 0, 0, errors.New("invalid ttl: " + strconv.Quote(req.TTL))
+		}
+	}
+	return *req.Level, ttl, nil
+}
+
+func writeLevelError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{err.Error()})
+}
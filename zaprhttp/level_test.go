@@ -0,0 +1,64 @@
+package zaprhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bursavich.dev/zapr"
+)
+
+func TestLevelHandler(t *testing.T) {
+	sink := zapr.NewLogSink(zapr.WithLevel(1)).(zapr.LevelSink)
+	h := LevelHandler(sink)
+
+	serve := func(method, contentType, body string) (int, *LevelState) {
+		req := httptest.NewRequest(method, LevelPath, strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		t.Log(rec.Body.String()) // help debugging
+		var s LevelState
+		json.Unmarshal(rec.Body.Bytes(), &s)
+		return rec.Code, &s
+	}
+
+	if code, s := serve("GET", "", ""); code != http.StatusOK || s.Level != 1 {
+		t.Errorf("unexpected response: %d: %+v", code, s)
+	}
+	if code, s := serve("PUT", "application/json", `{"level":3}`); code != http.StatusOK || s.Level != 3 || s.RevertAt != nil {
+		t.Errorf("unexpected response: %d: %+v", code, s)
+	}
+	if want, got := 3, sink.Level(); got != want {
+		t.Errorf("unexpected level: want: %d; got: %d", want, got)
+	}
+	code, s := serve("POST", "application/x-www-form-urlencoded", "level=5&ttl=10ms")
+	if code != http.StatusOK || s.Level != 5 || s.RevertLevel == nil || *s.RevertLevel != 3 {
+		t.Errorf("unexpected response: %d: %+v", code, s)
+	}
+	for deadline := time.Now().Add(5 * time.Second); sink.Level() != 3 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	if want, got := 3, sink.Level(); got != want {
+		t.Errorf("unexpected reverted level: want: %d; got: %d", want, got)
+	}
+
+	for _, tt := range []struct {
+		method, body string
+		code         int
+	}{
+		{"PUT", `{}`, http.StatusBadRequest},
+		{"PUT", `{"level":-1}`, http.StatusBadRequest},
+		{"PUT", `{"level":1,"ttl":"soon"}`, http.StatusBadRequest},
+		{"DELETE", ``, http.StatusMethodNotAllowed},
+	} {
+		if code, _ := serve(tt.method, "application/json", tt.body); code != tt.code {
+			t.Errorf("unexpected status for %s %s: want: %d; got: %d", tt.method, tt.body, tt.code, code)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package zaprjournal provides a systemd-journald encoder and output for zapr.
+//
+// Importing the package registers the "journal" Encoder and, on Linux,
+// the "journal" output scheme, so that logs may be written to journald
+// with flags:
+//
+//	-log-format=journal -log-output=journal:
+package zaprjournal
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"bursavich.dev/zapr/encoding"
+	"bursavich.dev/zapr/internal/fields"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var journalEncoder = encoding.Encoder(encoderCtor{})
+
+func init() {
+	if err := encoding.RegisterEncoder(journalEncoder); err != nil {
+		panic(err)
+	}
+}
+
+// Encoder returns an Encoder which encodes entries in the journald native
+// protocol. The entry's level is mapped to PRIORITY, its message to MESSAGE,
+// and its caller to CODE_FILE, CODE_LINE, and CODE_FUNC. The logger name and
+// stacktrace use their configured keys and, like all other fields, are
+// emitted as journal fields whose names are uppercased and sanitized
+// (e.g. "user.id" becomes "USER_ID"). Fields whose names would repeat those
+// of the entry (e.g. "message" or "priority") are prefixed with "FIELD_".
+// Non-string values are JSON-encoded.
+func Encoder() encoding.Encoder { return journalEncoder }
+
+type encoderCtor struct{}
+
+func (encoderCtor) Name() string { return "journal" }
+
+func (encoderCtor) NewEncoder(cfg zapcore.EncoderConfig) zapcore.Encoder {
+	reserved := map[string]bool{
+		"PRIORITY":          true,
+		"MESSAGE":           true,
+		"SYSLOG_IDENTIFIER": true,
+		"CODE_FILE":         true,
+		"CODE_LINE":         true,
+		"CODE_FUNC":         true,
+	}
+	for _, key := range []string{cfg.NameKey, cfg.StacktraceKey} {
+		if key != "" {
+			reserved[fieldName(key)] = true
+		}
+	}
+	return &encoder{
+		cfg:        cfg,
+		identifier: filepath.Base(os.Args[0]),
+		reserved:   reserved,
+	}
+}
+
+var pool = buffer.NewPool()
+
+type encoder struct {
+	fields.Recorder
+	cfg        zapcore.EncoderConfig
+	identifier string
+	reserved   map[string]bool // names of the entry's fields
+}
+
+func (enc *encoder) Clone() zapcore.Encoder {
+	return &encoder{
+		Recorder:   enc.Recorder.Clone(),
+		cfg:        enc.cfg,
+		identifier: enc.identifier,
+		reserved:   enc.reserved,
+	}
+}
+
+func (enc *encoder) EncodeEntry(ent zapcore.Entry, fs []zapcore.Field) (*buffer.Buffer, error) {
+	buf := pool.Get()
+	writeField(buf, "PRIORITY", strconv.Itoa(Priority(ent.Level)))
+	writeField(buf, "MESSAGE", ent.Message)
+	writeField(buf, "SYSLOG_IDENTIFIER", enc.identifier)
+	if ent.Caller.Defined {
+		writeField(buf, "CODE_FILE", ent.Caller.File)
+		writeField(buf, "CODE_LINE", strconv.Itoa(ent.Caller.Line))
+		if ent.Caller.Function != "" {
+			writeField(buf, "CODE_FUNC", ent.Caller.Function)
+		}
+	}
+	if ent.LoggerName != "" && enc.cfg.NameKey != "" {
+		writeField(buf, fieldName(enc.cfg.NameKey), ent.LoggerName)
+	}
+	if ent.Stack != "" && enc.cfg.StacktraceKey != "" {
+		writeField(buf, fieldName(enc.cfg.StacktraceKey), ent.Stack)
+	}
+	m := fields.Map(enc.With(fs))
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v, err := fieldValue(m[k])
+		if err != nil {
+			buf.Free()
+			return nil, err
+		}
+		name := fieldName(k)
+		if enc.reserved[name] {
+			name = fieldName("FIELD_" + name)
+		}
+		writeField(buf, name, v)
+	}
+	return buf, nil
+}
+
+// writeField writes the field in the native protocol's text format or,
+// if the value contains a newline, its binary format.
+func writeField(buf *buffer.Buffer, name, value string) {
+	buf.AppendString(name)
+	if !strings.Contains(value, "\n") {
+		buf.AppendByte('=')
+		buf.AppendString(value)
+		buf.AppendByte('\n')
+		return
+	}
+	buf.AppendByte('\n')
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	buf.Write(size[:])
+	buf.AppendString(value)
+	buf.AppendByte('\n')
+}
+
+// fieldName returns a valid journal field name for the key. Valid names
+// contain only uppercase letters, digits, and underscores, don't begin with
+// an underscore or digit, and are at most 64 characters.
+func fieldName(key string) string {
+	b := make([]byte, 0, len(key))
+	for i := 0; i < len(key) && len(b) < 64; i++ {
+		switch c := key[i]; {
+		case 'a' <= c && c <= 'z':
+			b = append(b, c-'a'+'A')
+		case 'A' <= c && c <= 'Z', c == '_' && len(b) > 0, '0' <= c && c <= '9' && len(b) > 0:
+			b = append(b, c)
+		case len(b) > 0:
+			b = append(b, '_')
+		}
+	}
+	if len(b) == 0 {
+		return "FIELD"
+	}
+	return string(b)
+}
+
+func fieldValue(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case time.Duration:
+		return v.String(), nil
+	case fmt.Stringer:
+		return v.String(), nil
+	case error:
+		return v.Error(), nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// Priority returns the syslog priority for the level.
+func Priority(lvl zapcore.Level) int {
+	switch lvl {
+	case zapcore.DebugLevel:
+		return 7 // debug
+	case zapcore.InfoLevel:
+		return 6 // info
+	case zapcore.WarnLevel:
+		return 4 // warning
+	case zapcore.ErrorLevel:
+		return 3 // err
+	case zapcore.DPanicLevel:
+		return 2 // crit
+	case zapcore.PanicLevel:
+		return 1 // alert
+	case zapcore.FatalLevel:
+		return 0 // emerg
+	default:
+		if lvl < zapcore.DebugLevel {
+			return 7
+		}
+		return 0
+	}
+}
@@ -0,0 +1,52 @@
+package zaprjournal_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"bursavich.dev/zapr"
+	"bursavich.dev/zapr/zaprjournal"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestEncoder(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	log, _ := zapr.NewLogger(
+		zapr.WithEncoder(zaprjournal.Encoder()),
+		zapr.WithWriteSyncer(zapcore.AddSync(buf)),
+		zapr.WithCallerEnabled(false),
+		zapr.WithName("test"),
+	)
+	log.WithValues("user.id", 7).Info("hello", "message", "spoofed", "priority", 0, "note", "a\nb")
+	t.Logf("%q", buf.String()) // help debugging
+
+	want := strings.Join([]string{
+		"PRIORITY=6",
+		"MESSAGE=hello",
+		"SYSLOG_IDENTIFIER=zaprjournal.test",
+		"LOGGER=test",
+		"FIELD_MESSAGE=spoofed",
+		"NOTE\n\x03\x00\x00\x00\x00\x00\x00\x00a\nb",
+		"FIELD_PRIORITY=0",
+		"USER_ID=7",
+	}, "\n") + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected entry:\nwant: %q\ngot:  %q", want, got)
+	}
+}
+
+func TestPriority(t *testing.T) {
+	for lvl, want := range map[zapcore.Level]int{
+		zapcore.DebugLevel - 1: 7,
+		zapcore.DebugLevel:     7,
+		zapcore.InfoLevel:      6,
+		zapcore.WarnLevel:      4,
+		zapcore.ErrorLevel:     3,
+		zapcore.FatalLevel:     0,
+	} {
+		if got := zaprjournal.Priority(lvl); got != want {
+			t.Errorf("unexpected priority of %v: want: %d; got: %d", lvl, want, got)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zaprjournal
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"syscall"
+
+	"bursavich.dev/zapr/output"
+	"golang.org/x/sys/unix"
+)
+
+// DefaultSocket is the default path of the journald native protocol socket.
+const DefaultSocket = "/run/systemd/journal/socket"
+
+func init() {
+	err := output.RegisterScheme("journal", func(u *url.URL) (output.Output, error) {
+		path := u.Path
+		if path == "" {
+			path = DefaultSocket
+		}
+		return Dial(path)
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Dial returns an Output that sends each write, which must be encoded in the
+// native protocol, as a message to the journald socket at the given path.
+// Messages which are too large for a datagram are passed in a sealed memfd
+// or, if memfds aren't supported, in an unlinked temporary file.
+func Dial(path string) (output.Output, error) {
+	addr := &net.UnixAddr{Name: path, Net: "unixgram"}
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("zapr: failed to open journal socket: %w", err)
+	}
+	return &journalOutput{conn: conn, addr: addr}, nil
+}
+
+type journalOutput struct {
+	conn *net.UnixConn
+	addr *net.UnixAddr
+}
+
+func (o *journalOutput) Write(b []byte) (int, error) {
+	_, _, err := o.conn.WriteMsgUnix(b, nil, o.addr)
+	if err == nil {
+		return len(b), nil
+	}
+	if !isMsgSize(err) {
+		return 0, err
+	}
+	// The message is too large for a datagram, so pass it in a file.
+	f, err := sealedFile(b)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	if _, _, err := o.conn.WriteMsgUnix(nil, syscall.UnixRights(int(f.Fd())), o.addr); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// sealedFile returns a sealed memfd containing the data, as journald requires
+// of memfds, or an unlinked temporary file if memfds aren't supported.
+func sealedFile(b []byte) (*os.File, error) {
+	fd, err := unix.MemfdCreate("zapr-journal", unix.MFD_CLOEXEC|unix.MFD_ALLOW_SEALING)
+	if err != nil {
+		return tempFile(b)
+	}
+	f := os.NewFile(uintptr(fd), "zapr-journal")
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return nil, err
+	}
+	const seals = unix.F_SEAL_SHRINK | unix.F_SEAL_GROW | unix.F_SEAL_WRITE | unix.F_SEAL_SEAL
+	if _, err := unix.FcntlInt(f.Fd(), unix.F_ADD_SEALS, seals); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("zapr: failed to seal journal memfd: %w", err)
+	}
+	return f, nil
+}
+
+func tempFile(b []byte) (*os.File, error) {
+	f, err := os.CreateTemp("/dev/shm", "zapr-journal-")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Remove(f.Name()); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func isMsgSize(err error) bool {
+	return errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS)
+}
+
+func (o *journalOutput) Sync() error  { return nil }
+func (o *journalOutput) Close() error { return o.conn.Close() }
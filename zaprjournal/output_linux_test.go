@@ -0,0 +1,72 @@
+package zaprjournal
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	out, err := Dial(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	small := []byte("MESSAGE=hello\n")
+	if _, err := out.Write(small); err != nil {
+		t.Fatal(err)
+	}
+	b, oob := make([]byte, 1<<10), make([]byte, 1<<10)
+	n, _, _, _, err := conn.ReadMsgUnix(b, oob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := small, b[:n]; !bytes.Equal(got, want) {
+		t.Errorf("unexpected message: want: %q; got: %q", want, got)
+	}
+
+	// Larger than the maximum datagram size, so it's passed in a file.
+	large := append([]byte("MESSAGE="), bytes.Repeat([]byte("x"), 4<<20)...)
+	if _, err := out.Write(large); err != nil {
+		t.Fatal(err)
+	}
+	_, oobn, _, _, err := conn.ReadMsgUnix(b, oob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("unexpected control messages: %v (%v)", msgs, err)
+	}
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	if err != nil || len(fds) != 1 {
+		t.Fatalf("unexpected rights: %v (%v)", fds, err)
+	}
+	f := os.NewFile(uintptr(fds[0]), "message")
+	defer f.Close()
+	if seals, err := unix.FcntlInt(f.Fd(), unix.F_GET_SEALS, 0); err == nil {
+		if want := unix.F_SEAL_SHRINK | unix.F_SEAL_GROW | unix.F_SEAL_WRITE | unix.F_SEAL_SEAL; seals&want != want {
+			t.Errorf("unexpected seals: want: %#x; got: %#x", want, seals)
+		}
+	}
+	got, err := io.ReadAll(io.NewSectionReader(f, 0, int64(len(large))+1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, large) {
+		t.Errorf("unexpected file message: want: %d bytes; got: %d bytes", len(large), len(got))
+	}
+}
@@ -0,0 +1,112 @@
+package zaprnats
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"bursavich.dev/zapr"
+)
+
+type pub struct {
+	subject, reply, payload string
+}
+
+// serve runs a minimal NATS server which acknowledges JetStream publishes.
+func serve(t *testing.T, ln net.Listener, pubs chan<- pub) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	conn.Write([]byte("INFO {}\r\n"))
+	for {
+		line, err := readLine(r)
+		if err != nil {
+			return
+		}
+		op, args, _ := strings.Cut(line, " ")
+		switch op {
+		case "CONNECT":
+			if !strings.Contains(args, `"auth_token":"secret"`) {
+				conn.Write([]byte("-ERR 'Authorization Violation'\r\n"))
+				return
+			}
+		case "PING":
+			conn.Write([]byte("PONG\r\n"))
+		case "PUB":
+			f := strings.Fields(args)
+			n, _ := strconv.Atoi(f[len(f)-1])
+			b := make([]byte, n+2)
+			if _, err := io.ReadFull(r, b); err != nil {
+				t.Error(err)
+				return
+			}
+			p := pub{subject: f[0], payload: string(b[:n])}
+			if len(f) == 3 {
+				p.reply = f[1]
+				ack := `{"stream":"LOGS","seq":1}`
+				conn.Write([]byte("MSG " + p.reply + " 1 " + strconv.Itoa(len(ack)) + "\r\n" + ack + "\r\n"))
+			}
+			pubs <- p
+		}
+	}
+}
+
+func TestOutput(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	pubs := make(chan pub, 2)
+	go serve(t, ln, pubs)
+
+	out, err := Dial(Config{
+		Address:   ln.Addr().String(),
+		Token:     "secret",
+		Subject:   "logs.{logger}.{level}",
+		JetStream: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	log, _ := zapr.NewLogger(zapr.WithWriteSyncer(out))
+	log.WithName("app").Info("hello")
+	log.Error(nil, "world")
+
+	for _, want := range []struct{ subject, msg string }{
+		{"logs.app.info", "hello"},
+		{"logs._.error", "world"},
+	} {
+		p := <-pubs
+		if p.subject != want.subject {
+			t.Errorf("unexpected subject: want: %q; got: %q", want.subject, p.subject)
+		}
+		if !strings.HasPrefix(p.reply, "_INBOX.") {
+			t.Errorf("unexpected reply: %q", p.reply)
+		}
+		if !strings.Contains(p.payload, `"message":"`+want.msg+`"`) {
+			t.Errorf("unexpected payload: %s", p.payload)
+		}
+	}
+}
+
+func TestAuthError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go serve(t, ln, nil)
+
+	_, err = Dial(Config{Address: ln.Addr().String(), Token: "wrong"})
+	if want := "Authorization Violation"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("unexpected error: want: %q; got: %v", want, err)
+	}
+}
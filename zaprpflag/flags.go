@@ -0,0 +1,31 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package zaprpflag registers zapr Options with pflag FlagSets,
+// such as those used by cobra commands.
+package zaprpflag
+
+import (
+	"flag"
+
+	"bursavich.dev/zapr"
+	"github.com/spf13/pflag"
+)
+
+// RegisterPFlags registers the given Options with the FlagSet, as described by
+// zapr.RegisterFlags. The flags have the same names (e.g. "--log-level").
+// If fs is nil, pflag.CommandLine is used.
+//
+//	opts := zaprpflag.RegisterPFlags(cmd.Flags(), zapr.AllOptions()...)
+func RegisterPFlags(fs *pflag.FlagSet, options ...zapr.Option) []zapr.Option {
+	if fs == nil {
+		fs = pflag.CommandLine
+	}
+	gofs := flag.NewFlagSet("", flag.ContinueOnError)
+	zapr.RegisterFlags(gofs, options...)
+	fs.AddGoFlagSet(gofs)
+	return options
+}
@@ -0,0 +1,26 @@
+package zaprpflag
+
+import (
+	"testing"
+
+	"bursavich.dev/zapr"
+	"github.com/spf13/pflag"
+)
+
+func TestRegisterPFlags(t *testing.T) {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	opts := RegisterPFlags(fs, zapr.AllOptions()...)
+	if err := fs.Parse([]string{"--log-level=3", "--log-format=console", "--log-caller=false"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg := zapr.EffectiveConfig(opts...)
+	if want, got := 3, cfg.Level; got != want {
+		t.Errorf("unexpected level: want: %d; got: %d", want, got)
+	}
+	if want, got := "console", cfg.Encoder; got != want {
+		t.Errorf("unexpected encoder: want: %q; got: %q", want, got)
+	}
+	if !cfg.DisableCaller {
+		t.Error("expected caller to be disabled")
+	}
+}
@@ -8,21 +8,30 @@
 package zaprprom
 
 import (
+	"strconv"
+
 	"bursavich.dev/zapr"
+	"bursavich.dev/zapr/output"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap/zapcore"
 )
 
 // An Observer observes zapr metrics for Prometheus.
 type Observer interface {
-	zapr.Observer
+	zapr.FlushObserver
+	zapr.TruncationObserver
+	output.Observer
 	prometheus.Collector
 }
 
 type observer struct {
-	lines  *prometheus.CounterVec
-	bytes  *prometheus.CounterVec
-	errors *prometheus.CounterVec
+	lines   *prometheus.CounterVec
+	bytes   *prometheus.CounterVec
+	errors  *prometheus.CounterVec
+	events  *prometheus.CounterVec
+	flushes *prometheus.CounterVec
+	truncs  prometheus.Counter
+	cuts    prometheus.Counter
 }
 
 // NewObserver returns new Observer.
@@ -49,6 +58,32 @@ func NewObserver() Observer {
 			},
 			[]string{"name"},
 		),
+		events: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "log_output_events_total",
+				Help: "Total number of log output events, such as failed and retried requests.",
+			},
+			[]string{"output", "event"},
+		),
+		flushes: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "log_flushes_total",
+				Help: "Total number of log flushes, which are coalesced if they shared a concurrent sync.",
+			},
+			[]string{"coalesced"},
+		),
+		truncs: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "log_truncated_values_total",
+				Help: "Total number of log values truncated to the max length.",
+			},
+		),
+		cuts: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "log_truncated_bytes_total",
+				Help: "Total bytes removed from truncated log values.",
+			},
+		),
 	}
 }
 
@@ -56,16 +91,24 @@ func (o *observer) Describe(ch chan<- *prometheus.Desc) {
 	o.lines.Describe(ch)
 	o.bytes.Describe(ch)
 	o.errors.Describe(ch)
+	o.events.Describe(ch)
+	o.flushes.Describe(ch)
+	o.truncs.Describe(ch)
+	o.cuts.Describe(ch)
 }
 
 func (o *observer) Collect(ch chan<- prometheus.Metric) {
 	o.lines.Collect(ch)
 	o.bytes.Collect(ch)
 	o.errors.Collect(ch)
+	o.events.Collect(ch)
+	o.flushes.Collect(ch)
+	o.truncs.Collect(ch)
+	o.cuts.Collect(ch)
 }
 
 func (o *observer) Init(logger string) {
-	for _, lvl := range []zapcore.Level{zapcore.InfoLevel, zapcore.ErrorLevel} {
+	for _, lvl := range []zapcore.Level{zapcore.InfoLevel, zapcore.WarnLevel, zapcore.ErrorLevel} {
 		o.lines.WithLabelValues(logger, lvl.String())
 		o.bytes.WithLabelValues(logger, lvl.String())
 	}
@@ -80,3 +123,16 @@ func (o *observer) ObserveEntryLogged(logger string, level string, bytes int) {
 func (o *observer) ObserveEncoderError(logger string) {
 	o.errors.WithLabelValues(logger).Inc()
 }
+
+func (o *observer) ObserveOutputEvent(output, event string) {
+	o.events.WithLabelValues(output, event).Inc()
+}
+
+func (o *observer) ObserveFlush(coalesced bool) {
+	o.flushes.WithLabelValues(strconv.FormatBool(coalesced)).Inc()
+}
+
+func (o *observer) ObserveValueTruncated(bytes int) {
+	o.truncs.Inc()
+	o.cuts.Add(float64(bytes))
+}
@@ -0,0 +1,357 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package zaprsentry provides a Sentry integration for zapr, which captures
+// error entries as Sentry events in addition to the normal output.
+//
+//	sentry, err := zaprsentry.WithSentry(dsn, zaprsentry.WithEnvironment("prod"))
+//	if err != nil {
+//		// ...
+//	}
+//	log, sink := zapr.NewLogger(append(zaprOptions, sentry)...)
+package zaprsentry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"bursavich.dev/zapr"
+	"bursavich.dev/zapr/internal/fields"
+	"bursavich.dev/zapr/output"
+	"go.uber.org/zap/zapcore"
+)
+
+// An Option applies optional configuration.
+type Option func(*config)
+
+type config struct {
+	level       zapcore.Level
+	errorKey    string
+	environment string
+	release     string
+	serverName  string
+	client      *http.Client
+}
+
+// WithLevel returns an Option that sets the minimum level of captured
+// entries. The default is error.
+func WithLevel(level zapcore.Level) Option {
+	return func(c *config) { c.level = level }
+}
+
+// WithErrorKey returns an Option that sets the key of the error field,
+// which is captured as the exception's value. The default is "error".
+func WithErrorKey(key string) Option {
+	return func(c *config) { c.errorKey = key }
+}
+
+// WithEnvironment returns an Option that sets the environment of events.
+func WithEnvironment(env string) Option {
+	return func(c *config) { c.environment = env }
+}
+
+// WithRelease returns an Option that sets the release of events.
+func WithRelease(release string) Option {
+	return func(c *config) { c.release = release }
+}
+
+// WithServerName returns an Option that sets the server name of events.
+func WithServerName(name string) Option {
+	return func(c *config) { c.serverName = name }
+}
+
+// WithHTTPClient returns an Option that sets the HTTP client.
+// The default is http.DefaultClient.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *config) { c.client = client }
+}
+
+// WithSentry returns a zapr.Option that captures entries to Sentry, as
+// described by NewCore, in addition to the normal output.
+func WithSentry(dsn string, opts ...Option) (zapr.Option, error) {
+	core, err := NewCore(dsn, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return zapr.WithTee(core), nil
+}
+
+// NewCore returns a zapcore.Core that captures entries to the Sentry project
+// identified by the DSN. The entry's message, level, and logger name are
+// captured, along with its fields as extras and its stacktrace, which is
+// captured by the core if the entry doesn't have one. Events are sent in the
+// background and syncing the core waits for them to be sent.
+func NewCore(dsn string, opts ...Option) (zapcore.Core, error) {
+	c := &config{
+		level:    zapcore.ErrorLevel,
+		errorKey: "error",
+		client:   http.DefaultClient,
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	endpoint, auth, err := parseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	s := &sender{
+		url:    endpoint,
+		auth:   auth,
+		dsn:    dsn,
+		client: c.client,
+	}
+	return &core{
+		LevelEnabler: c.level,
+		cfg:          c,
+		out: output.NewBatchOutput(output.BatchConfig{
+			Name: "sentry",
+			Size: 1,
+			Send: s.send,
+		}),
+	}, nil
+}
+
+// parseDSN returns the envelope endpoint and auth header of the DSN,
+// which is formatted as "{scheme}://{key}@{host}{/path}/{project}".
+func parseDSN(dsn string) (endpoint, auth string, err error) {
+	u, err := url.Parse(dsn)
+	if err != nil || u.User == nil || u.User.Username() == "" || u.Host == "" {
+		return "", "", fmt.Errorf("zapr: invalid Sentry DSN: %q", dsn)
+	}
+	dir, project := path.Split(strings.TrimSuffix(u.Path, "/"))
+	if project == "" {
+		return "", "", fmt.Errorf("zapr: invalid Sentry DSN: %q", dsn)
+	}
+	endpoint = (&url.URL{
+		Scheme: u.Scheme,
+		Host:   u.Host,
+		Path:   path.Join(dir, "api", project, "envelope") + "/",
+	}).String()
+	auth = "Sentry sentry_version=7, sentry_client=zapr/1.0, sentry_key=" + u.User.Username()
+	if secret, ok := u.User.Password(); ok {
+		auth += ", sentry_secret=" + secret
+	}
+	return endpoint, auth, nil
+}
+
+type core struct {
+	zapcore.LevelEnabler
+	cfg    *config
+	out    output.Output
+	fields []zapcore.Field
+}
+
+func (c *core) With(fields []zapcore.Field) zapcore.Core {
+	v := *c
+	v.fields = append(c.fields[:len(c.fields):len(c.fields)], fields...)
+	return &v
+}
+
+func (c *core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *core) Write(ent zapcore.Entry, fs []zapcore.Field) error {
+	if !c.Enabled(ent.Level) {
+		return nil // written without being checked
+	}
+	extra := fields.Map(append(c.fields[:len(c.fields):len(c.fields)], fs...))
+	ex := exception{Type: ent.Message}
+	if v, ok := extra[c.cfg.errorKey].(string); ok {
+		ex.Value = v
+		delete(extra, c.cfg.errorKey)
+	}
+	if ent.Stack != "" {
+		ex.Stacktrace.Frames = parseStack(ent.Stack)
+	} else {
+		ex.Stacktrace.Frames = callerFrames()
+	}
+	var id [16]byte
+	rand.Read(id[:])
+	b, err := json.Marshal(&event{
+		EventID:     hex.EncodeToString(id[:]),
+		Timestamp:   ent.Time.UTC().Format(time.RFC3339Nano),
+		Platform:    "go",
+		Level:       level(ent.Level),
+		Logger:      ent.LoggerName,
+		Message:     message{Formatted: ent.Message},
+		Environment: c.cfg.environment,
+		Release:     c.cfg.release,
+		ServerName:  c.cfg.serverName,
+		Extra:       extra,
+		Exception:   []exception{ex},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.out.Write(b)
+	return err
+}
+
+func (c *core) Sync() error { return c.out.Sync() }
+
+type event struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   string                 `json:"timestamp"`
+	Platform    string                 `json:"platform"`
+	Level       string                 `json:"level"`
+	Logger      string                 `json:"logger,omitempty"`
+	Message     message                `json:"message"`
+	Environment string                 `json:"environment,omitempty"`
+	Release     string                 `json:"release,omitempty"`
+	ServerName  string                 `json:"server_name,omitempty"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+	Exception   []exception            `json:"exception,omitempty"`
+}
+
+type message struct {
+	Formatted string `json:"formatted"`
+}
+
+type exception struct {
+	Type       string `json:"type"`
+	Value      string `json:"value,omitempty"`
+	Stacktrace struct {
+		Frames []frame `json:"frames"`
+	} `json:"stacktrace"`
+}
+
+type frame struct {
+	Function string `json:"function"`
+	AbsPath  string `json:"abs_path"`
+	Filename string `json:"filename"`
+	Lineno   int    `json:"lineno"`
+}
+
+func level(lvl zapcore.Level) string {
+	switch {
+	case lvl <= zapcore.DebugLevel:
+		return "debug"
+	case lvl == zapcore.InfoLevel:
+		return "info"
+	case lvl == zapcore.WarnLevel:
+		return "warning"
+	case lvl == zapcore.ErrorLevel:
+		return "error"
+	default:
+		return "fatal"
+	}
+}
+
+// parseStack parses a stacktrace formatted by zap, which alternates lines
+// of function names and tab-indented file paths with line numbers.
+// Sentry frames are ordered from oldest to newest.
+func parseStack(stack string) []frame {
+	lines := strings.Split(stack, "\n")
+	var frames []frame
+	for i := len(lines)/2*2 - 2; i >= 0; i -= 2 {
+		file, line, _ := strings.Cut(strings.TrimSpace(lines[i+1]), ":")
+		n, _ := strconv.Atoi(line)
+		frames = append(frames, newFrame(lines[i], file, n))
+	}
+	return frames
+}
+
+// callerFrames returns the frames of the caller of the logger,
+// skipping frames within the logging packages.
+func callerFrames() []frame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(2, pcs)
+	iter := runtime.CallersFrames(pcs[:n])
+	var frames []frame
+	internal := true
+	for {
+		f, more := iter.Next()
+		if internal && !isLoggingFunc(f.Function) {
+			internal = false
+		}
+		if !internal {
+			frames = append([]frame{newFrame(f.Function, f.File, f.Line)}, frames...)
+		}
+		if !more {
+			return frames
+		}
+	}
+}
+
+func isLoggingFunc(fn string) bool {
+	for _, prefix := range []string{
+		"go.uber.org/zap.",
+		"go.uber.org/zap/",
+		"github.com/go-logr/logr.",
+		"bursavich.dev/zapr.",
+		"bursavich.dev/zapr/zaprsentry.(*core).",
+	} {
+		if strings.HasPrefix(fn, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func newFrame(fn, file string, line int) frame {
+	return frame{Function: fn, AbsPath: file, Filename: path.Base(file), Lineno: line}
+}
+
+type sender struct {
+	url    string
+	auth   string
+	dsn    string
+	client *http.Client
+}
+
+func (s *sender) send(ctx context.Context, event []byte) error {
+	var id struct {
+		EventID string `json:"event_id"`
+	}
+	json.Unmarshal(event, &id)
+	hdr, _ := json.Marshal(map[string]string{
+		"event_id": id.EventID,
+		"dsn":      s.dsn,
+		"sent_at":  time.Now().UTC().Format(time.RFC3339Nano),
+	})
+	item, _ := json.Marshal(map[string]interface{}{"type": "event", "length": len(event)})
+	var body bytes.Buffer
+	for _, b := range [][]byte{hdr, item, event} {
+		body.Write(b)
+		body.WriteByte('\n')
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, &body)
+	if err != nil {
+		return output.Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", s.auth)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("zapr: failed to send Sentry event: %w", err)
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+	switch code := resp.StatusCode; {
+	case code < 300:
+		return nil
+	case code == http.StatusTooManyRequests || code >= 500:
+		return fmt.Errorf("zapr: failed to send Sentry event: %s: %s", resp.Status, msg)
+	default:
+		return output.Permanent(fmt.Errorf("zapr: rejected Sentry event: %s: %s", resp.Status, msg))
+	}
+}
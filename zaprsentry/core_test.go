@@ -0,0 +1,85 @@
+package zaprsentry
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bursavich.dev/zapr"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSentry(t *testing.T) {
+	var events []event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if want, got := "/api/42/envelope/", r.URL.Path; got != want {
+			t.Errorf("unexpected path: want: %q; got: %q", want, got)
+		}
+		if want, got := "sentry_key=public", r.Header.Get("X-Sentry-Auth"); !strings.HasSuffix(got, want) {
+			t.Errorf("unexpected auth: want suffix: %q; got: %q", want, got)
+		}
+		sc := bufio.NewScanner(r.Body)
+		var lines []string
+		for sc.Scan() {
+			lines = append(lines, sc.Text())
+		}
+		if len(lines) != 3 {
+			t.Errorf("unexpected envelope lines: want: 3; got: %d", len(lines))
+			return
+		}
+		var ev event
+		if err := json.Unmarshal([]byte(lines[2]), &ev); err != nil {
+			t.Error(err)
+		}
+		events = append(events, ev)
+	}))
+	defer srv.Close()
+
+	opt, err := WithSentry(strings.Replace(srv.URL, "://", "://public@", 1)+"/42", WithEnvironment("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Redaction wraps the tee, which must still check the Sentry core.
+	log, sink := zapr.NewLogger(opt, zapr.WithWriteSyncer(zapcore.AddSync(io.Discard)), zapr.WithRedactKeys("password"))
+	log.Info("ignored")
+	log.WithName("test").WithValues("a", 1).Error(io.EOF, "Failed to read", "b", "x")
+	if err := sink.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("unexpected events: want: 1; got: %d", len(events))
+	}
+	ev := events[0]
+	if ev.Level != "error" || ev.Logger != "test" || ev.Environment != "test" || ev.Message.Formatted != "Failed to read" {
+		t.Errorf("unexpected event: %+v", ev)
+	}
+	if ev.Extra["a"] != 1.0 || ev.Extra["b"] != "x" {
+		t.Errorf("unexpected extra: %v", ev.Extra)
+	}
+	ex := ev.Exception[0]
+	if want, got := "EOF", ex.Value; got != want {
+		t.Errorf("unexpected exception value: want: %q; got: %q", want, got)
+	}
+	frames := ex.Stacktrace.Frames
+	if len(frames) == 0 {
+		t.Fatal("missing stacktrace")
+	}
+	if want, got := "TestSentry", frames[len(frames)-1].Function; !strings.HasSuffix(got, want) {
+		t.Errorf("unexpected last frame: want suffix: %q; got: %q", want, got)
+	}
+}
+
+func TestParseStack(t *testing.T) {
+	frames := parseStack("main.b\n\t/src/main.go:5\nmain.a\n\t/src/main.go:9")
+	if len(frames) != 2 {
+		t.Fatalf("unexpected frames: %v", frames)
+	}
+	if f := frames[0]; f.Function != "main.a" || f.Lineno != 9 || f.Filename != "main.go" {
+		t.Errorf("unexpected frame: %+v", f)
+	}
+}
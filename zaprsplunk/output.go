@@ -0,0 +1,217 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package zaprsplunk provides a Splunk HTTP Event Collector output for zapr.
+//
+// Importing the package registers the "splunk" and "splunk+http" output
+// schemes, for HTTPS and HTTP respectively:
+//
+//	splunk://splunk.example.com:8088?token-file=/etc/splunk/token&index=main
+//
+// The following query parameters are supported:
+//
+//	token-file  path of a file containing the HEC token (required)
+//	index       index in which to store events
+//	source      source of events
+//	sourcetype  source type of events
+//	host        host of events
+//	batch-size  maximum number of entries per request (default 100)
+//	flush       maximum duration an entry is buffered (default "1s")
+package zaprsplunk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"bursavich.dev/zapr/output"
+)
+
+// DefaultPath is the default path of the event endpoint.
+const DefaultPath = "/services/collector/event"
+
+func init() {
+	must(output.RegisterScheme("splunk", func(u *url.URL) (output.Output, error) {
+		return openURL(u, "https")
+	}))
+	must(output.RegisterScheme("splunk+http", func(u *url.URL) (output.Output, error) {
+		return openURL(u, "http")
+	}))
+}
+
+func openURL(u *url.URL, scheme string) (output.Output, error) {
+	q := u.Query()
+	cfg := Config{
+		URL:        (&url.URL{Scheme: scheme, Host: u.Host, Path: u.Path}).String(),
+		Index:      q.Get("index"),
+		Source:     q.Get("source"),
+		SourceType: q.Get("sourcetype"),
+		Host:       q.Get("host"),
+	}
+	if name := q.Get("token-file"); name != "" {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("zapr: failed to read Splunk token file: %w", err)
+		}
+		cfg.Token = strings.TrimSpace(string(b))
+	}
+	if s := q.Get("batch-size"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("zapr: invalid Splunk batch size: %q: %w", s, err)
+		}
+		cfg.BatchSize = n
+	}
+	if s := q.Get("flush"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("zapr: invalid Splunk flush interval: %q: %w", s, err)
+		}
+		cfg.FlushInterval = d
+	}
+	return New(cfg)
+}
+
+// Config is the configuration of a Splunk HEC output.
+type Config struct {
+	// URL is the HEC endpoint (e.g. "https://splunk.example.com:8088").
+	// If it has no path, DefaultPath is used.
+	URL string
+
+	// Token is the HEC token.
+	Token string
+
+	// Index, Source, SourceType, and Host are the optional metadata of events.
+	Index      string
+	Source     string
+	SourceType string
+	Host       string
+
+	// BatchSize is the maximum number of entries per request.
+	// The default is 100.
+	BatchSize int
+
+	// FlushInterval is the maximum duration an entry is buffered.
+	// The default is 1s.
+	FlushInterval time.Duration
+
+	// Client is the HTTP client. The default is an http.Client with a
+	// timeout of 30s.
+	Client *http.Client
+}
+
+// New returns an Output that sends entries to the Splunk HTTP Event Collector
+// in batches. Entries that are valid JSON, such as those from the JSON encoder,
+// are sent as objects and others are sent as strings. Failed requests are
+// retried with backoff, except for those rejected as invalid or unauthorized.
+// If the Output is observed, it reports events for the "splunk" output.
+func New(cfg Config) (output.Output, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("zapr: invalid Splunk URL: %q", cfg.URL)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = DefaultPath
+	}
+	if cfg.Token == "" {
+		return nil, errors.New("zapr: missing Splunk token")
+	}
+	client := cfg.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	meta, err := json.Marshal(&metadata{
+		Index:      cfg.Index,
+		Source:     cfg.Source,
+		SourceType: cfg.SourceType,
+		Host:       cfg.Host,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s := &sender{
+		url:    u.String(),
+		auth:   "Splunk " + cfg.Token,
+		client: client,
+	}
+	return output.NewBatchOutput(output.BatchConfig{
+		Name:     "splunk",
+		Size:     cfg.BatchSize,
+		Interval: cfg.FlushInterval,
+		Append:   eventAppender(meta[:len(meta)-1]), // without the closing brace
+		Send:     s.send,
+	}), nil
+}
+
+type metadata struct {
+	Index      string `json:"index,omitempty"`
+	Source     string `json:"source,omitempty"`
+	SourceType string `json:"sourcetype,omitempty"`
+	Host       string `json:"host,omitempty"`
+}
+
+// eventAppender returns a function which appends an entry as an event
+// object, given the event's metadata object without its closing brace.
+func eventAppender(meta []byte) func(batch, entry []byte) []byte {
+	return func(batch, entry []byte) []byte {
+		batch = append(batch, meta...)
+		if len(meta) > 1 {
+			batch = append(batch, ',')
+		}
+		batch = append(batch, `"event":`...)
+		if entry = bytes.TrimSpace(entry); json.Valid(entry) {
+			batch = append(batch, entry...)
+		} else {
+			s, _ := json.Marshal(string(entry))
+			batch = append(batch, s...)
+		}
+		return append(batch, '}', '\n')
+	}
+}
+
+type sender struct {
+	url    string
+	auth   string
+	client *http.Client
+}
+
+func (s *sender) send(ctx context.Context, batch []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(batch))
+	if err != nil {
+		return output.Permanent(err)
+	}
+	req.Header.Set("Authorization", s.auth)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("zapr: failed to send Splunk events: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+	switch code := resp.StatusCode; {
+	case code < 300:
+		return nil
+	case code == http.StatusTooManyRequests || code >= 500:
+		return fmt.Errorf("zapr: failed to send Splunk events: %s: %s", resp.Status, body)
+	default:
+		return output.Permanent(fmt.Errorf("zapr: rejected Splunk events: %s: %s", resp.Status, body))
+	}
+}
+
+func must(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
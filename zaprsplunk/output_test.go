@@ -0,0 +1,93 @@
+package zaprsplunk
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"bursavich.dev/zapr"
+)
+
+type eventObserver struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (o *eventObserver) Init(string)                            {}
+func (o *eventObserver) ObserveEntryLogged(string, string, int) {}
+func (o *eventObserver) ObserveEncoderError(string)             {}
+
+func (o *eventObserver) ObserveOutputEvent(output, event string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events = append(o.events, output+":"+event)
+}
+
+func TestOutput(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		calls  int
+		bodies []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if want, got := "Splunk secret", r.Header.Get("Authorization"); got != want {
+			t.Errorf("unexpected authorization: want: %q; got: %q", want, got)
+		}
+		if want, got := DefaultPath, r.URL.Path; got != want {
+			t.Errorf("unexpected path: want: %q; got: %q", want, got)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if calls++; calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+	}))
+	defer srv.Close()
+
+	out, err := New(Config{URL: srv.URL, Token: "secret", Index: "main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	obs := &eventObserver{}
+	log, sink := zapr.NewLogger(zapr.WithWriteSyncer(out), zapr.WithObserver(obs))
+	log.Info("hello", "n", 1)
+	log.Info("world", "n", 2)
+	if err := sink.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 1 {
+		t.Fatalf("unexpected requests: want: 1; got: %d", len(bodies))
+	}
+	t.Log("\n" + strings.TrimSpace(bodies[0])) // help debugging
+	dec := json.NewDecoder(strings.NewReader(bodies[0]))
+	for _, want := range []string{"hello", "world"} {
+		var event struct {
+			Index string `json:"index"`
+			Event struct {
+				Message string `json:"message"`
+			} `json:"event"`
+		}
+		if err := dec.Decode(&event); err != nil {
+			t.Fatal(err)
+		}
+		if event.Index != "main" || event.Event.Message != want {
+			t.Errorf("unexpected event: want: main/%s; got: %s/%s", want, event.Index, event.Event.Message)
+		}
+	}
+	obs.mu.Lock()
+	defer obs.mu.Unlock()
+	if want, got := "splunk:error,splunk:retry", strings.Join(obs.events, ","); got != want {
+		t.Errorf("unexpected events: want: %q; got: %q", want, got)
+	}
+}
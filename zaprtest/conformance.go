@@ -0,0 +1,200 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package zaprtest provides conformance tests for zapr loggers, such that
+// users of custom encoders and options can verify that their configurations
+// uphold the guarantees of logr and, with Go 1.21 or later, slog.
+package zaprtest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"bursavich.dev/zapr"
+	"github.com/go-logr/logr"
+	"go.uber.org/zap/zapcore"
+)
+
+// Keys used by the conformance tests, which override those of the options.
+const (
+	MessageKey = "msg"
+	NameKey    = "logger"
+	ErrorKey   = "err"
+)
+
+// A ParseFunc parses encoded entries into maps of their keys and values.
+type ParseFunc func(b []byte) ([]map[string]interface{}, error)
+
+// ParseJSON parses newline-delimited JSON entries.
+func ParseJSON(b []byte) ([]map[string]interface{}, error) {
+	var entries []map[string]interface{}
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal(line, &m); err != nil {
+			return nil, fmt.Errorf("zapr: invalid JSON entry: %q: %w", line, err)
+		}
+		entries = append(entries, m)
+	}
+	return entries, sc.Err()
+}
+
+// TestLogSink runs conformance tests against loggers created with the options,
+// whose entries are parsed by parse. If parse is nil, ParseJSON is used.
+//
+// The options are overridden to write to a buffer at verbosity level 1 with
+// MessageKey, NameKey, and ErrorKey.
+func TestLogSink(t *testing.T, parse ParseFunc, options ...zapr.Option) {
+	t.Helper()
+	if parse == nil {
+		parse = ParseJSON
+	}
+	newLogger := func(t *testing.T) (logr.Logger, func() []map[string]interface{}) {
+		var buf syncBuffer
+		log, _ := zapr.NewLogger(append(options[:len(options):len(options)],
+			zapr.WithWriteSyncer(&buf),
+			zapr.WithLevel(1),
+			zapr.WithMessageKey(MessageKey),
+			zapr.WithNameKey(NameKey),
+			zapr.WithErrorKey(ErrorKey),
+		)...)
+		return log, func() []map[string]interface{} {
+			b := buf.Bytes()
+			entries, err := parse(b)
+			if err != nil {
+				t.Fatalf("failed to parse entries: %v", err)
+			}
+			t.Log("\n" + string(bytes.TrimSpace(b))) // help debugging
+			return entries
+		}
+	}
+
+	for _, tt := range []struct {
+		name string
+		log  func(log logr.Logger)
+		want []map[string]interface{}
+	}{
+		{
+			name: "Info",
+			log:  func(log logr.Logger) { log.Info("hello", "s", "x", "n", 1, "b", true) },
+			want: []map[string]interface{}{{MessageKey: "hello", "s": "x", "n": 1, "b": true}},
+		},
+		{
+			name: "Verbosity",
+			log: func(log logr.Logger) {
+				log.V(1).Info("enabled")
+				log.V(2).Info("disabled")
+			},
+			want: []map[string]interface{}{{MessageKey: "enabled"}},
+		},
+		{
+			name: "Error",
+			log: func(log logr.Logger) {
+				log.V(2).Error(errors.New("failed"), "uh oh", "k", "v")
+			},
+			want: []map[string]interface{}{{MessageKey: "uh oh", ErrorKey: "failed", "k": "v"}},
+		},
+		{
+			name: "WithValues",
+			log: func(log logr.Logger) {
+				child := log.WithValues("a", 1)
+				child.WithValues("b", 2).Info("grandchild")
+				child.Info("child", "c", 3)
+				log.Info("parent")
+			},
+			want: []map[string]interface{}{
+				{MessageKey: "grandchild", "a": 1, "b": 2},
+				{MessageKey: "child", "a": 1, "c": 3},
+				{MessageKey: "parent", "a": nil},
+			},
+		},
+		{
+			name: "WithName",
+			log: func(log logr.Logger) {
+				child := log.WithName("a")
+				child.WithName("b").Info("grandchild")
+				child.Info("child")
+			},
+			want: []map[string]interface{}{
+				{MessageKey: "grandchild", NameKey: "a.b"},
+				{MessageKey: "child", NameKey: "a"},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			log, entries := newLogger(t)
+			tt.log(log)
+			got := entries()
+			if len(got) != len(tt.want) {
+				t.Fatalf("unexpected number of entries: want: %d; got: %d", len(tt.want), len(got))
+			}
+			for i, want := range tt.want {
+				for k, v := range want {
+					if !equal(got[i][k], v) {
+						t.Errorf("unexpected entry %d value of %q: want: %v; got: %v", i, k, v, got[i][k])
+					}
+				}
+			}
+		})
+	}
+
+	t.Run("Concurrency", func(t *testing.T) {
+		log, entries := newLogger(t)
+		const n = 8
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				log.WithValues("i", i).Info("concurrent")
+			}(i)
+		}
+		wg.Wait()
+		if want, got := n, len(entries()); got != want {
+			t.Errorf("unexpected number of entries: want: %d; got: %d", want, got)
+		}
+	})
+}
+
+// equal returns a boolean indicating if the values are equal after parsing,
+// in which numbers may have been converted to floats or strings.
+func equal(got, want interface{}) bool {
+	if want == nil {
+		return got == nil
+	}
+	return got != nil && fmt.Sprint(got) == fmt.Sprint(want)
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+var _ zapcore.WriteSyncer = (*syncBuffer)(nil)
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Sync() error { return nil }
+
+func (b *syncBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]byte(nil), b.buf.Bytes()...)
+}
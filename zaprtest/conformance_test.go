@@ -0,0 +1,23 @@
+package zaprtest
+
+import (
+	"testing"
+
+	"bursavich.dev/zapr"
+)
+
+func TestConformance(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		options []zapr.Option
+	}{
+		{name: "Default"},
+		{name: "Google", options: []zapr.Option{zapr.WithGooglePreset()}},
+		{name: "Datadog", options: []zapr.Option{zapr.WithDatadogPreset()}},
+		{name: "Sorted", options: []zapr.Option{zapr.WithSortedFields(true)}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			TestLogSink(t, nil, tt.options...)
+		})
+	}
+}
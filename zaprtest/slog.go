@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21
+
+package zaprtest
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"testing/slogtest"
+
+	"bursavich.dev/zapr"
+	"github.com/go-logr/logr"
+)
+
+// TestSlogHandler runs the conformance tests of testing/slogtest against a
+// logger created with the options, whose entries are parsed by parse. If parse
+// is nil, ParseJSON is used. The logger is adapted to a slog.Handler which
+// passes slog.Attrs to it, so that its handling of them is tested.
+//
+// The options are overridden to write to a buffer without a time key and with
+// the level and message keys of slog. The time of each record is added as an
+// Attr, since logr entries are timed by their LogSinks.
+func TestSlogHandler(t *testing.T, parse ParseFunc, options ...zapr.Option) {
+	t.Helper()
+	if parse == nil {
+		parse = ParseJSON
+	}
+	var buf syncBuffer
+	log, _ := zapr.NewLogger(append(options[:len(options):len(options)],
+		zapr.WithWriteSyncer(&buf),
+		zapr.WithTimeKey(""),
+		zapr.WithLevelKey(slog.LevelKey),
+		zapr.WithMessageKey(slog.MessageKey),
+	)...)
+	err := slogtest.TestHandler(&slogHandler{log: log}, func() []map[string]interface{} {
+		b := buf.Bytes()
+		t.Log("\n" + string(bytes.TrimSpace(b))) // help debugging
+		entries, err := parse(b)
+		if err != nil {
+			t.Fatalf("failed to parse entries: %v", err)
+		}
+		return entries
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// slogHandler is a minimal slog.Handler of a logr.Logger, which passes
+// slog.Attrs to the Logger. Attrs added before any groups are added by
+// WithValues, while groups and the Attrs added within them are held until
+// they're nested in a record.
+type slogHandler struct {
+	log    logr.Logger
+	groups []slogGroup
+}
+
+type slogGroup struct {
+	name  string
+	attrs []slog.Attr
+}
+
+func (h *slogHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *slogHandler) Handle(_ context.Context, r slog.Record) error {
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		g := h.groups[i]
+		attrs = []slog.Attr{{Key: g.name, Value: slog.GroupValue(append(g.attrs[:len(g.attrs):len(g.attrs)], attrs...)...)}}
+	}
+	kvs := make([]interface{}, 0, len(attrs)+1)
+	if !r.Time.IsZero() {
+		kvs = append(kvs, slog.Time(slog.TimeKey, r.Time))
+	}
+	for _, a := range attrs {
+		kvs = append(kvs, a)
+	}
+	h.log.Info(r.Message, kvs...)
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(h.groups) == 0 {
+		kvs := make([]interface{}, len(attrs))
+		for i, a := range attrs {
+			kvs[i] = a
+		}
+		return &slogHandler{log: h.log.WithValues(kvs...)}
+	}
+	groups := append([]slogGroup(nil), h.groups...)
+	g := &groups[len(groups)-1]
+	g.attrs = append(g.attrs[:len(g.attrs):len(g.attrs)], attrs...)
+	return &slogHandler{log: h.log, groups: groups}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	groups := append(h.groups[:len(h.groups):len(h.groups)], slogGroup{name: name})
+	return &slogHandler{log: h.log, groups: groups}
+}
@@ -0,0 +1,25 @@
+//go:build go1.21
+
+package zaprtest
+
+import (
+	"testing"
+
+	"bursavich.dev/zapr"
+)
+
+func TestSlog(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		options []zapr.Option
+	}{
+		{name: "Default"},
+		{name: "Google", options: []zapr.Option{zapr.WithGooglePreset()}},
+		{name: "Datadog", options: []zapr.Option{zapr.WithDatadogPreset()}},
+		{name: "Sorted", options: []zapr.Option{zapr.WithSortedFields(true)}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			TestSlogHandler(t, nil, tt.options...)
+		})
+	}
+}
@@ -0,0 +1,267 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package zaprwebhook provides an output for zapr which posts batches of
+// entries to an arbitrary HTTP endpoint, such as a custom log collector.
+//
+// Importing the package registers the "webhook" and "webhook+http" output
+// schemes, for HTTPS and HTTP respectively:
+//
+//	webhook://logs.example.com/ingest?format=array&header=X-Api-Key:secret
+//
+// The following query parameters are supported:
+//
+//	format            "ndjson" or "array" (default "ndjson")
+//	header            request header as "Name:Value" (may be repeated)
+//	content-encoding  name of a registered output.Compressor (e.g. "gzip")
+//	batch-size        maximum number of entries per request (default 100)
+//	queue             maximum number of requests waiting to be sent (default 8)
+//	retries           maximum number of times a request is retried (default 5)
+//	flush             maximum duration an entry is buffered (default "1s")
+package zaprwebhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"bursavich.dev/zapr/output"
+)
+
+// A Format is the format of a request body.
+type Format string
+
+// Formats of request bodies.
+const (
+	// NDJSON is newline-delimited entries, sent as "application/x-ndjson".
+	NDJSON Format = "ndjson"
+	// JSONArray is a JSON array of entries, sent as "application/json".
+	JSONArray Format = "array"
+)
+
+func init() {
+	must(output.RegisterScheme("webhook", func(u *url.URL) (output.Output, error) {
+		return openURL(u, "https")
+	}))
+	must(output.RegisterScheme("webhook+http", func(u *url.URL) (output.Output, error) {
+		return openURL(u, "http")
+	}))
+}
+
+func openURL(u *url.URL, scheme string) (output.Output, error) {
+	q := u.Query()
+	cfg := Config{
+		URL:             (&url.URL{Scheme: scheme, User: u.User, Host: u.Host, Path: u.Path}).String(),
+		Format:          Format(q.Get("format")),
+		ContentEncoding: q.Get("content-encoding"),
+		Header:          make(http.Header),
+	}
+	for _, s := range q["header"] {
+		name, value, ok := strings.Cut(s, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("zapr: invalid webhook header: %q", s)
+		}
+		cfg.Header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	for _, p := range []struct {
+		name string
+		val  *int
+	}{
+		{"batch-size", &cfg.BatchSize},
+		{"queue", &cfg.Queue},
+		{"retries", &cfg.Retries},
+	} {
+		if s := q.Get(p.name); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return nil, fmt.Errorf("zapr: invalid webhook %s: %q: %w", p.name, s, err)
+			}
+			*p.val = n
+		}
+	}
+	if s := q.Get("flush"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("zapr: invalid webhook flush interval: %q: %w", s, err)
+		}
+		cfg.FlushInterval = d
+	}
+	return New(cfg)
+}
+
+// Config is the configuration of a webhook output.
+type Config struct {
+	// URL is the endpoint to which batches are posted.
+	URL string
+
+	// Format is the format of request bodies. The default is NDJSON.
+	Format Format
+
+	// Header is added to each request.
+	Header http.Header
+
+	// ContentEncoding is the name of a registered output.Compressor with
+	// which request bodies are compressed. By default, they're uncompressed.
+	ContentEncoding string
+
+	// BatchSize is the maximum number of entries per request.
+	// The default is 100.
+	BatchSize int
+
+	// Queue is the maximum number of requests waiting to be sent.
+	// The default is 8.
+	Queue int
+
+	// Retries is the maximum number of times a failed request is retried.
+	// If it's negative, requests aren't retried. The default is 5.
+	Retries int
+
+	// FlushInterval is the maximum duration an entry is buffered.
+	// The default is 1s.
+	FlushInterval time.Duration
+
+	// Client is the HTTP client. The default is an http.Client with a
+	// timeout of 30s.
+	Client *http.Client
+}
+
+// New returns an Output that posts entries to an HTTP endpoint in batches.
+// Failed requests are retried with backoff if the endpoint is unavailable or
+// responds with 429 Too Many Requests or a 5xx status. If the Output is
+// observed, it reports events for the "webhook" output.
+func New(cfg Config) (output.Output, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("zapr: invalid webhook URL: %q", cfg.URL)
+	}
+	s := &sender{
+		url:    cfg.URL,
+		header: cfg.Header.Clone(),
+		client: cfg.Client,
+	}
+	if s.header == nil {
+		s.header = make(http.Header)
+	}
+	if s.client == nil {
+		s.client = &http.Client{Timeout: 30 * time.Second}
+	}
+	var appendFn func(batch, entry []byte) []byte
+	switch cfg.Format {
+	case "", NDJSON:
+		appendFn = appendNDJSON
+		s.header.Set("Content-Type", "application/x-ndjson")
+	case JSONArray:
+		appendFn = appendArray
+		s.suffix = "]"
+		s.header.Set("Content-Type", "application/json")
+	default:
+		return nil, fmt.Errorf("zapr: unknown webhook format: %q", cfg.Format)
+	}
+	if name := cfg.ContentEncoding; name != "" {
+		for _, c := range output.Compressors() {
+			if c.Name() == name {
+				s.compressor = c
+			}
+		}
+		if s.compressor == nil {
+			return nil, fmt.Errorf("zapr: unknown Compressor: %q", name)
+		}
+		s.header.Set("Content-Encoding", name)
+	}
+	return output.NewBatchOutput(output.BatchConfig{
+		Name:     "webhook",
+		Size:     cfg.BatchSize,
+		Interval: cfg.FlushInterval,
+		Queue:    cfg.Queue,
+		Retries:  cfg.Retries,
+		Append:   appendFn,
+		Send:     s.send,
+	}), nil
+}
+
+func appendNDJSON(batch, entry []byte) []byte {
+	batch = append(batch, bytes.TrimSpace(entry)...)
+	return append(batch, '\n')
+}
+
+// appendArray appends the entry to a JSON array, which is closed when sent.
+func appendArray(batch, entry []byte) []byte {
+	if len(batch) == 0 {
+		batch = append(batch, '[')
+	} else {
+		batch = append(batch, ',')
+	}
+	return append(batch, bytes.TrimSpace(entry)...)
+}
+
+type sender struct {
+	url        string
+	header     http.Header
+	suffix     string
+	compressor output.Compressor
+	client     *http.Client
+}
+
+func (s *sender) body(batch []byte) ([]byte, error) {
+	if s.suffix != "" {
+		batch = append(batch[:len(batch):len(batch)], s.suffix...)
+	}
+	if s.compressor == nil {
+		return batch, nil
+	}
+	var buf bytes.Buffer
+	zw, err := s.compressor.NewWriter(&buf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := zw.Write(batch); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *sender) send(ctx context.Context, batch []byte) error {
+	body, err := s.body(batch)
+	if err != nil {
+		return output.Permanent(fmt.Errorf("zapr: failed to encode webhook request: %w", err))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return output.Permanent(err)
+	}
+	for k, v := range s.header {
+		req.Header[k] = v
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("zapr: failed to send webhook request: %w", err)
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+	switch code := resp.StatusCode; {
+	case code < 300:
+		return nil
+	case code == http.StatusTooManyRequests || code >= 500:
+		return fmt.Errorf("zapr: failed to send webhook request: %s: %s", resp.Status, msg)
+	default:
+		return output.Permanent(fmt.Errorf("zapr: rejected webhook request: %s: %s", resp.Status, msg))
+	}
+}
+
+func must(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,93 @@
+package zaprwebhook
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"bursavich.dev/zapr"
+	"bursavich.dev/zapr/output"
+)
+
+func TestOutput(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		calls  int
+		bodies []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if want, got := "secret", r.Header.Get("X-Api-Key"); got != want {
+			t.Errorf("unexpected header: want: %q; got: %q", want, got)
+		}
+		if want, got := "/ingest", r.URL.Path; got != want {
+			t.Errorf("unexpected path: want: %q; got: %q", want, got)
+		}
+		if want, got := "gzip", r.Header.Get("Content-Encoding"); got != want {
+			t.Errorf("unexpected content encoding: want: %q; got: %q", want, got)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if calls++; calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			return
+		}
+		b, _ := io.ReadAll(zr)
+		bodies = append(bodies, string(b))
+	}))
+	defer srv.Close()
+
+	rawURL := "webhook+http://" + strings.TrimPrefix(srv.URL, "http://") +
+		"/ingest?format=array&content-encoding=gzip&header=X-Api-Key:secret"
+	out, err := output.Open(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	log, sink := zapr.NewLogger(zapr.WithWriteSyncer(out))
+	log.Info("hello", "n", 1)
+	log.Info("world", "n", 2)
+	if err := sink.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want, got := 2, calls; got != want {
+		t.Errorf("unexpected requests: want: %d; got: %d", want, got)
+	}
+	if len(bodies) != 1 {
+		t.Fatalf("unexpected bodies: want: 1; got: %d", len(bodies))
+	}
+	t.Log("\n" + bodies[0]) // help debugging
+	var entries []struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(bodies[0]), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Message != "hello" || entries[1].Message != "world" {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	for _, cfg := range []Config{
+		{URL: "ftp://example.com"},
+		{URL: "https://example.com", Format: "xml"},
+		{URL: "https://example.com", ContentEncoding: "lzw"},
+	} {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("expected error for config: %+v", cfg)
+		}
+	}
+}
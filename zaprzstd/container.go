@@ -0,0 +1,247 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zaprzstd
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"bursavich.dev/zapr/output"
+	"github.com/klauspost/compress/zstd"
+)
+
+// The container format is a valid zstd stream. It begins with a skippable
+// frame whose content is the container magic string followed by the JSON
+// encoded Header. It's followed by zstd frames whose decompressed content is
+// a sequence of entries, each of which is a uvarint length followed by that
+// many bytes. Each frame holds complete entries, so that all complete frames
+// of a truncated file can be read. Appending a container to another results
+// in a valid container, whose subsequent headers are ignored.
+const (
+	containerMagic     = "ZAPRLOG1"
+	containerFrameType = 0x184D2A5A // a zstd skippable frame magic number
+	maxHeaderSize      = 1 << 20
+	maxEntrySize       = 64 << 20
+)
+
+// ContainerVersion is the version of the container format.
+const ContainerVersion = 1
+
+// Header is the header of a container.
+type Header struct {
+	// Version is the version of the container format.
+	// It's set by NewContainer.
+	Version int `json:"version"`
+
+	// Schema is an optional description of the entries,
+	// such as the JSON schema returned by zapr.JSONSchema.
+	Schema json.RawMessage `json:"schema,omitempty"`
+
+	// Metadata is optional information about the entries.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// NewContainer returns an Output that writes each write to out as an entry of
+// a zstd compressed container, which may be read by NewContainerReader.
+// A zstd frame is completed when Sync is called and, if interval is positive,
+// no later than interval after its first entry. Closing the returned Output
+// completes the last frame and closes out.
+func NewContainer(out output.Output, hdr Header, interval time.Duration) (output.Output, error) {
+	hdr.Version = ContainerVersion
+	b, err := json.Marshal(&hdr)
+	if err != nil {
+		return nil, fmt.Errorf("zapr: invalid container header: %w", err)
+	}
+	b = append([]byte(containerMagic), b...)
+	if len(b) > maxHeaderSize {
+		return nil, errors.New("zapr: container header too large")
+	}
+	frame := make([]byte, 8, 8+len(b))
+	binary.LittleEndian.PutUint32(frame, containerFrameType)
+	binary.LittleEndian.PutUint32(frame[4:], uint32(len(b)))
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return &containerOutput{
+		out:      out,
+		enc:      enc,
+		interval: interval,
+		header:   append(frame, b...),
+	}, nil
+}
+
+type containerOutput struct {
+	out      output.Output
+	enc      *zstd.Encoder
+	interval time.Duration
+
+	mu     sync.Mutex
+	header []byte // unwritten header
+	open   bool   // whether a frame is open
+	lenBuf [binary.MaxVarintLen64]byte
+	timer  *time.Timer
+	err    error
+	closed bool
+}
+
+func (o *containerOutput) Write(b []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if o.err != nil {
+		return 0, o.err
+	}
+	if !o.open {
+		if o.header != nil {
+			if _, o.err = o.out.Write(o.header); o.err != nil {
+				return 0, o.err
+			}
+			o.header = nil
+		}
+		o.enc.Reset(o.out)
+		o.open = true
+		if o.interval > 0 {
+			if o.timer == nil {
+				o.timer = time.AfterFunc(o.interval, o.flushTimer)
+			} else {
+				o.timer.Reset(o.interval)
+			}
+		}
+	}
+	n := binary.PutUvarint(o.lenBuf[:], uint64(len(b)))
+	if _, o.err = o.enc.Write(o.lenBuf[:n]); o.err != nil {
+		return 0, o.err
+	}
+	if _, o.err = o.enc.Write(b); o.err != nil {
+		return 0, o.err
+	}
+	return len(b), nil
+}
+
+func (o *containerOutput) flushTimer() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if !o.closed {
+		o.flush() // Errors are sticky and resurface later.
+	}
+}
+
+// flush completes the open frame, if any.
+func (o *containerOutput) flush() error {
+	if o.err != nil || !o.open {
+		return o.err
+	}
+	o.open = false
+	o.err = o.enc.Close()
+	return o.err
+}
+
+func (o *containerOutput) Sync() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return nil
+	}
+	if err := o.flush(); err != nil {
+		return err
+	}
+	return o.out.Sync()
+}
+
+func (o *containerOutput) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return nil
+	}
+	o.closed = true
+	if o.timer != nil {
+		o.timer.Stop()
+	}
+	err := o.flush()
+	if serr := o.out.Sync(); err == nil {
+		err = serr
+	}
+	if cerr := o.out.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// A ContainerReader reads entries from a container written by NewContainer.
+type ContainerReader struct {
+	hdr Header
+	dec *zstd.Decoder
+	br  *bufio.Reader
+}
+
+// NewContainerReader returns a ContainerReader which reads from r,
+// after reading the container's header.
+func NewContainerReader(r io.Reader) (*ContainerReader, error) {
+	var frame [8]byte
+	if _, err := io.ReadFull(r, frame[:]); err != nil {
+		return nil, fmt.Errorf("zapr: failed to read container header: %w", err)
+	}
+	size := binary.LittleEndian.Uint32(frame[4:])
+	if binary.LittleEndian.Uint32(frame[:]) != containerFrameType || size > maxHeaderSize {
+		return nil, errors.New("zapr: invalid container header")
+	}
+	b := make([]byte, size)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, fmt.Errorf("zapr: failed to read container header: %w", err)
+	}
+	if len(b) < len(containerMagic) || string(b[:len(containerMagic)]) != containerMagic {
+		return nil, errors.New("zapr: invalid container header")
+	}
+	var hdr Header
+	if err := json.Unmarshal(b[len(containerMagic):], &hdr); err != nil {
+		return nil, fmt.Errorf("zapr: invalid container header: %w", err)
+	}
+	if hdr.Version != ContainerVersion {
+		return nil, fmt.Errorf("zapr: unsupported container version: %d", hdr.Version)
+	}
+	dec, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return &ContainerReader{hdr: hdr, dec: dec, br: bufio.NewReader(dec)}, nil
+}
+
+// Header returns the container's header.
+func (r *ContainerReader) Header() Header { return r.hdr }
+
+// Next returns the next entry. It returns io.EOF if there are no more entries
+// and io.ErrUnexpectedEOF if the container ends with an incomplete entry.
+func (r *ContainerReader) Next() ([]byte, error) {
+	n, err := binary.ReadUvarint(r.br)
+	if err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, io.ErrUnexpectedEOF
+	}
+	if n > maxEntrySize {
+		return nil, fmt.Errorf("zapr: invalid container entry size: %d", n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r.br, b); err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return b, nil
+}
+
+// Close releases the resources of the ContainerReader.
+func (r *ContainerReader) Close() { r.dec.Close() }
@@ -0,0 +1,76 @@
+package zaprzstd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"bursavich.dev/zapr"
+)
+
+type bufferOutput struct{ bytes.Buffer }
+
+func (*bufferOutput) Sync() error  { return nil }
+func (*bufferOutput) Close() error { return nil }
+
+func TestContainer(t *testing.T) {
+	buf := &bufferOutput{}
+	write := func(msgs ...string) {
+		out, err := NewContainer(buf, Header{Metadata: map[string]string{"app": "test"}}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		log, sink := zapr.NewLogger(zapr.WithWriteSyncer(out), zapr.WithTimeKey(""))
+		for _, msg := range msgs {
+			log.Info(msg)
+			sink.Flush() // one frame per entry
+		}
+		if err := out.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a", "b")
+	write("c") // appended containers are valid
+
+	read := func(b []byte) ([]string, error) {
+		r, err := NewContainerReader(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+		if want, got := "test", r.Header().Metadata["app"]; got != want {
+			t.Errorf("unexpected header metadata: want: %q; got: %q", want, got)
+		}
+		var msgs []string
+		for {
+			b, err := r.Next()
+			if err != nil {
+				return msgs, err
+			}
+			var entry struct {
+				Message string `json:"message"`
+			}
+			if err := json.Unmarshal(b, &entry); err != nil {
+				t.Fatal(err)
+			}
+			msgs = append(msgs, entry.Message)
+		}
+	}
+	msgs, err := read(buf.Bytes())
+	if err != io.EOF {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if want, got := "a,b,c", strings.Join(msgs, ","); got != want {
+		t.Errorf("unexpected entries: want: %q; got: %q", want, got)
+	}
+
+	msgs, err = read(buf.Bytes()[:buf.Len()-4])
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("unexpected truncation error: %v", err)
+	}
+	if want, got := "a,b", strings.Join(msgs, ","); got != want {
+		t.Errorf("unexpected truncated entries: want: %q; got: %q", want, got)
+	}
+}
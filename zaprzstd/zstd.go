@@ -0,0 +1,55 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Copyright 2023 Andy Bursavich. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package zaprzstd provides a zstd output Compressor for zapr and
+// a zstd compressed container format for archiving entries.
+//
+// Importing the package registers the "zstd" Compressor:
+//
+//	import _ "bursavich.dev/zapr/zaprzstd"
+package zaprzstd
+
+import (
+	"fmt"
+	"io"
+
+	"bursavich.dev/zapr/output"
+	"github.com/klauspost/compress/zstd"
+)
+
+type compressor struct{ level zstd.EncoderLevel }
+
+func (compressor) Name() string { return "zstd" }
+
+func (c compressor) NewWriter(w io.Writer) (output.CompressWriter, error) {
+	return zstd.NewWriter(w, zstd.WithEncoderConcurrency(1), zstd.WithEncoderLevel(c.level))
+}
+
+func (compressor) WithLevel(level output.CompressLevel) (output.Compressor, error) {
+	switch level {
+	case output.CompressFastest:
+		return compressor{zstd.SpeedFastest}, nil
+	case output.CompressDefault:
+		return compressor{zstd.SpeedDefault}, nil
+	case output.CompressBetter:
+		return compressor{zstd.SpeedBetterCompression}, nil
+	case output.CompressBest:
+		return compressor{zstd.SpeedBestCompression}, nil
+	}
+	return nil, fmt.Errorf("zapr: unknown compression level: %q", level)
+}
+
+var zstdCompressor = output.Compressor(compressor{zstd.SpeedDefault})
+
+func init() {
+	if err := output.RegisterCompressor(zstdCompressor); err != nil {
+		panic(err)
+	}
+}
+
+// Compressor compresses output in the zstd format.
+// It's an output.LeveledCompressor.
+func Compressor() output.Compressor { return zstdCompressor }